@@ -0,0 +1,154 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"rpc/internal/lms"
+	"rpc/internal/local"
+	"rpc/internal/rpc"
+	"rpc/pkg/utils"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// localCommands manage the activated device over WS-Management through LMS instead of contacting RPS
+var localCommands = map[string]func(flags rpc.Flags, w local.WSMan) (local.Output, error){
+	"power":     runPower,
+	"features":  runFeatures,
+	"eventlog":  runEventLog,
+	"auditlog":  runAuditLog,
+	"inventory": runInventory,
+	"boot":      runBoot,
+	"users":     runUsers,
+	"network":   runNetwork,
+}
+
+func runPower(flags rpc.Flags, w local.WSMan) (local.Output, error) {
+	if flags.SubCommand == "status" {
+		return local.GetPowerStatus(w)
+	}
+	return local.RequestPowerAction(w, flags.SubCommand)
+}
+
+func runFeatures(flags rpc.Flags, w local.WSMan) (local.Output, error) {
+	if flags.SubCommand == "set" {
+		return local.SetFeatures(w, flags.Features)
+	}
+	return local.GetFeatures(w)
+}
+
+func runEventLog(flags rpc.Flags, w local.WSMan) (local.Output, error) {
+	return local.ReadEventLog(w, flags.Since)
+}
+
+func runAuditLog(flags rpc.Flags, w local.WSMan) (local.Output, error) {
+	return local.ReadAuditLog(w, flags.Since)
+}
+
+func runInventory(flags rpc.Flags, w local.WSMan) (local.Output, error) {
+	return local.ReadHardwareInventory(w, flags.CompareOS)
+}
+
+func runBoot(flags rpc.Flags, w local.WSMan) (local.Output, error) {
+	if flags.SubCommand == "set" {
+		return local.SetNextBoot(w, flags.BootDevice, flags.BootReset)
+	}
+	return local.GetBootSettings(w)
+}
+
+func runUsers(flags rpc.Flags, w local.WSMan) (local.Output, error) {
+	switch flags.SubCommand {
+	case "add":
+		return local.AddUser(w, flags.User)
+	case "remove":
+		return local.RemoveUser(w, flags.User.Username)
+	case "update":
+		if flags.UserConfig == "" {
+			return local.UpdateUser(w, flags.User)
+		}
+		config, err := local.ReadUserConfig(flags.UserConfig)
+		if err != nil {
+			return nil, err
+		}
+		return local.ApplyUsers(w, config, flags.PruneUsers)
+	}
+	return local.ListUsers(w)
+}
+
+func runNetwork(flags rpc.Flags, w local.WSMan) (local.Output, error) {
+	switch flags.SubCommand {
+	case "wired":
+		return local.SetWired(w, flags.Wired)
+	case "wireless":
+		return local.SetWireless(w, flags.Wireless)
+	case "addprofile":
+		profile := flags.WiFiProfile
+		if flags.CACertFile != "" {
+			certificate, err := ioutil.ReadFile(flags.CACertFile)
+			if err != nil {
+				return nil, err
+			}
+			profile.CACertificate = certificate
+		}
+		return local.AddWiFiProfile(w, profile)
+	case "removeprofile":
+		return local.RemoveWiFiProfile(w, flags.WiFiProfile.Name)
+	}
+	return local.GetNetworkSettings(w)
+}
+
+// ensureLMS starts the built in LMS unless one is already listening on the LMS port
+func ensureLMS() {
+	connection := lms.LMSConnection{}
+	err := connection.Connect(utils.LMSAddress, utils.LMSPort)
+	if err == nil {
+		connection.Close()
+		return
+	}
+	log.Trace("starting LMS")
+	go connection.InitiateLMS()
+	time.Sleep(5 * time.Second)
+}
+
+// runLocal runs a local command and exits with a non-zero code if it failed
+func runLocal(run func(flags rpc.Flags, w local.WSMan) (local.Output, error), flags rpc.Flags) {
+	ensureLMS()
+	output, err := run(flags, local.NewWSMan(flags.Password))
+	if err != nil {
+		// print the changes made before the error, the device configuration is no longer what it was
+		if changed, ok := output.(local.ChangeOutput); ok && changed.Changed() {
+			printLocal(output, flags)
+		}
+		log.Fatal(err)
+	}
+	printLocal(output, flags)
+	if output.Failed() {
+		os.Exit(1)
+	}
+}
+
+func printLocal(output local.Output, flags rpc.Flags) {
+	csvOutput, isCSV := output.(local.CSVOutput)
+	if flags.CSVOutput && isCSV {
+		csv, err := csvOutput.CSV()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Print(csv)
+	} else if flags.JsonOutput {
+		outBytes, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(outBytes))
+	} else {
+		fmt.Print(output.String())
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"os"
 	"os/signal"
 	"rpc/internal/amt"
+	"rpc/internal/check"
+	"rpc/internal/diag"
 	"rpc/internal/lms"
 	"rpc/internal/rpc"
 	"rpc/internal/rps"
@@ -24,18 +26,83 @@ func checkAccess() {
 	amt := amt.NewAMTCommand()
 	result, err := amt.Initialize()
 	if !result || err != nil {
-		println("Unable to launch application. Please ensure that Intel ME is present, the MEI driver is installed and that this application is run with administrator or root privileges.")
+		os.Exit(rpc.PrintAccessError(err))
+	}
+}
+
+// checkCapabilities refuses commands the AMT firmware version does not support before contacting RPS
+func checkCapabilities(command string) {
+	amtCommand := amt.NewAMTCommand()
+	result, err := amtCommand.GetVersionDataFromME("AMT")
+	if err != nil || result == "" {
+		log.Warn("unable to read AMT version, skipping capability check")
+		return
+	}
+	version, err := amt.ParseVersion(result)
+	if err != nil {
+		log.Warn("unable to parse AMT version ", result, ", skipping capability check")
+		return
+	}
+	err = amt.RequireCapabilities(version, command)
+	if err != nil {
+		println(err.Error())
+		os.Exit(utils.UnsupportedFirmware)
+	}
+}
+
+// dryRun displays the redacted request and preflight results without contacting RPS or starting LMS
+func dryRun(payload rps.Payload, flags rpc.Flags, messageRequest rps.RPSMessage) {
+	report, err := payload.CreateDryRunReport(flags, messageRequest)
+	if err != nil {
+		log.Fatal(err)
+	}
+	outBytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(outBytes))
+	if report.Failed() {
 		os.Exit(1)
 	}
 }
-func main() {
 
-	checkAccess()
+// runCheck verifies the activation prerequisites and exits with a non-zero code if any failed
+func runCheck(flags rpc.Flags) {
+	report := check.NewChecker(flags).Run()
+	if flags.JsonOutput {
+		fmt.Println(report.JSON())
+	} else {
+		fmt.Println(report.String())
+	}
+	if report.Failed() {
+		os.Exit(1)
+	}
+}
+
+// runDiag writes the diagnostics bundle to the requested or default location
+func runDiag(flags rpc.Flags) {
+	path := flags.OutputFile
+	if path == "" {
+		path = diag.DefaultBundleName()
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+	err = diag.WriteBundle(file, diag.NewCollector(flags).Collect())
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Diagnostics written to " + path)
+}
+
+func main() {
 	//process flags
 	flags := rpc.NewFlags(os.Args)
-	_, result := flags.ParseFlags()
+	command, result := flags.ParseFlags()
 	if !result {
-		os.Exit(1)
+		os.Exit(flags.ExitCode)
 	}
 	if flags.SyncClock {
 		fmt.Println("Time to sync the clock")
@@ -48,6 +115,22 @@ func main() {
 	if flags.JsonOutput {
 		log.SetFormatter(&log.JSONFormatter{})
 	}
+	if command == "check" {
+		runCheck(*flags)
+		return
+	}
+	if command == "diag" {
+		runDiag(*flags)
+		return
+	}
+	checkAccess()
+	if run, ok := localCommands[command]; ok {
+		runLocal(run, *flags)
+		return
+	}
+	if !flags.DryRun {
+		checkCapabilities(command)
+	}
 
 	//create activation request
 	payload := rps.Payload{
@@ -57,6 +140,10 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	if flags.DryRun {
+		dryRun(payload, *flags, messageRequest)
+		return
+	}
 
 	//try to connect to an existing LMS instance
 	log.Trace("Seeing if existing LMS is already running....")
@@ -80,6 +167,13 @@ func main() {
 	amtactivationserver := rps.AMTActivationServer{
 		URL: flags.URL,
 	}
+	transcriptFile, err := rps.CreateTranscriptFile()
+	if err != nil {
+		log.Warn("unable to create session transcript: ", err)
+	} else {
+		defer transcriptFile.Close()
+		amtactivationserver.Transcript = rps.NewTranscript(transcriptFile)
+	}
 
 	err = amtactivationserver.Connect(flags.SkipCertCheck)
 	if err != nil {
@@ -0,0 +1,152 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package amt
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"rpc/pkg/heci"
+	"rpc/pkg/utils"
+	"strings"
+	"syscall"
+)
+
+// AccessError describes why rpc is unable to communicate with the AMT host interface
+type AccessError struct {
+	Cause       string
+	Remediation string
+	ExitCode    int
+	Err         error
+}
+
+func (e *AccessError) Error() string {
+	return e.Cause
+}
+
+func (e *AccessError) Unwrap() error {
+	return e.Err
+}
+
+// accessProbe inspects the system to explain an access failure
+type accessProbe struct {
+	stat     func(name string) (os.FileInfo, error)
+	glob     func(pattern string) ([]string, error)
+	readFile func(name string) ([]byte, error)
+	elevated func() bool
+}
+
+var defaultAccessProbe = accessProbe{
+	stat:     os.Stat,
+	glob:     filepath.Glob,
+	readFile: ioutil.ReadFile,
+	elevated: elevated,
+}
+
+// euidElevated reports whether the effective user is root, a negative euid means the platform has none
+func euidElevated(geteuid func() int) bool {
+	return geteuid() == 0
+}
+
+// diagnoseAccessError converts a failure to connect to a firmware client into an AccessError
+func diagnoseAccessError(err error, client heci.Client, probe accessProbe) *AccessError {
+	var pathError *os.PathError
+	openFailed := errors.As(err, &pathError)
+
+	switch {
+	case openFailed && (errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ENODEV) || errors.Is(err, syscall.ENXIO)):
+		if probe.inContainer() {
+			return &AccessError{
+				Cause:       "MEI device is not available inside this container",
+				Remediation: "Pass the device through to the container, for example: docker run --device /dev/mei0",
+				ExitCode:    utils.ContainerDeviceMissing,
+				Err:         err,
+			}
+		}
+		if !probe.driverLoaded() {
+			return &AccessError{
+				Cause:       "MEI driver is not loaded",
+				Remediation: "Load the driver with 'modprobe mei_me' on Linux, or install the Intel Management Engine Interface driver on Windows",
+				ExitCode:    utils.DriverNotLoaded,
+				Err:         err,
+			}
+		}
+		return &AccessError{
+			Cause:       "MEI device node not found",
+			Remediation: "Ensure Intel ME is enabled in the BIOS and that udev has created /dev/mei0, or select the device with --mei-device",
+			ExitCode:    utils.DeviceNotFound,
+			Err:         err,
+		}
+	case errors.Is(err, os.ErrPermission) || errors.Is(err, syscall.EACCES) || errors.Is(err, syscall.EPERM):
+		if !probe.elevated() {
+			return &AccessError{
+				Cause:       "permission denied, rpc is not running as root",
+				Remediation: "Run rpc with sudo or as root, or as Administrator on Windows",
+				ExitCode:    utils.NotRoot,
+				Err:         err,
+			}
+		}
+		return &AccessError{
+			Cause:       "permission denied opening the MEI device",
+			Remediation: "rpc has administrator privileges but access was still denied, check SELinux or AppArmor policy and the permissions on /dev/mei0",
+			ExitCode:    utils.PermissionDenied,
+			Err:         err,
+		}
+	case errors.Is(err, syscall.EBUSY) || errors.Is(err, syscall.EAGAIN):
+		return &AccessError{
+			Cause:       "firmware is busy",
+			Remediation: "Another application may be connected to the AMT host interface or the firmware is resetting, stop other AMT tools and retry",
+			ExitCode:    utils.FirmwareBusy,
+			Err:         err,
+		}
+	case !openFailed && (errors.Is(err, syscall.ENOTTY) || errors.Is(err, syscall.ENODEV) || errors.Is(err, os.ErrNotExist)):
+		accessError := &AccessError{
+			Cause:       client.Name + " client not found",
+			Remediation: "The firmware does not expose the " + client.Name + " client, check that Intel ME is enabled in the BIOS",
+			ExitCode:    utils.ClientNotFound,
+			Err:         err,
+		}
+		if client == heci.AMTHI {
+			accessError.Cause = "AMT host interface (AMTHI) client not found"
+			accessError.Remediation = "Intel AMT may be disabled in the BIOS or MEBx, or this SKU does not support Intel AMT"
+		}
+		return accessError
+	}
+	return &AccessError{
+		Cause:       "unable to initialize: " + err.Error(),
+		Remediation: utils.AccessErrorMessage,
+		ExitCode:    utils.GenericFailure,
+		Err:         err,
+	}
+}
+
+func (probe accessProbe) inContainer() bool {
+	for _, path := range []string{"/.dockerenv", "/run/.containerenv"} {
+		if _, err := probe.stat(path); err == nil {
+			return true
+		}
+	}
+	cgroup, err := probe.readFile("/proc/1/cgroup")
+	if err != nil {
+		return false
+	}
+	for _, runtime := range []string{"docker", "kubepods", "containerd", "lxc", "libpod"} {
+		if strings.Contains(string(cgroup), runtime) {
+			return true
+		}
+	}
+	return false
+}
+
+func (probe accessProbe) driverLoaded() bool {
+	for _, path := range []string{"/sys/module/mei_me", "/sys/module/mei_txe"} {
+		if _, err := probe.stat(path); err == nil {
+			return true
+		}
+	}
+	devices, err := probe.glob("/sys/class/mei/mei*")
+	return err == nil && len(devices) > 0
+}
@@ -0,0 +1,121 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package amt
+
+import (
+	"errors"
+	"os"
+	"rpc/pkg/heci"
+	"rpc/pkg/utils"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestProbe(existing []string, meiDevices []string, cgroup string, euid int) accessProbe {
+	return accessProbe{
+		stat: func(name string) (os.FileInfo, error) {
+			for _, path := range existing {
+				if path == name {
+					return nil, nil
+				}
+			}
+			return nil, os.ErrNotExist
+		},
+		glob: func(pattern string) ([]string, error) {
+			return meiDevices, nil
+		},
+		readFile: func(name string) ([]byte, error) {
+			if cgroup == "" {
+				return nil, os.ErrNotExist
+			}
+			return []byte(cgroup), nil
+		},
+		elevated: func() bool {
+			return euidElevated(func() int { return euid })
+		},
+	}
+}
+
+func openError(errno syscall.Errno) error {
+	return &os.PathError{Op: "open", Path: "/dev/mei0", Err: errno}
+}
+
+func TestDiagnoseAccessErrorContainer(t *testing.T) {
+	probe := newTestProbe([]string{"/.dockerenv"}, nil, "", 0)
+	result := diagnoseAccessError(openError(syscall.ENOENT), heci.AMTHI, probe)
+	assert.Equal(t, utils.ContainerDeviceMissing, result.ExitCode)
+}
+
+func TestDiagnoseAccessErrorContainerCgroup(t *testing.T) {
+	probe := newTestProbe(nil, nil, "12:pids:/kubepods/besteffort/pod1", 0)
+	result := diagnoseAccessError(openError(syscall.ENOENT), heci.AMTHI, probe)
+	assert.Equal(t, utils.ContainerDeviceMissing, result.ExitCode)
+}
+
+func TestDiagnoseAccessErrorDriverNotLoaded(t *testing.T) {
+	probe := newTestProbe(nil, nil, "0::/", 0)
+	result := diagnoseAccessError(openError(syscall.ENOENT), heci.AMTHI, probe)
+	assert.Equal(t, utils.DriverNotLoaded, result.ExitCode)
+	assert.Contains(t, result.Remediation, "modprobe mei_me")
+}
+
+func TestDiagnoseAccessErrorDeviceNotFound(t *testing.T) {
+	probe := newTestProbe([]string{"/sys/module/mei_me"}, nil, "", 0)
+	result := diagnoseAccessError(openError(syscall.ENODEV), heci.AMTHI, probe)
+	assert.Equal(t, utils.DeviceNotFound, result.ExitCode)
+}
+
+func TestDiagnoseAccessErrorDeviceNotFoundSysfs(t *testing.T) {
+	probe := newTestProbe(nil, []string{"/sys/class/mei/mei0"}, "", 0)
+	result := diagnoseAccessError(openError(syscall.ENOENT), heci.AMTHI, probe)
+	assert.Equal(t, utils.DeviceNotFound, result.ExitCode)
+}
+
+func TestDiagnoseAccessErrorNotRoot(t *testing.T) {
+	probe := newTestProbe(nil, nil, "", 1000)
+	result := diagnoseAccessError(openError(syscall.EACCES), heci.AMTHI, probe)
+	assert.Equal(t, utils.NotRoot, result.ExitCode)
+}
+
+func TestDiagnoseAccessErrorNoEffectiveUser(t *testing.T) {
+	probe := newTestProbe(nil, nil, "", -1)
+	result := diagnoseAccessError(openError(syscall.EACCES), heci.AMTHI, probe)
+	assert.Equal(t, utils.NotRoot, result.ExitCode)
+}
+
+func TestDiagnoseAccessErrorPermissionDenied(t *testing.T) {
+	probe := newTestProbe(nil, nil, "", 0)
+	result := diagnoseAccessError(openError(syscall.EPERM), heci.AMTHI, probe)
+	assert.Equal(t, utils.PermissionDenied, result.ExitCode)
+}
+
+func TestDiagnoseAccessErrorFirmwareBusy(t *testing.T) {
+	probe := newTestProbe(nil, nil, "", 0)
+	result := diagnoseAccessError(syscall.EBUSY, heci.AMTHI, probe)
+	assert.Equal(t, utils.FirmwareBusy, result.ExitCode)
+}
+
+func TestDiagnoseAccessErrorClientNotFound(t *testing.T) {
+	probe := newTestProbe(nil, nil, "", 0)
+	result := diagnoseAccessError(syscall.ENOTTY, heci.AMTHI, probe)
+	assert.Equal(t, utils.ClientNotFound, result.ExitCode)
+}
+
+func TestDiagnoseAccessErrorOtherClientNotFound(t *testing.T) {
+	probe := newTestProbe(nil, nil, "", 0)
+	result := diagnoseAccessError(syscall.ENOTTY, heci.MKHI, probe)
+	assert.Equal(t, utils.ClientNotFound, result.ExitCode)
+	assert.Equal(t, "MKHI client not found", result.Cause)
+}
+
+func TestDiagnoseAccessErrorGeneric(t *testing.T) {
+	probe := newTestProbe(nil, nil, "", 0)
+	cause := errors.New("unexpected")
+	result := diagnoseAccessError(cause, heci.AMTHI, probe)
+	assert.Equal(t, utils.GenericFailure, result.ExitCode)
+	assert.True(t, errors.Is(result, cause))
+}
@@ -0,0 +1,87 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package amt
+
+import "fmt"
+
+// Capability names an operation whose support depends on the AMT firmware version
+type Capability string
+
+const (
+	// RemoteProvisioning is activation, deactivation and maintenance through RPS
+	RemoteProvisioning Capability = "remote provisioning through RPS"
+	// WirelessSync is synchronization of wireless profiles with the OS
+	WirelessSync Capability = "wireless profile synchronization"
+	// UUIDLittleEndian means the first three UUID fields are reported little endian as in SMBIOS
+	UUIDLittleEndian Capability = "little endian UUID byte order"
+)
+
+// CapabilityRequirement is the first AMT version that supports a capability
+type CapabilityRequirement struct {
+	Capability Capability
+	Minimum    Version
+}
+
+// CapabilityTable describes the operations each AMT major.minor version supports
+var CapabilityTable = []CapabilityRequirement{
+	{Capability: UUIDLittleEndian, Minimum: Version{Major: 9}},
+	{Capability: WirelessSync, Minimum: Version{Major: 11}},
+	{Capability: RemoteProvisioning, Minimum: Version{Major: 11, Minor: 8}},
+}
+
+// CommandCapabilities lists the capabilities each rpc command requires
+var CommandCapabilities = map[string][]Capability{
+	"activate":    {RemoteProvisioning},
+	"deactivate":  {RemoteProvisioning},
+	"maintenance": {RemoteProvisioning},
+}
+
+// Supports reports whether version supports capability. Unknown capabilities are not supported.
+func (v Version) Supports(capability Capability) bool {
+	for _, requirement := range CapabilityTable {
+		if requirement.Capability == capability {
+			return v.AtLeast(requirement.Minimum)
+		}
+	}
+	return false
+}
+
+// Capabilities lists every capability supported by version
+func (v Version) Capabilities() []Capability {
+	capabilities := []Capability{}
+	for _, requirement := range CapabilityTable {
+		if v.AtLeast(requirement.Minimum) {
+			capabilities = append(capabilities, requirement.Capability)
+		}
+	}
+	return capabilities
+}
+
+// RequireCapabilities returns the RequireCapability error of the first capability the command needs that version does not support
+func RequireCapabilities(version Version, command string) error {
+	for _, capability := range CommandCapabilities[command] {
+		if err := RequireCapability(version, capability); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RequireCapability returns an error naming the minimum version when version does not support capability
+func RequireCapability(version Version, capability Capability) error {
+	if !version.Supports(capability) {
+		return fmt.Errorf("AMT %s does not support %s, which requires AMT %s or later", version, capability, minimumVersion(capability))
+	}
+	return nil
+}
+
+func minimumVersion(capability Capability) string {
+	for _, requirement := range CapabilityTable {
+		if requirement.Capability == capability {
+			return fmt.Sprintf("%d.%d", requirement.Minimum.Major, requirement.Minimum.Minor)
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,39 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package amt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupports(t *testing.T) {
+	version := Version{Major: 11, Minor: 6, Patch: 10}
+	assert.True(t, version.Supports(WirelessSync))
+	assert.False(t, version.Supports(RemoteProvisioning))
+	assert.False(t, version.Supports(Capability("unknown")))
+	assert.True(t, Version{Major: 11, Minor: 8}.Supports(RemoteProvisioning))
+	assert.True(t, Version{Major: 16, Minor: 1}.Supports(RemoteProvisioning))
+}
+
+func TestCapabilities(t *testing.T) {
+	result := Version{Major: 9, Minor: 5}.Capabilities()
+	assert.Equal(t, []Capability{UUIDLittleEndian}, result)
+	assert.Empty(t, Version{Major: 6, Minor: 2}.Capabilities())
+}
+
+func TestRequireCapabilities(t *testing.T) {
+	err := RequireCapabilities(Version{Major: 11, Minor: 0, Patch: 25}, "activate")
+	assert.EqualError(t, err, "AMT 11.0.25 does not support remote provisioning through RPS, which requires AMT 11.8 or later")
+	assert.NoError(t, RequireCapabilities(Version{Major: 12}, "deactivate"))
+	assert.NoError(t, RequireCapabilities(Version{Major: 1}, "amtinfo"))
+}
+
+func TestRequireCapability(t *testing.T) {
+	err := RequireCapability(Version{Major: 10, Minor: 0, Patch: 55}, WirelessSync)
+	assert.EqualError(t, err, "AMT 10.0.55 does not support wireless profile synchronization, which requires AMT 11.0 or later")
+	assert.NoError(t, RequireCapability(Version{Major: 11, Minor: 8}, WirelessSync))
+}
@@ -5,14 +5,17 @@
 package amt
 
 import (
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net"
-	"os"
+	"rpc/pkg/dnssuffix"
+	"rpc/pkg/heci"
+	"rpc/pkg/mkhi"
 	"rpc/pkg/pthi"
+	"rpc/pkg/smbios"
 	"rpc/pkg/utils"
 	"strconv"
-	"strings"
 )
 
 //TODO: Ensure pointers are freed properly throughout this file
@@ -38,12 +41,13 @@ type CodeVersions struct {
 
 // InterfaceSettings ...
 type InterfaceSettings struct {
-	IsEnabled   bool   `json:"isEnable"`
-	LinkStatus  string `json:"linkStatus"`
-	DHCPEnabled bool   `json:"dhcpEnabled"`
-	DHCPMode    string `json:"dhcpMode"`
-	IPAddress   string `json:"ipAddress"` //net.IP
-	MACAddress  string `json:"macAddress"`
+	IsEnabled   bool         `json:"isEnable"`
+	LinkStatus  string       `json:"linkStatus"`
+	DHCPEnabled bool         `json:"dhcpEnabled"`
+	DHCPMode    string       `json:"dhcpMode"`
+	IPAddress   string       `json:"ipAddress"` //net.IP
+	MACAddress  string       `json:"macAddress"`
+	IPv6        IPv6Settings `json:"ipv6"`
 }
 
 // RemoteAccessStatus holds connect status information
@@ -75,6 +79,7 @@ type Interface interface {
 	GetUUID() (string, error)
 	GetControlMode() (int, error)
 	GetOSDNSSuffix() (string, error)
+	DetectOSDNSSuffix() (dnssuffix.Result, error)
 	GetDNSSuffix() (string, error)
 	GetCertificateHashes() ([]CertHashEntry, error)
 	GetRemoteAccessConnectionStatus() (RemoteAccessStatus, error)
@@ -82,22 +87,20 @@ type Interface interface {
 	GetLocalSystemAccount() (LocalSystemAccount, error)
 }
 
-func ANSI2String(ansi pthi.AMTANSIString) string {
-	output := ""
-	for i := 0; i < int(ansi.Length); i++ {
-		output = output + string(ansi.Buffer[i])
-	}
-
-	return output
+// ANSI2String converts an AMT string, failing if its length exceeds the buffer
+func ANSI2String(ansi pthi.AMTANSIString) (string, error) {
+	return ansi.Decode()
 }
 
 type AMTCommand struct {
 	PTHI pthi.Interface
+	MKHI mkhi.Interface
 }
 
 func NewAMTCommand() AMTCommand {
 	return AMTCommand{
 		PTHI: pthi.NewCommand(),
+		MKHI: mkhi.NewCommand(),
 	}
 }
 
@@ -106,7 +109,7 @@ func (amt AMTCommand) Initialize() (bool, error) {
 	// initialize HECI interface
 	err := amt.PTHI.Open()
 	if err != nil {
-		return false, errors.New("unable to initialize")
+		return false, diagnoseAccessError(err, heci.AMTHI, defaultAccessProbe)
 	}
 	defer amt.PTHI.Close()
 	return true, nil
@@ -124,18 +127,30 @@ func (amt AMTCommand) GetVersionDataFromME(key string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	err = result.CodeVersion.Validate()
+	if err != nil {
+		return "", err
+	}
 
 	for i := 0; i < int(result.CodeVersion.VersionsCount); i++ {
-		if string(result.CodeVersion.Versions[i].Description.String[:result.CodeVersion.Versions[i].Description.Length]) == key {
-			return strings.Replace(string(result.CodeVersion.Versions[i].Version.String[:]), "\u0000", "", -1), nil
+		description, _ := result.CodeVersion.Versions[i].Description.Decode()
+		if description == key {
+			return result.CodeVersion.Versions[i].Version.Decode()
 		}
 	}
 
 	return "", errors.New(key + " Not Found")
 }
 
-// GetUUID ...
+// GetUUID returns the platform UUID, swapping the first three fields on firmware that reports them little endian
 func (amt AMTCommand) GetUUID() (string, error) {
+	// assume the byte order of current firmware when the AMT version is not reported
+	littleEndian := true
+	if version, err := amt.GetVersionDataFromME("AMT"); err == nil {
+		if parsed, err := ParseVersion(version); err == nil {
+			littleEndian = parsed.Supports(UUIDLittleEndian)
+		}
+	}
 	err := amt.PTHI.Open()
 	if err != nil {
 		return "", nil
@@ -146,19 +161,10 @@ func (amt AMTCommand) GetUUID() (string, error) {
 		return "", err
 	}
 
-	var hexValues [16]string
-
-	for i := 0; i < 16; i++ {
-		hexValues[i] = fmt.Sprintf("%02x", int(result[i]))
+	if !littleEndian {
+		return smbios.FormatRawUUID([]byte(result))
 	}
-
-	uuidStr := hexValues[3] + hexValues[2] + hexValues[1] + hexValues[0] + "-" +
-		hexValues[5] + hexValues[4] + "-" +
-		hexValues[7] + hexValues[6] + "-" +
-		hexValues[8] + hexValues[9] + "-" +
-		hexValues[10] + hexValues[11] + hexValues[12] + hexValues[13] + hexValues[14] + hexValues[15]
-	return uuidStr, nil
-
+	return smbios.FormatUUID([]byte(result))
 }
 
 // GetControlMode ...
@@ -176,31 +182,24 @@ func (amt AMTCommand) GetControlMode() (int, error) {
 	return result, nil
 }
 
-// GetDNSSuffix ...
+// GetOSDNSSuffix returns the DNS suffix chosen by DetectOSDNSSuffix
 func (amt AMTCommand) GetOSDNSSuffix() (string, error) {
+	result, err := amt.DetectOSDNSSuffix()
+	return result.Suffix, err
+}
+
+// DetectOSDNSSuffix gathers suffix candidates for the OS interface sharing the AMT wired MAC address
+// and prefers one matching the AMT PKI DNS suffix, which must match the provisioning certificate
+func (amt AMTCommand) DetectOSDNSSuffix() (dnssuffix.Result, error) {
 	lanResult, _ := amt.GetLANInterfaceSettings(false)
+	preferred, _ := amt.GetDNSSuffix()
 	ifaces, _ := net.Interfaces()
 	for _, v := range ifaces {
 		if v.HardwareAddr.String() == lanResult.MACAddress {
-			addrs, _ := v.Addrs()
-			for _, a := range addrs {
-				networkIp, ok := a.(*net.IPNet)
-				if ok && !networkIp.IP.IsLoopback() && networkIp.IP.To4() != nil {
-					ip := networkIp.IP.String()
-					suffix, _ := net.LookupAddr(ip)
-					if len(suffix) > 0 {
-						hostname, _ := os.Hostname()
-						dnsSuffix := strings.Trim(suffix[0], hostname)
-						dnsSuffix = strings.TrimLeft(dnsSuffix, ".")
-						dnsSuffix = strings.TrimRight(dnsSuffix, ".")
-						return dnsSuffix, nil
-					}
-					return "", nil
-				}
-			}
+			return dnssuffix.NewDetector(&v).Detect(preferred), nil
 		}
 	}
-	return "", nil
+	return dnssuffix.NewDetector(nil).Detect(preferred), nil
 }
 
 func (amt AMTCommand) GetDNSSuffix() (string, error) {
@@ -232,16 +231,19 @@ func (amt AMTCommand) GetCertificateHashes() ([]CertHashEntry, error) {
 	// Convert pthi results to amt results
 	for _, pthiEntry := range pthiEntryList {
 
-		hashSize, algo := utils.InterpretHashAlgorithm(int(pthiEntry.HashAlgorithm))
-
-		hashString := ""
-		for i := 0; i < hashSize; i++ {
-			hashString = hashString + fmt.Sprintf("%02x", int(pthiEntry.CertificateHash[i]))
+		digest, err := pthiEntry.Digest()
+		if err != nil {
+			return []CertHashEntry{}, err
 		}
+		name, err := ANSI2String(pthiEntry.Name)
+		if err != nil {
+			return []CertHashEntry{}, err
+		}
+		_, algo := utils.InterpretHashAlgorithm(int(pthiEntry.HashAlgorithm))
 
 		amtEntry := CertHashEntry{
-			Hash:      hashString,
-			Name:      ANSI2String(pthiEntry.Name),
+			Hash:      hex.EncodeToString(digest),
+			Name:      name,
 			Algorithm: algo,
 			IsActive:  pthiEntry.IsActive > 0,
 			IsDefault: pthiEntry.IsDefault > 0,
@@ -265,11 +267,16 @@ func (amt AMTCommand) GetRemoteAccessConnectionStatus() (RemoteAccessStatus, err
 		return emptyRAStatus, err
 	}
 
+	hostname, err := ANSI2String(result.MPSHostname)
+	if err != nil {
+		return emptyRAStatus, err
+	}
+
 	RAStatus := RemoteAccessStatus{
 		NetworkStatus: utils.InterpretAMTNetworkConnectionStatus(int(result.NetworkStatus)),
 		RemoteStatus:  utils.InterpretRemoteAccessConnectionStatus(int(result.RemoteStatus)),
 		RemoteTrigger: utils.InterpretRemoteAccessTrigger(int(result.RemoteTrigger)),
-		MPSHostname:   ANSI2String(result.MPSHostname),
+		MPSHostname:   hostname,
 	}
 
 	return RAStatus, nil
@@ -293,6 +300,13 @@ func (amt AMTCommand) GetLANInterfaceSettings(useWireless bool) (InterfaceSettin
 		DHCPEnabled: result.DhcpEnabled == 1,
 		LinkStatus:  "down",
 		DHCPMode:    "passive",
+		IPv6:        IPv6Settings{Addresses: []IPv6Address{}},
+	}
+
+	// firmware without IPv6 support rejects the request, which leaves IPv6 reported as disabled
+	ipv6Result, err := amt.PTHI.GetIPv6LANInterfaceStatus(useWireless)
+	if err == nil {
+		settings.IPv6 = interpretIPv6Status(ipv6Result)
 	}
 
 	if result.LinkStatus == 1 {
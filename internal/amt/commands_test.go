@@ -5,6 +5,9 @@
 package amt
 
 import (
+	"errors"
+	"rpc/pkg/mefw"
+	"rpc/pkg/mkhi"
 	"rpc/pkg/pthi"
 	"testing"
 
@@ -79,6 +82,28 @@ func (c MockPTHICommands) GetLANInterfaceSettings(useWireless bool) (LANInterfac
 		}, nil
 	}
 }
+func (c MockPTHICommands) GetIPv6LANInterfaceStatus(useWireless bool) (IPv6Status pthi.GetIPv6LANInterfaceStatusResponse, err error) {
+	if useWireless {
+		return pthi.GetIPv6LANInterfaceStatusResponse{}, errors.New("not supported")
+	}
+	response := pthi.GetIPv6LANInterfaceStatusResponse{
+		Enabled:       1,
+		DefaultRouter: [16]uint8{0xfe, 0x80, 15: 1},
+		PrimaryDNS:    [16]uint8{0x20, 0x01, 0x0d, 0xb8, 15: 0x53},
+		AddressCount:  2,
+	}
+	response.Addresses[0] = pthi.IPv6AddressInfo{
+		Address: [16]uint8{0xfe, 0x80, 8: 0x02, 15: 0x07},
+		Type:    pthi.IPV6_ADDR_TYPE_LINK_LOCAL,
+		State:   pthi.IPV6_ADDR_STATE_PREFERRED,
+	}
+	response.Addresses[1] = pthi.IPv6AddressInfo{
+		Address: [16]uint8{0x20, 0x01, 0x0d, 0xb8, 15: 0x07},
+		Type:    pthi.IPV6_ADDR_TYPE_AUTO,
+		State:   pthi.IPV6_ADDR_STATE_PREFERRED,
+	}
+	return response, nil
+}
 func (c MockPTHICommands) GetLocalSystemAccount() (localAccount pthi.GetLocalSystemAccountResponse, err error) {
 	return pthi.GetLocalSystemAccountResponse{
 		Account: pthi.LocalSystemAccount{
@@ -101,12 +126,50 @@ func TestGetVersionDataFromME(t *testing.T) {
 	assert.Equal(t, "11.8.55", result)
 }
 
+// paddedVersionPTHICommands reports a version whose buffer holds bytes beyond its length
+type paddedVersionPTHICommands struct {
+	MockPTHICommands
+}
+
+func (c paddedVersionPTHICommands) GetCodeVersions() (pthi.GetCodeVersionsResponse, error) {
+	response := pthi.GetCodeVersionsResponse{}
+	response.CodeVersion.VersionsCount = 1
+	response.CodeVersion.Versions[0].Description = pthi.AMTUnicodeString{Length: 3, String: [20]uint8{65, 77, 84}}
+	response.CodeVersion.Versions[0].Version = pthi.AMTUnicodeString{Length: 6, String: [20]uint8{49, 54, 46, 49, 46, 50, 53, 88}}
+	return response, nil
+}
+
+func TestGetVersionDataFromMEUsesLength(t *testing.T) {
+	result, err := AMTCommand{PTHI: paddedVersionPTHICommands{}}.GetVersionDataFromME("AMT")
+	assert.NoError(t, err)
+	assert.Equal(t, "16.1.2", result)
+}
+
 func TestGetGUID(t *testing.T) {
 	result, err := amt.GetUUID()
 	assert.NoError(t, err)
 	assert.Equal(t, "1c113fd2-3325-4594-a272-54b2038beb07", result)
 }
 
+// amt8PTHICommands reports AMT 8.1.40, which returns the UUID with every field big endian
+type amt8PTHICommands struct {
+	MockPTHICommands
+}
+
+func (c amt8PTHICommands) GetCodeVersions() (pthi.GetCodeVersionsResponse, error) {
+	response := pthi.GetCodeVersionsResponse{}
+	response.CodeVersion.VersionsCount = 1
+	response.CodeVersion.Versions[0].Description = pthi.AMTUnicodeString{Length: 3, String: [20]uint8{65, 77, 84}}
+	response.CodeVersion.Versions[0].Version = pthi.AMTUnicodeString{Length: 6, String: [20]uint8{56, 46, 49, 46, 52, 48}}
+	return response, nil
+}
+
+func TestGetUUIDBigEndian(t *testing.T) {
+	result, err := AMTCommand{PTHI: amt8PTHICommands{}}.GetUUID()
+	assert.NoError(t, err)
+	assert.Equal(t, "d23f111c-2533-9445-a272-54b2038beb07", result)
+}
+
 func TestGetControlmode(t *testing.T) {
 	result, err := amt.GetControlMode()
 	assert.NoError(t, err)
@@ -129,6 +192,15 @@ func TestGetCertificateHashes(t *testing.T) {
 	assert.Equal(t, true, result[0].IsDefault)
 }
 
+func TestANSI2String(t *testing.T) {
+	result, err := ANSI2String(pthi.AMTANSIString{Length: 4, Buffer: [1000]uint8{84, 101, 115, 116}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Test", result)
+
+	_, err = ANSI2String(pthi.AMTANSIString{Length: 1001})
+	assert.EqualError(t, err, "string length 1001 exceeds 1000 bytes")
+}
+
 func TestGetRemoteAccessConnectionStatus(t *testing.T) {
 	result, err := amt.GetRemoteAccessConnectionStatus()
 	assert.NoError(t, err)
@@ -147,6 +219,8 @@ func TestGetLANInterfaceSettingsTrue(t *testing.T) {
 	assert.Equal(t, "passive", result.DHCPMode)
 	assert.Equal(t, "0.0.0.0", result.IPAddress)
 	assert.Equal(t, "00:00:00:00:00:00", result.MACAddress)
+	assert.Equal(t, false, result.IPv6.IsEnabled)
+	assert.Empty(t, result.IPv6.Addresses)
 }
 
 func TestGetLANInterfaceSettingsFalse(t *testing.T) {
@@ -157,6 +231,13 @@ func TestGetLANInterfaceSettingsFalse(t *testing.T) {
 	assert.Equal(t, "passive", result.DHCPMode)
 	assert.Equal(t, "0.0.0.0", result.IPAddress)
 	assert.Equal(t, "07:07:07:07:07:07", result.MACAddress)
+	assert.Equal(t, true, result.IPv6.IsEnabled)
+	assert.Equal(t, "fe80::1", result.IPv6.DefaultRouter)
+	assert.Equal(t, []string{"2001:db8::53"}, result.IPv6.DNSServers)
+	assert.Equal(t, []IPv6Address{
+		{Address: "fe80::200:0:0:7", Scope: "link-local", Origin: "link-local", State: "preferred"},
+		{Address: "2001:db8::7", Scope: "global", Origin: "slaac", State: "preferred"},
+	}, result.IPv6.Addresses)
 }
 
 func TestGetLocalSystemAccount(t *testing.T) {
@@ -165,3 +246,49 @@ func TestGetLocalSystemAccount(t *testing.T) {
 	assert.Equal(t, "Test", result.Username)
 	assert.Equal(t, "Test", result.Password)
 }
+
+type MockMKHICommands struct {
+	statusErr error
+}
+
+func (c MockMKHICommands) Open() error { return nil }
+func (c MockMKHICommands) Close()      {}
+func (c MockMKHICommands) Call(command []byte, header mkhi.MessageHeader) (result []byte, err error) {
+	return nil, nil
+}
+func (c MockMKHICommands) GetFirmwareVersion() (mkhi.FWVersion, error) {
+	return mkhi.FWVersion{
+		Code:     mkhi.VersionBlock{Major: 15, Minor: 0, Hotfix: 35, Build: 2039},
+		Recovery: mkhi.VersionBlock{Major: 15, Minor: 0, Hotfix: 35, Build: 2039},
+	}, nil
+}
+func (c MockMKHICommands) GetFirmwareCapabilities() (uint32, error) { return 0x18, nil }
+func (c MockMKHICommands) GetFeatureState() (uint32, error)         { return 0x08, nil }
+func (c MockMKHICommands) GetFirmwareStatus() ([]uint32, error) {
+	return []uint32{0x94000245, 0x09F10506}, c.statusErr
+}
+
+func TestGetFirmwareInfo(t *testing.T) {
+	amt.MKHI = MockMKHICommands{}
+	result, err := amt.GetFirmwareInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, "15.0.35.2039", result.Version)
+	assert.Equal(t, "15.0.35.2039", result.RecoveryVersion)
+	assert.Equal(t, []string{"Intel AMT", "Intel Standard Manageability"}, result.Capabilities)
+	assert.Equal(t, []string{"Intel AMT"}, result.EnabledFeatures)
+	assert.Equal(t, []string{"94000245", "9F10506"}, result.Status.Registers)
+}
+
+func TestGetFirmwareInfoStatusFallsBackToSysfs(t *testing.T) {
+	defer func() { sysfsStatus = mefw.Read }()
+	sysfsStatus = func() (mefw.Status, error) { return mefw.Decode([]uint32{0x00033002}) }
+	amt.MKHI = MockMKHICommands{statusErr: errors.New("MKHI command failed with status 0x8d")}
+	result, err := amt.GetFirmwareInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, "Recovery", result.Status.WorkingState)
+
+	sysfsStatus = func() (mefw.Status, error) { return mefw.Status{}, errors.New("no MEI devices found") }
+	result, err = amt.GetFirmwareInfo()
+	assert.NoError(t, err)
+	assert.Nil(t, result.Status)
+}
@@ -0,0 +1,80 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package amt
+
+import (
+	"rpc/pkg/heci"
+	"rpc/pkg/mefw"
+	"rpc/pkg/mkhi"
+)
+
+// FirmwareInfo holds the ME firmware details reported over MKHI, which are available on every SKU
+type FirmwareInfo struct {
+	Version         string       `json:"version"`
+	RecoveryVersion string       `json:"recoveryVersion"`
+	FITCVersion     string       `json:"fitcVersion"`
+	Capabilities    []string     `json:"capabilities"`
+	EnabledFeatures []string     `json:"enabledFeatures"`
+	CapabilityBits  uint32       `json:"capabilityBits"`
+	FeatureBits     uint32       `json:"featureBits"`
+	Status          *mefw.Status `json:"status,omitempty"`
+}
+
+// sysfsStatus reads the firmware status registers exposed by the MEI driver, replaced in tests
+var sysfsStatus = mefw.Read
+
+// GetFirmwareInfo queries the firmware version and feature bits from the MKHI client
+func (amt AMTCommand) GetFirmwareInfo() (FirmwareInfo, error) {
+	info := FirmwareInfo{}
+	err := amt.MKHI.Open()
+	if err != nil {
+		info.Status = readSysfsStatus()
+		return info, diagnoseAccessError(err, heci.MKHI, defaultAccessProbe)
+	}
+	defer amt.MKHI.Close()
+	info.Status = amt.getFirmwareStatus()
+
+	version, err := amt.MKHI.GetFirmwareVersion()
+	if err != nil {
+		return info, err
+	}
+	info.Version = version.Code.String()
+	info.RecoveryVersion = version.Recovery.String()
+	info.FITCVersion = version.FITC.String()
+
+	info.CapabilityBits, err = amt.MKHI.GetFirmwareCapabilities()
+	if err != nil {
+		return info, err
+	}
+	info.Capabilities = mkhi.DecodeFeatures(info.CapabilityBits)
+
+	info.FeatureBits, err = amt.MKHI.GetFeatureState()
+	if err != nil {
+		return info, err
+	}
+	info.EnabledFeatures = mkhi.DecodeFeatures(info.FeatureBits)
+	return info, nil
+}
+
+// getFirmwareStatus decodes the status registers reported over MKHI and only falls back to sysfs when the query fails
+func (amt AMTCommand) getFirmwareStatus() *mefw.Status {
+	registers, err := amt.MKHI.GetFirmwareStatus()
+	if err != nil {
+		return readSysfsStatus()
+	}
+	status, err := mefw.Decode(registers)
+	if err != nil {
+		return readSysfsStatus()
+	}
+	return &status
+}
+
+func readSysfsStatus() *mefw.Status {
+	status, err := sysfsStatus()
+	if err != nil {
+		return nil
+	}
+	return &status
+}
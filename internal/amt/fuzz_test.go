@@ -0,0 +1,26 @@
+//go:build go1.18
+// +build go1.18
+
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package amt
+
+import (
+	"rpc/pkg/pthi"
+	"testing"
+)
+
+func FuzzANSI2String(f *testing.F) {
+	f.Add(uint16(4), []byte("Test"))
+	f.Add(uint16(1001), []byte{})
+	f.Fuzz(func(t *testing.T, length uint16, data []byte) {
+		ansi := pthi.AMTANSIString{Length: length}
+		copy(ansi.Buffer[:], data)
+		result, err := ANSI2String(ansi)
+		if err == nil && len(result) != int(length) {
+			t.Errorf("expected %d bytes, got %d", length, len(result))
+		}
+	})
+}
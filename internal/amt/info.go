@@ -0,0 +1,152 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package amt
+
+import (
+	"os"
+	"rpc/pkg/smbios"
+	"rpc/pkg/utils"
+)
+
+// InfoFields selects the sections of amtinfo
+type InfoFields struct {
+	Version           bool
+	BuildNumber       bool
+	SKU               bool
+	CodeVersions      bool
+	UUID              bool
+	ControlMode       bool
+	DNSSuffix         bool
+	Hostname          bool
+	RAS               bool
+	LAN               bool
+	CertificateHashes bool
+	FirmwareStatus    bool
+}
+
+// DefaultInfoFields are the sections amtinfo shows when none is selected
+var DefaultInfoFields = InfoFields{
+	Version:        true,
+	BuildNumber:    true,
+	SKU:            true,
+	UUID:           true,
+	ControlMode:    true,
+	DNSSuffix:      true,
+	Hostname:       true,
+	RAS:            true,
+	LAN:            true,
+	FirmwareStatus: true,
+}
+
+// AllInfoFields selects every section of amtinfo
+var AllInfoFields = InfoFields{
+	Version:           true,
+	BuildNumber:       true,
+	SKU:               true,
+	CodeVersions:      true,
+	UUID:              true,
+	ControlMode:       true,
+	DNSSuffix:         true,
+	Hostname:          true,
+	RAS:               true,
+	LAN:               true,
+	CertificateHashes: true,
+	FirmwareStatus:    true,
+}
+
+// GetInfo gathers the selected amtinfo sections keyed by their JSON names. A section that cannot be read is listed
+// under "errors" instead of aborting. When the AMT host interface is unavailable the AccessError is returned with
+// the firmware status, which does not need it.
+func (amt AMTCommand) GetInfo(fields InfoFields) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	failures := []string{}
+	record := func(key string, value interface{}, err error) {
+		data[key] = value
+		if err != nil {
+			failures = append(failures, key+": "+err.Error())
+		}
+	}
+	// firmware status comes from sysfs, so it is still available when the AMT host interface is not
+	if fields.FirmwareStatus {
+		if status, err := sysfsStatus(); err == nil {
+			data["meStatus"] = status
+		}
+	}
+	result, err := amt.Initialize()
+	if !result || err != nil {
+		return data, err
+	}
+	if fields.Version {
+		result, err := amt.GetVersionDataFromME("AMT")
+		record("amt", result, err)
+	}
+	if fields.BuildNumber {
+		result, err := amt.GetVersionDataFromME("Build Number")
+		record("buildNumber", result, err)
+	}
+	if fields.SKU {
+		result, err := amt.GetVersionDataFromME("Sku")
+		record("sku", result, err)
+		if features, err := DecodeSKU(result); err == nil {
+			data["skuFeatures"] = features
+		}
+	}
+	if fields.CodeVersions {
+		table, err := amt.GetCodeVersions()
+		record("codeVersions", table, err)
+		if version, ok := table.Lookup("AMT"); ok {
+			if parsed, err := ParseVersion(version); err == nil {
+				data["amtVersion"] = parsed
+				data["capabilities"] = parsed.Capabilities()
+			}
+		}
+	}
+	if fields.UUID {
+		result, err := amt.GetUUID()
+		record("uuid", result, err)
+		if info, err := smbios.Read(); err == nil {
+			data["smbios"] = info
+			data["uuidCheck"] = smbios.CompareUUID(result, info.UUID)
+		}
+	}
+	if fields.ControlMode {
+		result, err := amt.GetControlMode()
+		record("controlMode", string(utils.InterpretControlMode(result)), err)
+	}
+	if fields.DNSSuffix {
+		result, err := amt.GetDNSSuffix()
+		record("dnsSuffix", result, err)
+		detection, err := amt.DetectOSDNSSuffix()
+		record("dnsSuffixOS", detection.Suffix, err)
+		data["dnsSuffixOSSource"] = detection.Source
+		data["dnsSuffixOSCandidates"] = detection.Candidates
+	}
+	if fields.Hostname {
+		result, err := os.Hostname()
+		record("hostnameOS", result, err)
+	}
+	if fields.RAS {
+		result, err := amt.GetRemoteAccessConnectionStatus()
+		record("ras", result, err)
+	}
+	if fields.LAN {
+		wired, err := amt.GetLANInterfaceSettings(false)
+		record("wiredAdapter", wired, err)
+		wireless, err := amt.GetLANInterfaceSettings(true)
+		record("wirelessAdapter", wireless, err)
+	}
+	if fields.CertificateHashes {
+		result, err := amt.GetCertificateHashes()
+		certs := make(map[string]CertHashEntry)
+		for _, v := range result {
+			certs[v.Name] = v
+		}
+		record("certificateHashes", certs, err)
+	}
+	if len(failures) > 0 {
+		data["errors"] = failures
+	}
+	return data, nil
+}
@@ -0,0 +1,34 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package amt
+
+import (
+	"rpc/pkg/mefw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetInfo(t *testing.T) {
+	defer func() { sysfsStatus = mefw.Read }()
+	sysfsStatus = func() (mefw.Status, error) { return mefw.Decode([]uint32{0x94000245}) }
+	result, err := amt.GetInfo(AllInfoFields)
+	assert.NoError(t, err)
+	assert.Equal(t, "Normal", result["meStatus"].(mefw.Status).WorkingState)
+	assert.Equal(t, "Test", result["dnsSuffix"])
+	assert.Equal(t, "1c113fd2-3325-4594-a272-54b2038beb07", result["uuid"])
+	assert.Equal(t, "pre-provisioning state", result["controlMode"])
+	assert.Equal(t, "Test", result["codeVersions"].(CodeVersionTable).BiosVersion)
+	assert.Contains(t, result["certificateHashes"], "Test")
+	assert.Contains(t, result, "wirelessAdapter")
+	// the mock reports no AMT, Build Number or Sku entries
+	assert.Contains(t, result["errors"], "amt: AMT Not Found")
+}
+
+func TestGetInfoSelectedFields(t *testing.T) {
+	result, err := amt.GetInfo(InfoFields{ControlMode: true})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"controlMode": "pre-provisioning state"}, result)
+}
@@ -0,0 +1,60 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package amt
+
+import (
+	"net"
+	"rpc/pkg/pthi"
+	"rpc/pkg/utils"
+)
+
+// IPv6Address is an address assigned to an AMT interface
+type IPv6Address struct {
+	Address string `json:"address"`
+	Scope   string `json:"scope"`
+	Origin  string `json:"origin"`
+	State   string `json:"state"`
+}
+
+// IPv6Settings holds the IPv6 status of an AMT interface
+type IPv6Settings struct {
+	IsEnabled     bool          `json:"isEnabled"`
+	DefaultRouter string        `json:"defaultRouter,omitempty"`
+	DNSServers    []string      `json:"dnsServers,omitempty"`
+	Addresses     []IPv6Address `json:"addresses"`
+}
+
+func interpretIPv6Status(result pthi.GetIPv6LANInterfaceStatusResponse) IPv6Settings {
+	settings := IPv6Settings{
+		IsEnabled: result.Enabled == 1,
+		Addresses: []IPv6Address{},
+	}
+	if router := net.IP(result.DefaultRouter[:]); !router.IsUnspecified() {
+		settings.DefaultRouter = router.String()
+	}
+	for _, dns := range [][16]uint8{result.PrimaryDNS, result.SecondaryDNS} {
+		if server := net.IP(dns[:]); !server.IsUnspecified() {
+			settings.DNSServers = append(settings.DNSServers, server.String())
+		}
+	}
+	count := int(result.AddressCount)
+	if count > len(result.Addresses) {
+		count = len(result.Addresses)
+	}
+	for _, info := range result.Addresses[:count] {
+		address := net.IP(info.Address[:])
+		scope := "global"
+		if address.IsLinkLocalUnicast() {
+			scope = "link-local"
+		}
+		settings.Addresses = append(settings.Addresses, IPv6Address{
+			Address: address.String(),
+			Scope:   scope,
+			Origin:  utils.InterpretIPv6AddressType(int(info.Type)),
+			State:   utils.InterpretIPv6AddressState(int(info.State)),
+		})
+	}
+	return settings
+}
@@ -0,0 +1,15 @@
+//go:build linux
+// +build linux
+
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package amt
+
+import "os"
+
+// elevated reports whether rpc runs as root
+func elevated() bool {
+	return euidElevated(os.Geteuid)
+}
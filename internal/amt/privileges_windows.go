@@ -0,0 +1,15 @@
+//go:build windows
+// +build windows
+
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package amt
+
+import "golang.org/x/sys/windows"
+
+// elevated reports whether the process token is elevated, os.Geteuid always returns -1 on Windows
+func elevated() bool {
+	return windows.GetCurrentProcessToken().IsElevated()
+}
@@ -0,0 +1,130 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package amt
+
+import (
+	"errors"
+	"fmt"
+	"rpc/pkg/mkhi"
+	"rpc/pkg/pthi"
+	"strconv"
+	"strings"
+)
+
+// SKU feature bits reported in the Sku code version entry
+const (
+	SKUAMT = 0x08
+	SKUISM = 0x10
+	SKUSBA = 0x20
+)
+
+// VersionEntry is a single description and version pair from the code versions table
+type VersionEntry struct {
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+// CodeVersionTable holds every entry reported by the code versions command
+type CodeVersionTable struct {
+	BiosVersion string         `json:"biosVersion"`
+	Versions    []VersionEntry `json:"versions"`
+}
+
+// Version is a comparable major.minor.patch version
+type Version struct {
+	Major int `json:"major"`
+	Minor int `json:"minor"`
+	Patch int `json:"patch"`
+}
+
+// GetCodeVersions returns the BIOS version and every entry in the code versions table
+func (amt AMTCommand) GetCodeVersions() (CodeVersionTable, error) {
+	table := CodeVersionTable{Versions: []VersionEntry{}}
+	err := amt.PTHI.Open()
+	if err != nil {
+		return table, err
+	}
+	defer amt.PTHI.Close()
+	result, err := amt.PTHI.GetCodeVersions()
+	if err != nil {
+		return table, err
+	}
+	table.BiosVersion = strings.TrimRight(string(result.CodeVersion.BiosVersion[:]), "\u0000")
+	err = result.CodeVersion.Validate()
+	if err != nil {
+		return table, err
+	}
+	for i := 0; i < int(result.CodeVersion.VersionsCount); i++ {
+		table.Versions = append(table.Versions, VersionEntry{
+			Description: unicodeString(result.CodeVersion.Versions[i].Description),
+			Version:     unicodeString(result.CodeVersion.Versions[i].Version),
+		})
+	}
+	return table, nil
+}
+
+// unicodeString decodes a validated entry and drops trailing NULs
+func unicodeString(value pthi.AMTUnicodeString) string {
+	decoded, _ := value.Decode()
+	return strings.TrimRight(decoded, "\u0000")
+}
+
+// Lookup returns the version for description
+func (t CodeVersionTable) Lookup(description string) (string, bool) {
+	for _, entry := range t.Versions {
+		if entry.Description == description {
+			return entry.Version, true
+		}
+	}
+	return "", false
+}
+
+// DecodeSKU returns the names of the features set in the Sku code version entry
+func DecodeSKU(sku string) ([]string, error) {
+	value, err := strconv.ParseUint(strings.TrimSpace(sku), 10, 32)
+	if err != nil {
+		return nil, errors.New("invalid SKU " + sku)
+	}
+	return mkhi.DecodeFeatures(uint32(value)), nil
+}
+
+// ParseVersion parses an AMT version such as 11.8.55 into a comparable Version
+func ParseVersion(version string) (Version, error) {
+	parts := strings.Split(strings.TrimSpace(version), ".")
+	if len(parts) < 2 || len(parts) > 4 {
+		return Version{}, errors.New("invalid version " + version)
+	}
+	values := [3]int{}
+	for i := 0; i < len(parts) && i < len(values); i++ {
+		value, err := strconv.Atoi(parts[i])
+		if err != nil || value < 0 {
+			return Version{}, errors.New("invalid version " + version)
+		}
+		values[i] = value
+	}
+	return Version{Major: values[0], Minor: values[1], Patch: values[2]}, nil
+}
+
+// Compare returns -1, 0 or 1 when v is older than, equal to or newer than other
+func (v Version) Compare(other Version) int {
+	for _, diff := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if diff < 0 {
+			return -1
+		}
+		if diff > 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+// AtLeast reports whether v is the same as or newer than other
+func (v Version) AtLeast(other Version) bool {
+	return v.Compare(other) >= 0
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
@@ -0,0 +1,58 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package amt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCodeVersions(t *testing.T) {
+	result, err := amt.GetCodeVersions()
+	assert.NoError(t, err)
+	assert.Equal(t, "Test", result.BiosVersion)
+	assert.Equal(t, []VersionEntry{{Description: "Flash", Version: "11.8.55"}}, result.Versions)
+	version, ok := result.Lookup("Flash")
+	assert.True(t, ok)
+	assert.Equal(t, "11.8.55", version)
+	_, ok = result.Lookup("AMT")
+	assert.False(t, ok)
+}
+
+func TestDecodeSKU(t *testing.T) {
+	result, err := DecodeSKU("16392")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Intel AMT", "Corporate"}, result)
+	result, err = DecodeSKU("16")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Intel Standard Manageability"}, result)
+	_, err = DecodeSKU("abc")
+	assert.Error(t, err)
+}
+
+func TestParseVersion(t *testing.T) {
+	result, err := ParseVersion("11.8.55")
+	assert.NoError(t, err)
+	assert.Equal(t, Version{Major: 11, Minor: 8, Patch: 55}, result)
+	assert.Equal(t, "11.8.55", result.String())
+	result, err = ParseVersion("16.1")
+	assert.NoError(t, err)
+	assert.Equal(t, Version{Major: 16, Minor: 1}, result)
+	_, err = ParseVersion("11")
+	assert.Error(t, err)
+	_, err = ParseVersion("11.x.5")
+	assert.Error(t, err)
+}
+
+func TestVersionCompare(t *testing.T) {
+	older, _ := ParseVersion("11.8.55")
+	newer, _ := ParseVersion("11.10.0")
+	assert.Equal(t, -1, older.Compare(newer))
+	assert.Equal(t, 1, newer.Compare(older))
+	assert.Equal(t, 0, older.Compare(older))
+	assert.True(t, newer.AtLeast(older))
+	assert.False(t, older.AtLeast(newer))
+}
@@ -0,0 +1,383 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package check
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"rpc/internal/amt"
+	"rpc/internal/rpc"
+	"rpc/pkg/dnssuffix"
+	"rpc/pkg/mefw"
+	"rpc/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Pass indicates the prerequisite is met
+	Pass = "pass"
+	// Warn indicates the prerequisite may cause activation to fail
+	Warn = "warn"
+	// Fail indicates activation will not succeed
+	Fail = "fail"
+)
+
+// Result holds the outcome of a single prerequisite check
+type Result struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// Report is the collection of all check results
+type Report struct {
+	Status  string   `json:"status"`
+	Results []Result `json:"results"`
+}
+
+// Checker verifies the prerequisites for activating a device
+type Checker struct {
+	AMT           amt.Interface
+	URL           string
+	Proxy         string
+	SkipCertCheck bool
+	Timeout       time.Duration
+	stat          func(name string) (os.FileInfo, error)
+	open          func(name string, flag int, perm os.FileMode) (*os.File, error)
+	dial          func(network, address string, timeout time.Duration) (net.Conn, error)
+	listen        func(network, address string) (net.Listener, error)
+	fwStatus      func() (mefw.Status, error)
+}
+
+// NewChecker creates a Checker for this device using the provided flags
+func NewChecker(flags rpc.Flags) Checker {
+	return Checker{
+		AMT:           amt.NewAMTCommand(),
+		URL:           flags.URL,
+		Proxy:         flags.Proxy,
+		SkipCertCheck: flags.SkipCertCheck,
+		Timeout:       5 * time.Second,
+		stat:          os.Stat,
+		open:          os.OpenFile,
+		dial:          net.DialTimeout,
+		listen:        net.Listen,
+		fwStatus:      mefw.Read,
+	}
+}
+
+// Run executes all checks and returns the report
+func (c Checker) Run() Report {
+	report := Report{}
+	report.add(c.checkDevice()...)
+	report.add(c.checkFirmwareStatus())
+	access := c.checkAccess()
+	report.add(access)
+	if access.Status == Pass {
+		report.add(c.checkSKU())
+		report.add(c.checkControlMode())
+		report.add(c.checkDNSSuffix())
+	}
+	report.add(c.checkLMS())
+	report.add(c.checkRPS())
+	report.add(c.checkProxy())
+	return report
+}
+
+func (r *Report) add(results ...Result) {
+	for _, result := range results {
+		r.Results = append(r.Results, result)
+		if result.Status == Fail || (result.Status == Warn && r.Status != Fail) {
+			r.Status = result.Status
+		}
+	}
+	if r.Status == "" {
+		r.Status = Pass
+	}
+}
+
+// Failed reports whether any check failed
+func (r Report) Failed() bool {
+	return r.Status == Fail
+}
+
+// String formats the report for display
+func (r Report) String() string {
+	output := ""
+	for _, result := range r.Results {
+		output = output + fmt.Sprintf("[%s] %-20s: %s\n", strings.ToUpper(result.Status), result.Name, result.Message)
+	}
+	return output + "Result: " + strings.ToUpper(r.Status)
+}
+
+// JSON formats the report as indented JSON
+func (r Report) JSON() string {
+	outBytes, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err.Error()
+	}
+	return string(outBytes)
+}
+
+func (c Checker) checkAccess() Result {
+	result := Result{Name: "amt access", Status: Pass, Message: "connected to the AMT host interface"}
+	ok, err := c.AMT.Initialize()
+	if !ok || err != nil {
+		result.Status = Fail
+		result.Message = "unable to connect to the AMT host interface"
+		accessError := &amt.AccessError{}
+		if errors.As(err, &accessError) {
+			result.Message = result.Message + ": " + accessError.Cause + ". " + accessError.Remediation
+		} else if err != nil {
+			result.Message = result.Message + ": " + err.Error()
+		}
+	}
+	return result
+}
+
+func (c Checker) checkFirmwareStatus() Result {
+	result := Result{Name: "me firmware", Status: Pass}
+	status, err := c.fwStatus()
+	if err != nil {
+		result.Status = Warn
+		result.Message = "firmware status is not available: " + err.Error()
+		return result
+	}
+	result.Message = status.String()
+	if !status.Healthy() {
+		result.Status = Fail
+		result.Message = result.Message + " (HFSTS " + strings.Join(status.Registers, " ") + ")"
+	} else if status.ManufacturingMode {
+		result.Status = Warn
+		result.Message = result.Message + ", close manufacturing mode before deployment"
+	}
+	return result
+}
+
+func (c Checker) checkSKU() Result {
+	result := Result{Name: "amt sku", Status: Pass}
+	version, err := c.AMT.GetVersionDataFromME("AMT")
+	if err != nil || version == "" {
+		result.Status = Fail
+		result.Message = "unable to read AMT version"
+		return result
+	}
+	sku, err := c.AMT.GetVersionDataFromME("Sku")
+	if err != nil {
+		result.Status = Fail
+		result.Message = "unable to read SKU"
+		return result
+	}
+	skuValue, err := strconv.Atoi(sku)
+	if err != nil {
+		result.Status = Warn
+		result.Message = "AMT " + version + ", unrecognized SKU " + sku
+		return result
+	}
+	if skuValue&amt.SKUAMT == 0 {
+		result.Status = Fail
+		result.Message = "SKU " + sku + " does not support Intel AMT"
+		return result
+	}
+	result.Message = "AMT " + version + ", SKU " + sku
+	if versionResult := CheckVersion(version, "activate"); versionResult.Status == Fail {
+		result.Status = Fail
+		result.Message = result.Message + ", " + versionResult.Message
+	}
+	return result
+}
+
+// CheckVersion checks that the AMT version supports command
+func CheckVersion(version string, command string) Result {
+	result := Result{Name: "amt version", Status: Pass, Message: "AMT " + version}
+	if version == "" {
+		result.Status = Fail
+		result.Message = "unable to read AMT version"
+		return result
+	}
+	parsed, err := amt.ParseVersion(version)
+	if err != nil {
+		result.Status = Warn
+		result.Message = "unable to parse AMT version " + version
+	} else if err := amt.RequireCapabilities(parsed, command); err != nil {
+		result.Status = Fail
+		result.Message = err.Error()
+	}
+	return result
+}
+
+func (c Checker) checkControlMode() Result {
+	mode, err := c.AMT.GetControlMode()
+	if err != nil {
+		return Result{Name: "control mode", Status: Fail, Message: "unable to read control mode"}
+	}
+	return CheckControlMode(mode, "activate")
+}
+
+// CheckControlMode checks that command can run in the control mode, activation expects an unprovisioned device
+// while deactivate and maintenance expect an activated one
+func CheckControlMode(mode int, command string) Result {
+	result := Result{Name: "control mode", Status: Pass, Message: utils.InterpretControlMode(mode)}
+	if command == "activate" && mode != 0 {
+		result.Status = Warn
+		result.Message = result.Message + ", deactivate before activating with a new profile"
+	} else if (command == "deactivate" || command == "maintenance") && mode == 0 {
+		result.Status = Fail
+		result.Message = result.Message + ", the device is not activated"
+	}
+	return result
+}
+
+func (c Checker) checkDNSSuffix() Result {
+	amtSuffix, _ := c.AMT.GetDNSSuffix()
+	detection, _ := c.AMT.DetectOSDNSSuffix()
+	return CheckDNSSuffix(amtSuffix, detection)
+}
+
+// CheckDNSSuffix compares the DNS suffix of AMT with the one detected in the OS
+func CheckDNSSuffix(amtSuffix string, detection dnssuffix.Result) Result {
+	result := Result{Name: "dns suffix", Status: Pass}
+	osSuffix := detection.Suffix
+	switch {
+	case amtSuffix == "" && osSuffix == "":
+		result.Status = Warn
+		result.Message = "no DNS suffix found in AMT or the OS, use -d to specify one"
+	case amtSuffix != "" && osSuffix != "" && !dnssuffix.Matches(osSuffix, amtSuffix):
+		result.Status = Warn
+		result.Message = "AMT suffix " + amtSuffix + " does not match OS suffix " + osSuffix + " (" + string(detection.Source) + ")"
+	case amtSuffix != "":
+		result.Message = amtSuffix + " (AMT)"
+	default:
+		result.Message = osSuffix + " (OS, " + string(detection.Source) + ")"
+	}
+	return result
+}
+
+func (c Checker) checkLMS() Result {
+	result := Result{Name: "lms", Status: Pass}
+	address := net.JoinHostPort(utils.LMSAddress, utils.LMSPort)
+	conn, err := c.dial("tcp4", address, c.Timeout)
+	if err == nil {
+		conn.Close()
+		result.Message = "LMS is listening on " + address
+		return result
+	}
+	listener, err := c.listen("tcp4", address)
+	if err != nil {
+		result.Status = Fail
+		result.Message = "port " + utils.LMSPort + " is unavailable: " + err.Error()
+		return result
+	}
+	listener.Close()
+	result.Message = "port " + utils.LMSPort + " is available, rpc will start LMS"
+	return result
+}
+
+// ParseServerURL parses an RPS address, which must use the wss or ws scheme
+func ParseServerURL(address string) (*url.URL, error) {
+	serverURL, err := url.Parse(address)
+	if err != nil || (serverURL.Scheme != "wss" && serverURL.Scheme != "ws") {
+		return nil, errors.New("server address must start with wss:// or ws://")
+	}
+	return serverURL, nil
+}
+
+// CheckServerURL validates the RPS address without connecting to it
+func CheckServerURL(address string) Result {
+	result := Result{Name: "rps", Status: Pass, Message: address}
+	if address == "" {
+		result.Status = Warn
+		result.Message = "no server address specified, use -u to specify one"
+		return result
+	}
+	serverURL, err := ParseServerURL(address)
+	if err != nil {
+		result.Status = Fail
+		result.Message = err.Error()
+	} else if serverURL.Scheme == "ws" {
+		result.Status = Warn
+		result.Message = "connection to the server will not be encrypted"
+	}
+	return result
+}
+
+func (c Checker) checkRPS() Result {
+	result := CheckServerURL(c.URL)
+	if result.Status == Fail || c.URL == "" {
+		return result
+	}
+	serverURL, _ := ParseServerURL(c.URL)
+	address := serverURL.Host
+	if serverURL.Port() == "" {
+		port := "443"
+		if serverURL.Scheme == "ws" {
+			port = "80"
+		}
+		address = net.JoinHostPort(serverURL.Hostname(), port)
+	}
+	conn, err := c.dial("tcp", address, c.Timeout)
+	if err != nil {
+		result.Status = Fail
+		result.Message = "unable to reach " + address + ": " + err.Error()
+		return result
+	}
+	defer conn.Close()
+	if serverURL.Scheme == "ws" {
+		result.Status = Warn
+		result.Message = address + " is reachable, connection is not encrypted"
+		return result
+	}
+	tlsConn := tls.Client(conn, &tls.Config{
+		ServerName:         serverURL.Hostname(),
+		InsecureSkipVerify: c.SkipCertCheck,
+	})
+	tlsConn.SetDeadline(time.Now().Add(c.Timeout))
+	err = tlsConn.Handshake()
+	if err != nil {
+		result.Status = Fail
+		result.Message = "TLS handshake with " + address + " failed: " + err.Error()
+		return result
+	}
+	if c.SkipCertCheck {
+		result.Status = Warn
+		result.Message = address + " is reachable, certificate verification skipped"
+		return result
+	}
+	certs := tlsConn.ConnectionState().PeerCertificates
+	result.Message = address + " is reachable with a valid certificate"
+	if len(certs) > 0 {
+		result.Message = result.Message + " (expires " + certs[0].NotAfter.Format("2006-01-02") + ")"
+	}
+	return result
+}
+
+func (c Checker) checkProxy() Result {
+	result := Result{Name: "proxy", Status: Pass, Message: "no proxy configured"}
+	proxy := c.Proxy
+	source := "-p"
+	if proxy == "" && c.URL != "" {
+		// rpc connects to RPS directly, so report any proxy the environment would otherwise apply
+		serverURL, err := url.Parse(strings.Replace(c.URL, "ws", "http", 1))
+		if err == nil {
+			proxyURL, err := http.ProxyFromEnvironment(&http.Request{URL: serverURL})
+			if err == nil && proxyURL != nil {
+				proxy = proxyURL.String()
+				source = "environment"
+			}
+		}
+	}
+	if proxy != "" {
+		result.Status = Warn
+		result.Message = "proxy " + proxy + " configured via " + source + " is not used, rpc connects to the server directly"
+	}
+	return result
+}
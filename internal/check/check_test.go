@@ -0,0 +1,286 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package check
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"rpc/internal/amt"
+	"rpc/pkg/dnssuffix"
+	"rpc/pkg/mefw"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Mock the AMT Hardware
+type MockAMT struct{}
+
+var initializeError error
+var sku = "16392"
+var amtVersion = "15.0.0"
+var controlMode = 0
+var amtDNSSuffix = "vprodemo.com"
+var osDNSSuffix = "vprodemo.com"
+
+func (c MockAMT) Initialize() (bool, error) {
+	return initializeError == nil, initializeError
+}
+func (c MockAMT) GetVersionDataFromME(key string) (string, error) {
+	if key == "Sku" {
+		return sku, nil
+	}
+	return amtVersion, nil
+}
+func (c MockAMT) GetUUID() (string, error)        { return "123-456-789", nil }
+func (c MockAMT) GetControlMode() (int, error)    { return controlMode, nil }
+func (c MockAMT) GetOSDNSSuffix() (string, error) { return osDNSSuffix, nil }
+func (c MockAMT) GetDNSSuffix() (string, error)   { return amtDNSSuffix, nil }
+func (c MockAMT) DetectOSDNSSuffix() (dnssuffix.Result, error) {
+	result := dnssuffix.Result{Candidates: []dnssuffix.Candidate{}}
+	if osDNSSuffix != "" {
+		result.Suffix = osDNSSuffix
+		result.Source = dnssuffix.SourceDHCP
+		result.Candidates = append(result.Candidates, dnssuffix.Candidate{Suffix: osDNSSuffix, Source: dnssuffix.SourceDHCP})
+	}
+	return result, nil
+}
+func (c MockAMT) GetCertificateHashes() ([]amt.CertHashEntry, error) {
+	return []amt.CertHashEntry{}, nil
+}
+func (c MockAMT) GetRemoteAccessConnectionStatus() (amt.RemoteAccessStatus, error) {
+	return amt.RemoteAccessStatus{}, nil
+}
+func (c MockAMT) GetLANInterfaceSettings(useWireless bool) (amt.InterfaceSettings, error) {
+	return amt.InterfaceSettings{}, nil
+}
+func (c MockAMT) GetLocalSystemAccount() (amt.LocalSystemAccount, error) {
+	return amt.LocalSystemAccount{}, nil
+}
+
+func newTestChecker() Checker {
+	return Checker{
+		AMT:     MockAMT{},
+		Timeout: time.Second,
+		stat:    func(name string) (os.FileInfo, error) { return nil, nil },
+		open:    func(name string, flag int, perm os.FileMode) (*os.File, error) { return nil, nil },
+		dial: func(network, address string, timeout time.Duration) (net.Conn, error) {
+			_, client := net.Pipe()
+			return client, nil
+		},
+		listen: net.Listen,
+		fwStatus: func() (mefw.Status, error) {
+			return mefw.Decode([]uint32{0x94000245, 0x09F10506})
+		},
+	}
+}
+
+func findResult(report Report, name string) Result {
+	for _, result := range report.Results {
+		if result.Name == name {
+			return result
+		}
+	}
+	return Result{}
+}
+
+func TestRun(t *testing.T) {
+	c := newTestChecker()
+	report := c.Run()
+	assert.False(t, report.Failed())
+	assert.Equal(t, Pass, findResult(report, "amt access").Status)
+	assert.Equal(t, Pass, findResult(report, "amt sku").Status)
+	assert.Equal(t, Pass, findResult(report, "control mode").Status)
+	assert.Equal(t, Pass, findResult(report, "dns suffix").Status)
+	assert.Equal(t, Pass, findResult(report, "lms").Status)
+	assert.Equal(t, Warn, findResult(report, "rps").Status)
+	assert.Equal(t, Warn, report.Status)
+}
+
+func TestRunNoAccess(t *testing.T) {
+	initializeError = errors.New("unable to initialize")
+	defer func() { initializeError = nil }()
+	c := newTestChecker()
+	report := c.Run()
+	assert.True(t, report.Failed())
+	assert.Equal(t, Fail, findResult(report, "amt access").Status)
+	assert.Equal(t, "", findResult(report, "amt sku").Status)
+}
+
+func TestCheckSKUWithoutAMT(t *testing.T) {
+	sku = "16400"
+	defer func() { sku = "16392" }()
+	c := newTestChecker()
+	assert.Equal(t, Fail, c.checkSKU().Status)
+}
+
+func TestCheckSKUUnsupportedVersion(t *testing.T) {
+	amtVersion = "11.0.25"
+	defer func() { amtVersion = "15.0.0" }()
+	c := newTestChecker()
+	result := c.checkSKU()
+	assert.Equal(t, Fail, result.Status)
+	assert.Contains(t, result.Message, "requires AMT 11.8 or later")
+}
+
+func TestCheckControlModeActivated(t *testing.T) {
+	controlMode = 2
+	defer func() { controlMode = 0 }()
+	c := newTestChecker()
+	assert.Equal(t, Warn, c.checkControlMode().Status)
+}
+
+func TestCheckControlModeCommands(t *testing.T) {
+	assert.Equal(t, Pass, CheckControlMode(0, "activate").Status)
+	assert.Equal(t, Fail, CheckControlMode(0, "deactivate").Status)
+	assert.Equal(t, Fail, CheckControlMode(0, "maintenance").Status)
+	assert.Equal(t, Pass, CheckControlMode(1, "deactivate").Status)
+}
+
+func TestCheckVersion(t *testing.T) {
+	assert.Equal(t, Result{Name: "amt version", Status: Pass, Message: "AMT 15.0.0"}, CheckVersion("15.0.0", "activate"))
+	assert.Equal(t, Fail, CheckVersion("", "activate").Status)
+	assert.Equal(t, Warn, CheckVersion("unknown", "activate").Status)
+}
+
+func TestCheckDNSSuffixMismatch(t *testing.T) {
+	osDNSSuffix = "other.com"
+	defer func() { osDNSSuffix = "vprodemo.com" }()
+	c := newTestChecker()
+	result := c.checkDNSSuffix()
+	assert.Equal(t, Warn, result.Status)
+	assert.Contains(t, result.Message, "other.com")
+}
+
+func TestCheckDNSSuffixSubdomain(t *testing.T) {
+	osDNSSuffix = "corp.vprodemo.com"
+	defer func() { osDNSSuffix = "vprodemo.com" }()
+	c := newTestChecker()
+	assert.Equal(t, Pass, c.checkDNSSuffix().Status)
+}
+
+func TestCheckDNSSuffixOSOnly(t *testing.T) {
+	amtDNSSuffix = ""
+	defer func() { amtDNSSuffix = "vprodemo.com" }()
+	c := newTestChecker()
+	result := c.checkDNSSuffix()
+	assert.Equal(t, Pass, result.Status)
+	assert.Equal(t, "vprodemo.com (OS, dhcp)", result.Message)
+}
+
+func TestCheckLMSPortAvailable(t *testing.T) {
+	c := newTestChecker()
+	c.dial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		return nil, errors.New("connection refused")
+	}
+	c.listen = func(network, address string) (net.Listener, error) {
+		return net.Listen("tcp4", "127.0.0.1:0")
+	}
+	result := c.checkLMS()
+	assert.Equal(t, Pass, result.Status)
+	assert.Contains(t, result.Message, "available")
+}
+
+func TestCheckLMSPortInUse(t *testing.T) {
+	c := newTestChecker()
+	c.dial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		return nil, errors.New("connection refused")
+	}
+	c.listen = func(network, address string) (net.Listener, error) {
+		return nil, errors.New("address already in use")
+	}
+	assert.Equal(t, Fail, c.checkLMS().Status)
+}
+
+func TestCheckRPSInvalidURL(t *testing.T) {
+	c := newTestChecker()
+	c.URL = "https://localhost"
+	assert.Equal(t, Fail, c.checkRPS().Status)
+}
+
+func TestCheckRPSUnreachable(t *testing.T) {
+	c := newTestChecker()
+	c.URL = "wss://localhost:1"
+	c.dial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		return nil, errors.New("connection refused")
+	}
+	assert.Equal(t, Fail, c.checkRPS().Status)
+}
+
+func TestCheckRPSCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	c := newTestChecker()
+	c.dial = net.DialTimeout
+	c.URL = "wss" + strings.TrimPrefix(server.URL, "https")
+	result := c.checkRPS()
+	assert.Equal(t, Fail, result.Status)
+	assert.Contains(t, result.Message, "TLS handshake")
+
+	c.SkipCertCheck = true
+	assert.Equal(t, Warn, c.checkRPS().Status)
+}
+
+func TestCheckProxy(t *testing.T) {
+	c := newTestChecker()
+	assert.Equal(t, Pass, c.checkProxy().Status)
+	c.Proxy = "http://proxy:911"
+	assert.Equal(t, Warn, c.checkProxy().Status)
+}
+
+func TestReportString(t *testing.T) {
+	report := Report{}
+	report.add(Result{Name: "lms", Status: Pass, Message: "ok"})
+	assert.Equal(t, Pass, report.Status)
+	report.add(Result{Name: "rps", Status: Fail, Message: "down"})
+	report.add(Result{Name: "proxy", Status: Warn, Message: "configured"})
+	assert.Equal(t, Fail, report.Status)
+	assert.Contains(t, report.String(), "[FAIL] rps")
+	assert.Contains(t, report.JSON(), "\"status\": \"fail\"")
+}
+
+func TestCheckFirmwareStatusFailure(t *testing.T) {
+	c := newTestChecker()
+	c.fwStatus = func() (mefw.Status, error) { return mefw.Decode([]uint32{0x00033002}) }
+	result := c.checkFirmwareStatus()
+	assert.Equal(t, Fail, result.Status)
+	assert.Contains(t, result.Message, "Image Failure")
+}
+
+func TestCheckFirmwareStatusManufacturingMode(t *testing.T) {
+	c := newTestChecker()
+	c.fwStatus = func() (mefw.Status, error) { return mefw.Decode([]uint32{0x94000255}) }
+	result := c.checkFirmwareStatus()
+	assert.Equal(t, Warn, result.Status)
+}
+
+func TestCheckFirmwareStatusUnavailable(t *testing.T) {
+	c := newTestChecker()
+	c.fwStatus = func() (mefw.Status, error) { return mefw.Status{}, errors.New("no MEI devices found") }
+	result := c.checkFirmwareStatus()
+	assert.Equal(t, Warn, result.Status)
+}
+
+func TestCheckServerURL(t *testing.T) {
+	assert.Equal(t, Result{Name: "rps", Status: Pass, Message: "wss://localhost"}, CheckServerURL("wss://localhost"))
+	assert.Equal(t, Warn, CheckServerURL("ws://localhost").Status)
+	assert.Equal(t, Warn, CheckServerURL("").Status)
+	assert.Equal(t, Fail, CheckServerURL("https://localhost").Status)
+}
+
+func TestParseServerURL(t *testing.T) {
+	serverURL, err := ParseServerURL("wss://rps.vprodemo.com/activate")
+	assert.NoError(t, err)
+	assert.Equal(t, "rps.vprodemo.com", serverURL.Host)
+	_, err = ParseServerURL("https://rps.vprodemo.com")
+	assert.EqualError(t, err, "server address must start with wss:// or ws://")
+	_, err = ParseServerURL("wss://%zz")
+	assert.Error(t, err)
+}
@@ -0,0 +1,71 @@
+//go:build linux
+// +build linux
+
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package check
+
+import (
+	"os"
+	"rpc/pkg/heci"
+	"strings"
+)
+
+// MEIDriverPaths are the sysfs entries present when the MEI driver is loaded
+var MEIDriverPaths = []string{"/sys/module/mei_me", "/sys/module/mei_txe", "/sys/class/mei/mei0"}
+
+// meiDevices lists the device nodes rpc will try, in order
+var meiDevices = heci.Candidates
+
+func (c Checker) checkDevice() []Result {
+	devices := meiDevices()
+	// report the first device unless a later one is accessible
+	device := c.checkDeviceNode(devices[0])
+	for _, path := range devices[1:] {
+		if device.Status == Pass {
+			break
+		}
+		if result := c.checkDeviceNode(path); result.Status == Pass {
+			device = result
+		}
+	}
+	if device.Status == Pass && len(devices) > 1 {
+		device.Message = device.Message + " (found " + strings.Join(devices, ", ") + ")"
+	}
+
+	driver := Result{Name: "mei driver", Status: Fail, Message: "MEI driver (mei_me) is not loaded"}
+	for _, path := range MEIDriverPaths {
+		if _, err := c.stat(path); err == nil {
+			driver.Status = Pass
+			driver.Message = "MEI driver is loaded"
+			break
+		}
+	}
+	return []Result{device, driver}
+}
+
+func (c Checker) checkDeviceNode(path string) Result {
+	device := Result{Name: "mei device", Status: Pass, Message: path + " is accessible"}
+	_, err := c.stat(path)
+	if os.IsNotExist(err) {
+		device.Status = Fail
+		device.Message = path + " not found"
+	} else if err != nil {
+		device.Status = Fail
+		device.Message = err.Error()
+	} else {
+		file, err := c.open(path, os.O_RDWR, 0)
+		if os.IsPermission(err) {
+			device.Status = Fail
+			device.Message = "permission denied opening " + path + ", run as root"
+		} else if err != nil {
+			device.Status = Fail
+			device.Message = err.Error()
+		} else if file != nil {
+			file.Close()
+		}
+	}
+	return device
+}
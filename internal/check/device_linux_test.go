@@ -0,0 +1,49 @@
+//go:build linux
+// +build linux
+
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package check
+
+import (
+	"os"
+	"rpc/pkg/heci"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckDeviceMissing(t *testing.T) {
+	c := newTestChecker()
+	c.stat = func(name string) (os.FileInfo, error) { return nil, os.ErrNotExist }
+	results := c.checkDevice()
+	assert.Equal(t, Fail, results[0].Status)
+	assert.Contains(t, results[0].Message, "not found")
+	assert.Equal(t, Fail, results[1].Status)
+}
+
+func TestCheckDevicePermissionDenied(t *testing.T) {
+	c := newTestChecker()
+	c.open = func(name string, flag int, perm os.FileMode) (*os.File, error) { return nil, os.ErrPermission }
+	results := c.checkDevice()
+	assert.Equal(t, Fail, results[0].Status)
+	assert.Contains(t, results[0].Message, "permission denied")
+	assert.Equal(t, Pass, results[1].Status)
+}
+
+func TestCheckDeviceSecondDevice(t *testing.T) {
+	c := newTestChecker()
+	meiDevices = func() []string { return []string{"/dev/mei0", "/dev/mei1"} }
+	defer func() { meiDevices = heci.Candidates }()
+	c.stat = func(name string) (os.FileInfo, error) {
+		if name == "/dev/mei0" {
+			return nil, os.ErrNotExist
+		}
+		return nil, nil
+	}
+	results := c.checkDevice()
+	assert.Equal(t, Pass, results[0].Status)
+	assert.Contains(t, results[0].Message, "/dev/mei1 is accessible")
+}
@@ -0,0 +1,13 @@
+//go:build windows
+// +build windows
+
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package check
+
+// checkDevice has nothing to add on Windows, the HECI device is located through SetupAPI by checkAccess
+func (c Checker) checkDevice() []Result {
+	return []Result{}
+}
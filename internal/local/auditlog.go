@@ -0,0 +1,302 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package local
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"rpc/pkg/wsman"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Audit record initiator types
+const (
+	initiatorDigest   = 0
+	initiatorKerberos = 1
+	initiatorLocal    = 2
+	initiatorKVMPort  = 3
+)
+
+var initiatorTypeNames = map[uint8]string{
+	initiatorDigest:   "HTTP Digest",
+	initiatorKerberos: "Kerberos",
+	initiatorLocal:    "Local",
+	initiatorKVMPort:  "KVM Default Port",
+}
+
+// auditAppNames are the AMT audit application IDs
+var auditAppNames = map[uint16]string{
+	16: "Security Admin",
+	17: "RCO",
+	18: "Redirection Manager",
+	19: "Firmware Update Manager",
+	20: "Security Audit Log",
+	21: "Network Time",
+	22: "Network Administration",
+	23: "Storage Administration",
+	24: "Event Manager",
+	25: "Circuit Breaker Manager",
+	26: "Agent Presence Manager",
+	27: "Wireless Configuration",
+	28: "EAC",
+	29: "KVM",
+	30: "User Opt-In Events",
+	32: "Screen Blanking",
+	33: "Watchdog Events",
+}
+
+// auditEventNames are the events of the audit applications, keyed by application ID * 100 + event ID
+var auditEventNames = map[int]string{
+	1600: "Provisioning Started",
+	1601: "Provisioning Completed",
+	1602: "ACL Entry Added",
+	1603: "ACL Entry Modified",
+	1604: "ACL Entry Removed",
+	1605: "ACL Access with Invalid Credentials",
+	1606: "ACL Entry State",
+	1607: "TLS State Changed",
+	1608: "TLS Server Certificate Set",
+	1609: "TLS Server Certificate Removed",
+	1610: "TLS Trusted Root Certificate Added",
+	1611: "TLS Trusted Root Certificate Removed",
+	1612: "TLS Preshared Key Set",
+	1613: "Kerberos Settings Modified",
+	1614: "Kerberos Master Key Modified",
+	1615: "Flash Wear-Out Counters Reset",
+	1616: "Power Package Modified",
+	1617: "Set Realm Authentication Mode",
+	1618: "Upgrade Client to Admin Control Mode",
+	1619: "Unprovisioning Started",
+	1800: "IDE-R Session Opened",
+	1801: "IDE-R Session Closed",
+	1802: "IDE-R Enabled",
+	1803: "IDE-R Disabled",
+	1804: "SOL Session Opened",
+	1805: "SOL Session Closed",
+	1806: "SOL Enabled",
+	1807: "SOL Disabled",
+	1808: "KVM Session Started",
+	1809: "KVM Session Ended",
+	1810: "KVM Enabled",
+	1811: "KVM Disabled",
+	1812: "VNC Password Failed 3 Times",
+	1900: "Firmware Updated",
+	1901: "Firmware Update Failed",
+	2000: "Security Audit Log Cleared",
+	2001: "Security Audit Policy Modified",
+	2002: "Security Audit Log Disabled",
+	2003: "Security Audit Log Enabled",
+	2004: "Audit Log Storage Locked",
+	2005: "Audit Log Storage Unlocked",
+	2100: "AMT Time Set",
+	2200: "TCP/IP Parameters Set",
+	2201: "Host Name Set",
+	2202: "Domain Name Set",
+	2203: "VLAN Parameters Set",
+	2204: "Link Policy Set",
+	2205: "IPv6 Parameters Set",
+	2900: "KVM Opt-In Enabled",
+	2901: "KVM Opt-In Disabled",
+	2902: "KVM Password Changed",
+	2903: "KVM Consent Succeeded",
+	2904: "KVM Consent Failed",
+	3000: "Opt-In Policy Changed",
+	3001: "Send Consent Code Event",
+	3002: "Start Opt-In Blocked Event",
+}
+
+// AuditRecord is a decoded AMT_AuditLog record
+type AuditRecord struct {
+	Time          time.Time `json:"time"`
+	AuditAppID    uint16    `json:"auditAppId"`
+	AuditApp      string    `json:"auditApp"`
+	EventID       uint16    `json:"eventId"`
+	Event         string    `json:"event"`
+	InitiatorType uint8     `json:"initiatorType"`
+	InitiatorName string    `json:"initiatorTypeName"`
+	Initiator     string    `json:"initiator"`
+	LocationType  uint8     `json:"locationType"`
+	NetAddress    string    `json:"netAddress"`
+	ExtendedData  string    `json:"extendedData"`
+}
+
+// AuditLog is the AMT security audit log
+type AuditLog struct {
+	TotalRecordCount int           `json:"totalRecordCount"`
+	Records          []AuditRecord `json:"records"`
+}
+
+// recordReader reads the fields of an audit record, remembering the first read past its end
+type recordReader struct {
+	data []byte
+	err  error
+}
+
+func (r *recordReader) next(length int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if length > len(r.data) {
+		r.err = fmt.Errorf("audit record truncated, %d bytes left but %d expected", len(r.data), length)
+		return nil
+	}
+	value := r.data[:length]
+	r.data = r.data[length:]
+	return value
+}
+
+func (r *recordReader) uint8() uint8 {
+	if value := r.next(1); value != nil {
+		return value[0]
+	}
+	return 0
+}
+
+func (r *recordReader) uint16() uint16 {
+	if value := r.next(2); value != nil {
+		return binary.BigEndian.Uint16(value)
+	}
+	return 0
+}
+
+func (r *recordReader) uint32() uint32 {
+	if value := r.next(4); value != nil {
+		return binary.BigEndian.Uint32(value)
+	}
+	return 0
+}
+
+// lengthPrefixed reads a field preceded by its one byte length
+func (r *recordReader) lengthPrefixed() []byte {
+	return r.next(int(r.uint8()))
+}
+
+// decodeAuditRecord decodes a base64 encoded audit record
+func decodeAuditRecord(encoded string) (AuditRecord, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return AuditRecord{}, fmt.Errorf("invalid audit record: %v", err)
+	}
+	reader := &recordReader{data: data}
+	record := AuditRecord{}
+	record.AuditAppID = reader.uint16()
+	record.EventID = reader.uint16()
+	record.InitiatorType = reader.uint8()
+	switch record.InitiatorType {
+	case initiatorDigest:
+		record.Initiator = string(reader.lengthPrefixed())
+	case initiatorKerberos:
+		reader.uint32() // user in domain
+		record.Initiator = formatSID(reader.lengthPrefixed())
+	case initiatorLocal, initiatorKVMPort:
+		record.Initiator = initiatorTypeNames[record.InitiatorType]
+	default:
+		return AuditRecord{}, fmt.Errorf("unknown audit record initiator type %d", record.InitiatorType)
+	}
+	record.Time = time.Unix(int64(reader.uint32()), 0).UTC()
+	record.LocationType = reader.uint8()
+	record.NetAddress = strings.TrimRight(string(reader.lengthPrefixed()), "\x00")
+	record.ExtendedData = hex.EncodeToString(reader.lengthPrefixed())
+	if reader.err != nil {
+		return AuditRecord{}, reader.err
+	}
+	record.InitiatorName = initiatorTypeNames[record.InitiatorType]
+	record.AuditApp = fmt.Sprintf("Unknown (%d)", record.AuditAppID)
+	if name, ok := auditAppNames[record.AuditAppID]; ok {
+		record.AuditApp = name
+	}
+	record.Event = fmt.Sprintf("Event %d", record.EventID)
+	if name, ok := auditEventNames[int(record.AuditAppID)*100+int(record.EventID)]; ok {
+		record.Event = name
+	}
+	return record, nil
+}
+
+// formatSID renders a binary Windows security identifier as S-1-5-21-...
+func formatSID(sid []byte) string {
+	if len(sid) < 8 || len(sid) < 8+4*int(sid[1]) {
+		return hex.EncodeToString(sid)
+	}
+	authority := uint64(0)
+	for _, b := range sid[2:8] {
+		authority = authority<<8 | uint64(b)
+	}
+	formatted := fmt.Sprintf("S-%d-%d", sid[0], authority)
+	for i := 0; i < int(sid[1]); i++ {
+		formatted += fmt.Sprintf("-%d", binary.LittleEndian.Uint32(sid[8+4*i:]))
+	}
+	return formatted
+}
+
+// ReadAuditLog reads the audit log records logged at or after since, all records when since is zero
+func ReadAuditLog(w WSMan, since time.Time) (AuditLog, error) {
+	log := AuditLog{Records: []AuditRecord{}}
+	index := 1
+	for {
+		output := wsman.ReadRecordsOutput{}
+		err := w.Invoke(wsman.AMTAuditLogURI, "ReadRecords", nil, wsman.ReadRecordsInput{StartIndex: index}, &output)
+		if err != nil {
+			return log, err
+		}
+		if output.ReturnValue != 0 {
+			return log, fmt.Errorf("unable to read audit records: %s", newActionResult(output.ReturnValue))
+		}
+		log.TotalRecordCount = output.TotalRecordCount
+		for _, encoded := range output.EventRecords {
+			record, err := decodeAuditRecord(encoded)
+			if err != nil {
+				return log, err
+			}
+			if !record.Time.Before(since) {
+				log.Records = append(log.Records, record)
+			}
+		}
+		index += len(output.EventRecords)
+		if len(output.EventRecords) == 0 || index > output.TotalRecordCount {
+			return log, nil
+		}
+	}
+}
+
+// Failed is always false, a log that could not be read is reported as an error
+func (l AuditLog) Failed() bool {
+	return false
+}
+
+func (l AuditLog) String() string {
+	var b strings.Builder
+	for _, r := range l.Records {
+		fmt.Fprintf(&b, "%s  %-22s %-36s %s", r.Time.Format(time.RFC3339), r.AuditApp, r.Event, r.Initiator)
+		if r.NetAddress != "" {
+			fmt.Fprintf(&b, " (%s)", r.NetAddress)
+		}
+		b.WriteString("\n")
+	}
+	if len(l.Records) == 0 {
+		b.WriteString("No audit records\n")
+	}
+	return b.String()
+}
+
+// CSV writes one line per record after a header line
+func (l AuditLog) CSV() (string, error) {
+	var b bytes.Buffer
+	writer := csv.NewWriter(&b)
+	writer.Write([]string{"time", "auditAppId", "auditApp", "eventId", "event", "initiatorType", "initiator", "locationType", "netAddress", "extendedData"})
+	for _, r := range l.Records {
+		writer.Write([]string{
+			r.Time.Format(time.RFC3339), strconv.Itoa(int(r.AuditAppID)), r.AuditApp, strconv.Itoa(int(r.EventID)), r.Event,
+			r.InitiatorName, r.Initiator, strconv.Itoa(int(r.LocationType)), r.NetAddress, r.ExtendedData,
+		})
+	}
+	writer.Flush()
+	return b.String(), writer.Error()
+}
@@ -0,0 +1,217 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package local
+
+import (
+	"fmt"
+	"rpc/pkg/wsman"
+	"sort"
+	"strings"
+)
+
+// BootDevices maps the boot command devices to CIM_BootSourceSetting instance IDs, BIOS setup is a boot setting instead
+var BootDevices = map[string]string{
+	"pxe":        "Intel(r) AMT: Force PXE Boot",
+	"hdd":        "Intel(r) AMT: Force Hard-drive Boot",
+	"cd":         "Intel(r) AMT: Force CD/DVD Boot",
+	"bios-setup": "",
+}
+
+const (
+	bootConfigurationID = "Intel(r) AMT: Boot Configuration 0"
+	bootSettingDataID   = "Intel(r) AMT:BootSettingData 0"
+	bootServiceName     = "Intel(r) AMT Boot Service"
+	// roleIsNext applies the boot configuration to the next boot only
+	roleIsNext = 1
+)
+
+// BootSource is a device AMT can force the next boot from
+type BootSource struct {
+	InstanceID  string `json:"instanceId"`
+	Device      string `json:"device,omitempty"`
+	Description string `json:"description"`
+}
+
+// BootSettings are the one time boot options AMT passes to the BIOS and the devices it can boot from
+type BootSettings struct {
+	BIOSSetup          bool         `json:"biosSetup"`
+	BIOSPause          bool         `json:"biosPause"`
+	UseSOL             bool         `json:"useSOL"`
+	UseIDER            bool         `json:"useIDER"`
+	LockKeyboard       bool         `json:"lockKeyboard"`
+	LockPowerButton    bool         `json:"lockPowerButton"`
+	LockResetButton    bool         `json:"lockResetButton"`
+	LockSleepButton    bool         `json:"lockSleepButton"`
+	UserPasswordBypass bool         `json:"userPasswordBypass"`
+	Sources            []BootSource `json:"sources"`
+	Next               string       `json:"next,omitempty"`
+	Changes            `json:"changes,omitempty"`
+	Power              *PowerStatus `json:"power,omitempty"`
+	data               wsman.AMTBootSettingData
+}
+
+// GetBootSettings reads the boot options and the boot sources
+func GetBootSettings(w WSMan) (BootSettings, error) {
+	settings := BootSettings{Sources: []BootSource{}}
+	err := w.Get(wsman.AMTBootSettingDataURI, nil, &settings.data)
+	if err != nil {
+		return settings, err
+	}
+	d := settings.data
+	settings.BIOSSetup = d.BIOSSetup
+	settings.BIOSPause = d.BIOSPause
+	settings.UseSOL = d.UseSOL
+	settings.UseIDER = d.UseIDER
+	settings.LockKeyboard = d.LockKeyboard
+	settings.LockPowerButton = d.LockPowerButton
+	settings.LockResetButton = d.LockResetButton
+	settings.LockSleepButton = d.LockSleepButton
+	settings.UserPasswordBypass = d.UserPasswordBypass
+	items, err := w.EnumerateAll(wsman.CIMBootSourceSettingURI)
+	if err != nil {
+		return settings, err
+	}
+	for _, item := range items {
+		source := wsman.CIMBootSourceSetting{}
+		if err := item.Decode(&source); err != nil {
+			return settings, err
+		}
+		settings.Sources = append(settings.Sources, BootSource{
+			InstanceID:  source.InstanceID,
+			Device:      bootDevice(source.InstanceID),
+			Description: source.StructuredBootString,
+		})
+	}
+	return settings, nil
+}
+
+// SetNextBoot forces the next boot from device, then resets or powers on the host when reset is set
+func SetNextBoot(w WSMan, device string, reset bool) (BootSettings, error) {
+	sourceID, ok := BootDevices[device]
+	if !ok {
+		return BootSettings{}, fmt.Errorf("unknown boot device %s", device)
+	}
+	current, err := GetBootSettings(w)
+	if err != nil {
+		return current, err
+	}
+	changes := Changes{}
+
+	// clear the one time options left from an earlier boot before setting the new one
+	data := current.data
+	data.BIOSSetup = sourceID == ""
+	data.BIOSPause = false
+	data.UseSOL = false
+	data.UseIDER = false
+	data.ReflashBIOS = false
+	data.SecureErase = false
+	data.ConfigurationDataReset = false
+	data.BootMediaIndex = 0
+	instanceID := data.InstanceID
+	if instanceID == "" {
+		instanceID = bootSettingDataID
+	}
+	err = w.Put(wsman.AMTBootSettingDataURI, []wsman.Selector{{Name: "InstanceID", Value: instanceID}}, data, nil)
+	if err != nil {
+		return current, err
+	}
+	changes = append(changes, Change{Setting: "bootSettingData", Result: newActionResult(0)})
+
+	input := wsman.ChangeBootOrderInput{}
+	if sourceID != "" {
+		source := wsman.NewReference(wsman.CIMBootSourceSettingURI, wsman.Selector{Name: "InstanceID", Value: sourceID})
+		input.Source = &source
+	}
+	output := wsman.MethodOutput{}
+	err = w.Invoke(wsman.CIMBootConfigSettingURI, "ChangeBootOrder", []wsman.Selector{{Name: "InstanceID", Value: bootConfigurationID}}, input, &output)
+	if err != nil {
+		current.Changes = changes
+		return current, err
+	}
+	order := newActionResult(output.ReturnValue)
+	changes = append(changes, Change{Setting: "bootOrder", Result: order})
+
+	if !order.Failed() {
+		role := wsman.SetBootConfigRoleInput{
+			BootConfigSetting: wsman.NewReference(wsman.CIMBootConfigSettingURI, wsman.Selector{Name: "InstanceID", Value: bootConfigurationID}),
+			Role:              roleIsNext,
+		}
+		output = wsman.MethodOutput{}
+		err = w.Invoke(wsman.CIMBootServiceURI, "SetBootConfigRole", []wsman.Selector{{Name: "Name", Value: bootServiceName}}, role, &output)
+		if err != nil {
+			current.Changes = changes
+			return current, err
+		}
+		changes = append(changes, Change{Setting: "bootConfigRole", Result: newActionResult(output.ReturnValue)})
+	}
+
+	updated, err := GetBootSettings(w)
+	updated.Next = device
+	updated.Changes = changes
+	if err != nil {
+		return updated, err
+	}
+	if reset && !updated.Failed() {
+		power, err := GetPowerStatus(w)
+		if err != nil {
+			return updated, err
+		}
+		// a host that is off is powered on instead of reset
+		action := "reset"
+		if power.PowerState != PowerActions["on"] {
+			action = "on"
+		}
+		power, err = RequestPowerAction(w, action)
+		if err != nil {
+			return updated, err
+		}
+		updated.Power = &power
+	}
+	return updated, nil
+}
+
+// bootDevice returns the boot command device of a boot source
+func bootDevice(instanceID string) string {
+	for device, id := range BootDevices {
+		if id != "" && id == instanceID {
+			return device
+		}
+	}
+	return ""
+}
+
+// Failed reports whether any change or the power action was rejected
+func (s BootSettings) Failed() bool {
+	return s.Changes.Failed() || s.Power != nil && s.Power.Failed()
+}
+
+func (s BootSettings) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "BIOS Setup\t\t: %t\n", s.BIOSSetup)
+	fmt.Fprintf(&b, "BIOS Pause\t\t: %t\n", s.BIOSPause)
+	fmt.Fprintf(&b, "Use SOL\t\t\t: %t\n", s.UseSOL)
+	fmt.Fprintf(&b, "Use IDE-R\t\t: %t\n", s.UseIDER)
+	fmt.Fprintf(&b, "Lock Keyboard\t\t: %t\n", s.LockKeyboard)
+	fmt.Fprintf(&b, "Lock Power Button\t: %t\n", s.LockPowerButton)
+	fmt.Fprintf(&b, "Lock Reset Button\t: %t\n", s.LockResetButton)
+	fmt.Fprintf(&b, "User Password Bypass\t: %t\n", s.UserPasswordBypass)
+	sources := []string{}
+	for _, source := range s.Sources {
+		if source.Device != "" {
+			sources = append(sources, source.Device)
+		}
+	}
+	sort.Strings(sources)
+	fmt.Fprintf(&b, "Boot Sources\t\t: %s\n", strings.Join(sources, ", "))
+	if s.Next != "" {
+		fmt.Fprintf(&b, "Next Boot\t\t: %s\n", s.Next)
+	}
+	s.Changes.write(&b)
+	if s.Power != nil {
+		fmt.Fprintf(&b, "Power Action\t\t: %s\n", s.Power.Action)
+		fmt.Fprintf(&b, "Result\t\t\t: %s\n", s.Power.Result)
+	}
+	return b.String()
+}
@@ -0,0 +1,91 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package local
+
+import (
+	"rpc/pkg/wsman"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bootSource(id string, description string) string {
+	return `<h:CIM_BootSourceSetting xmlns:h="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_BootSourceSetting"><h:ElementName>Intel(r) AMT: Boot Source</h:ElementName><h:FailThroughSupported>2</h:FailThroughSupported><h:InstanceID>` + id + `</h:InstanceID><h:StructuredBootString>` + description + `</h:StructuredBootString></h:CIM_BootSourceSetting>`
+}
+
+func newBootMock(orderReturnValue string) *mockWSMan {
+	m := newPowerMock("0")
+	m.instances[wsman.AMTBootSettingDataURI] = `<g:AMT_BootSettingData xmlns:g="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_BootSettingData"><g:BIOSLastStatus>2</g:BIOSLastStatus><g:BIOSLastStatus>0</g:BIOSLastStatus><g:BIOSPause>false</g:BIOSPause><g:BIOSSetup>false</g:BIOSSetup><g:BootMediaIndex>0</g:BootMediaIndex><g:ConfigurationDataReset>false</g:ConfigurationDataReset><g:ElementName>Intel(r) AMT Boot Configuration Settings</g:ElementName><g:EnforceSecureBoot>false</g:EnforceSecureBoot><g:FirmwareVerbosity>0</g:FirmwareVerbosity><g:ForcedProgressEvents>false</g:ForcedProgressEvents><g:IDERBootDevice>0</g:IDERBootDevice><g:InstanceID>Intel(r) AMT:BootSettingData 0</g:InstanceID><g:LockKeyboard>false</g:LockKeyboard><g:LockPowerButton>false</g:LockPowerButton><g:LockResetButton>false</g:LockResetButton><g:LockSleepButton>false</g:LockSleepButton><g:OwningEntity>Intel(r) AMT</g:OwningEntity><g:ReflashBIOS>false</g:ReflashBIOS><g:SecureErase>false</g:SecureErase><g:UseIDER>true</g:UseIDER><g:UseSOL>true</g:UseSOL><g:UseSafeMode>false</g:UseSafeMode><g:UserPasswordBypass>false</g:UserPasswordBypass></g:AMT_BootSettingData>`
+	m.enumeration[wsman.CIMBootSourceSettingURI] = []string{
+		bootSource("Intel(r) AMT: Force Hard-drive Boot", "CIM:Hard-Disk:1"),
+		bootSource("Intel(r) AMT: Force PXE Boot", "CIM:Network:1"),
+		bootSource("Intel(r) AMT: Force CD/DVD Boot", "CIM:CD/DVD:1"),
+	}
+	m.outputs[wsman.CIMBootConfigSettingURI+"/ChangeBootOrder"] = `<g:ChangeBootOrder_OUTPUT xmlns:g="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_BootConfigSetting"><g:ReturnValue>` + orderReturnValue + `</g:ReturnValue></g:ChangeBootOrder_OUTPUT>`
+	m.outputs[wsman.CIMBootServiceURI+"/SetBootConfigRole"] = `<g:SetBootConfigRole_OUTPUT xmlns:g="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_BootService"><g:ReturnValue>0</g:ReturnValue></g:SetBootConfigRole_OUTPUT>`
+	return m
+}
+
+func TestGetBootSettings(t *testing.T) {
+	settings, err := GetBootSettings(newBootMock("0"))
+	assert.NoError(t, err)
+	assert.True(t, settings.UseSOL)
+	assert.True(t, settings.UseIDER)
+	assert.False(t, settings.BIOSSetup)
+	assert.Len(t, settings.Sources, 3)
+	assert.Equal(t, BootSource{InstanceID: "Intel(r) AMT: Force PXE Boot", Device: "pxe", Description: "CIM:Network:1"}, settings.Sources[1])
+	assert.Contains(t, settings.String(), "Boot Sources\t\t: cd, hdd, pxe\n")
+	assert.False(t, settings.Failed())
+}
+
+func TestSetNextBootPXE(t *testing.T) {
+	m := newBootMock("0")
+	settings, err := SetNextBoot(m, "pxe", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "pxe", settings.Next)
+	assert.Nil(t, settings.Power)
+	assert.False(t, settings.Failed())
+	assert.Len(t, m.invocations, 3)
+	put := m.invocations[0]
+	assert.Equal(t, "Put", put.Method)
+	assert.Equal(t, []wsman.Selector{{Name: "InstanceID", Value: "Intel(r) AMT:BootSettingData 0"}}, put.Selectors)
+	assert.Contains(t, put.Body, "<BIOSSetup>false</BIOSSetup>")
+	assert.Contains(t, put.Body, "<UseIDER>false</UseIDER>")
+	assert.Contains(t, put.Body, "<UseSOL>false</UseSOL>")
+	assert.NotContains(t, put.Body, "BIOSLastStatus")
+	assert.Equal(t, "ChangeBootOrder", m.invocations[1].Method)
+	assert.Contains(t, m.invocations[1].Body, `Name="InstanceID">Intel(r) AMT: Force PXE Boot</Selector>`)
+	assert.Equal(t, "SetBootConfigRole", m.invocations[2].Method)
+	assert.Contains(t, m.invocations[2].Body, `Name="InstanceID">Intel(r) AMT: Boot Configuration 0</Selector>`)
+	assert.Contains(t, m.invocations[2].Body, ">1</Role>")
+}
+
+func TestSetNextBootBIOSSetupWithReset(t *testing.T) {
+	m := newBootMock("0")
+	settings, err := SetNextBoot(m, "bios-setup", true)
+	assert.NoError(t, err)
+	assert.Contains(t, m.invocations[0].Body, "<BIOSSetup>true</BIOSSetup>")
+	assert.Equal(t, `<ChangeBootOrder_INPUT xmlns="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_BootConfigSetting"></ChangeBootOrder_INPUT>`, m.invocations[1].Body)
+	assert.Len(t, m.invocations, 4)
+	assert.Contains(t, m.invocations[3].Body, ">10</PowerState>")
+	assert.Equal(t, "reset", settings.Power.Action)
+	assert.False(t, settings.Failed())
+	assert.Contains(t, settings.String(), "Power Action\t\t: reset\n")
+}
+
+func TestSetNextBootOrderFailed(t *testing.T) {
+	m := newBootMock("1")
+	settings, err := SetNextBoot(m, "cd", true)
+	assert.NoError(t, err)
+	assert.True(t, settings.Failed())
+	// neither the boot role nor the reset are requested after a failure
+	assert.Len(t, m.invocations, 2)
+	assert.Nil(t, settings.Power)
+}
+
+func TestSetNextBootUnknownDevice(t *testing.T) {
+	_, err := SetNextBoot(newBootMock("0"), "floppy", false)
+	assert.EqualError(t, err, "unknown boot device floppy")
+}
@@ -0,0 +1,196 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package local
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"rpc/pkg/wsman"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// eventRecordLength is the size of a platform event record in AMT_MessageLog
+const eventRecordLength = 21
+
+// maxReadRecords is the number of event records requested per GetRecords call
+const maxReadRecords = 390
+
+// sensorTypeNames are the IPMI sensor types
+var sensorTypeNames = map[uint8]string{
+	1: "Temperature", 2: "Voltage", 3: "Current", 4: "Fan", 5: "Physical Security",
+	6: "Platform Security Violation Attempt", 7: "Processor", 8: "Power Supply", 9: "Power Unit",
+	10: "Cooling Device", 11: "Other Units-based Sensor", 12: "Memory", 13: "Drive Slot",
+	14: "POST Memory Resize", 15: "System Firmware Progress", 16: "Event Logging Disabled",
+	17: "Watchdog 1", 18: "System Event", 19: "Critical Interrupt", 20: "Button/Switch",
+	21: "Module/Board", 22: "Microcontroller/Coprocessor", 23: "Add-in Card", 24: "Chassis",
+	25: "Chip Set", 26: "Other FRU", 27: "Cable/Interconnect", 28: "Terminator",
+	29: "System Boot/Restart Initiated", 30: "Boot Error", 31: "Base OS Boot/Installation Status",
+	32: "OS Stop/Shutdown", 33: "Slot/Connector", 34: "System ACPI Power State", 35: "Watchdog 2",
+	36: "Platform Alert", 37: "Entity Presence", 38: "Monitor ASIC/IC", 39: "LAN",
+	40: "Management Subsystem Health", 41: "Battery", 42: "Session Audit", 43: "Version Change",
+	44: "FRU State",
+}
+
+// entityNames are the IPMI entity IDs, with 35 used by AMT for the Intel(r) ME
+var entityNames = []string{
+	"Unspecified", "Other", "Unknown", "Processor", "Disk", "Peripheral", "System management module",
+	"System board", "Memory module", "Processor module", "Power supply", "Add in card", "Front panel board",
+	"Back panel board", "Power system board", "Drive backplane", "System internal expansion board",
+	"Other system board", "Processor board", "Power unit", "Power module", "Power management board",
+	"Chassis back panel board", "System chassis", "Sub chassis", "Other chassis board", "Disk drive bay",
+	"Peripheral bay", "Device bay", "Fan cooling", "Cooling unit", "Cable interconnect", "Memory device",
+	"System management software", "BIOS", "Intel(r) ME", "System bus", "Group", "Remote management communication device",
+	"External environment", "Battery", "Processing blade", "Connectivity switch", "Processor/memory module",
+	"I/O module", "Processor I/O module", "Management controller firmware", "IPMI channel", "PCI bus",
+	"PCI express bus", "SCSI bus", "SATA/SAS bus", "Processor front side bus",
+}
+
+// severityNames are the platform event trap severities
+var severityNames = map[uint8]string{
+	0:  "Unspecified",
+	1:  "Monitor",
+	2:  "Information",
+	4:  "OK",
+	8:  "Non-critical",
+	16: "Critical",
+	32: "Non-recoverable",
+}
+
+// EventRecord is a decoded AMT_MessageLog platform event record
+type EventRecord struct {
+	Time            time.Time `json:"time"`
+	DeviceAddress   uint8     `json:"deviceAddress"`
+	EventSensorType uint8     `json:"eventSensorType"`
+	SensorType      string    `json:"sensorType"`
+	EventType       uint8     `json:"eventType"`
+	EventOffset     uint8     `json:"eventOffset"`
+	EventSourceType uint8     `json:"eventSourceType"`
+	EventSeverity   uint8     `json:"eventSeverity"`
+	Severity        string    `json:"severity"`
+	SensorNumber    uint8     `json:"sensorNumber"`
+	Entity          uint8     `json:"entity"`
+	EntityName      string    `json:"entityName"`
+	EntityInstance  uint8     `json:"entityInstance"`
+	EventData       string    `json:"eventData"`
+}
+
+// EventLog is the AMT event log
+type EventLog struct {
+	Records []EventRecord `json:"records"`
+}
+
+// decodeEventRecord decodes a base64 encoded event record
+func decodeEventRecord(encoded string) (EventRecord, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return EventRecord{}, fmt.Errorf("invalid event record: %v", err)
+	}
+	if len(data) < eventRecordLength {
+		return EventRecord{}, fmt.Errorf("event record is %d bytes, expected %d", len(data), eventRecordLength)
+	}
+	record := EventRecord{
+		Time:            time.Unix(int64(binary.LittleEndian.Uint32(data[0:4])), 0).UTC(),
+		DeviceAddress:   data[4],
+		EventSensorType: data[5],
+		EventType:       data[6],
+		EventOffset:     data[7],
+		EventSourceType: data[8],
+		EventSeverity:   data[9],
+		SensorNumber:    data[10],
+		Entity:          data[11],
+		EntityInstance:  data[12],
+		EventData:       hex.EncodeToString(data[13:21]),
+	}
+	record.SensorType = lookupName(sensorTypeNames, record.EventSensorType)
+	record.Severity = lookupName(severityNames, record.EventSeverity)
+	record.EntityName = fmt.Sprintf("Unknown (%d)", record.Entity)
+	if int(record.Entity) < len(entityNames) {
+		record.EntityName = entityNames[record.Entity]
+	}
+	return record, nil
+}
+
+func lookupName(names map[uint8]string, value uint8) string {
+	if name, ok := names[value]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (%d)", value)
+}
+
+// ReadEventLog reads the event log records logged at or after since, all records when since is zero
+func ReadEventLog(w WSMan, since time.Time) (EventLog, error) {
+	log := EventLog{Records: []EventRecord{}}
+	position := wsman.PositionToFirstRecordOutput{}
+	err := w.Invoke(wsman.AMTMessageLogURI, "PositionToFirstRecord", nil, wsman.PositionToFirstRecordInput{}, &position)
+	if err != nil {
+		return log, err
+	}
+	if position.ReturnValue != 0 {
+		return log, fmt.Errorf("unable to position to the first event record: %s", newActionResult(position.ReturnValue))
+	}
+	identifier := position.IterationIdentifier
+	for {
+		output := wsman.GetRecordsOutput{}
+		err = w.Invoke(wsman.AMTMessageLogURI, "GetRecords", nil, wsman.GetRecordsInput{IterationIdentifier: identifier, MaxReadRecords: maxReadRecords}, &output)
+		if err != nil {
+			return log, err
+		}
+		if output.ReturnValue != 0 {
+			return log, fmt.Errorf("unable to read event records: %s", newActionResult(output.ReturnValue))
+		}
+		for _, encoded := range output.RecordArray {
+			record, err := decodeEventRecord(encoded)
+			if err != nil {
+				return log, err
+			}
+			if !record.Time.Before(since) {
+				log.Records = append(log.Records, record)
+			}
+		}
+		// an unchanged identifier would request the same page again
+		if output.NoMoreRecords || len(output.RecordArray) == 0 || output.IterationIdentifier == identifier {
+			return log, nil
+		}
+		identifier = output.IterationIdentifier
+	}
+}
+
+// Failed is always false, a log that could not be read is reported as an error
+func (l EventLog) Failed() bool {
+	return false
+}
+
+func (l EventLog) String() string {
+	var b strings.Builder
+	for _, r := range l.Records {
+		fmt.Fprintf(&b, "%s  %-15s %-28s %s\n", r.Time.Format(time.RFC3339), r.Severity, r.EntityName, r.SensorType)
+	}
+	if len(l.Records) == 0 {
+		b.WriteString("No event records\n")
+	}
+	return b.String()
+}
+
+// CSV writes one line per record after a header line
+func (l EventLog) CSV() (string, error) {
+	var b bytes.Buffer
+	writer := csv.NewWriter(&b)
+	writer.Write([]string{"time", "severity", "entity", "entityInstance", "sensorType", "sensorNumber", "eventType", "eventOffset", "eventSourceType", "deviceAddress", "eventData"})
+	for _, r := range l.Records {
+		writer.Write([]string{
+			r.Time.Format(time.RFC3339), r.Severity, r.EntityName, strconv.Itoa(int(r.EntityInstance)), r.SensorType,
+			strconv.Itoa(int(r.SensorNumber)), strconv.Itoa(int(r.EventType)), strconv.Itoa(int(r.EventOffset)),
+			strconv.Itoa(int(r.EventSourceType)), strconv.Itoa(int(r.DeviceAddress)), r.EventData,
+		})
+	}
+	writer.Flush()
+	return b.String(), writer.Error()
+}
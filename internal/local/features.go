@@ -0,0 +1,218 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package local
+
+import (
+	"fmt"
+	"rpc/pkg/wsman"
+	"strings"
+)
+
+// AMT_RedirectionService enabled states for the combinations of IDE-R and SOL
+const (
+	redirectionDisabled = 32768
+	redirectionIDER     = 1
+	redirectionSOL      = 2
+)
+
+// CIM_KVMRedirectionSAP states
+const (
+	kvmEnabled  = 2
+	kvmDisabled = 3
+	// kvmEnabledButOffline means KVM is enabled but the redirection listener is disabled
+	kvmEnabledButOffline = 6
+)
+
+// UserConsentPolicies maps the user consent names to IPS_OptInService OptInRequired values
+var UserConsentPolicies = map[string]uint32{
+	"none": 0,
+	"kvm":  1,
+	"all":  0xFFFFFFFF,
+}
+
+var optInStates = map[int]string{
+	0: "Not Started",
+	1: "Requested",
+	2: "Displayed",
+	3: "Received",
+	4: "In Session",
+}
+
+// FeatureChanges holds the features to change, nil fields and an empty user consent are left unchanged
+type FeatureChanges struct {
+	KVM         *bool
+	SOL         *bool
+	IDER        *bool
+	UserConsent string
+}
+
+// Features is the redirection and user consent configuration
+type Features struct {
+	RedirectionListener  bool   `json:"redirectionListener"`
+	KVM                  bool   `json:"kvm"`
+	KVMEnabledByMEBx     bool   `json:"kvmEnabledByMEBx"`
+	SOL                  bool   `json:"sol"`
+	IDER                 bool   `json:"ider"`
+	UserConsent          string `json:"userConsent"`
+	CanModifyUserConsent bool   `json:"canModifyUserConsent"`
+	OptInState           string `json:"optInState"`
+	Changes              `json:"changes,omitempty"`
+	redirection          wsman.AMTRedirectionService
+	optIn                wsman.IPSOptInService
+}
+
+// GetFeatures reads the redirection services and the user consent policy
+func GetFeatures(w WSMan) (Features, error) {
+	features := Features{}
+	err := w.Get(wsman.AMTRedirectionServiceURI, nil, &features.redirection)
+	if err != nil {
+		return features, err
+	}
+	kvm := wsman.CIMKVMRedirectionSAP{}
+	err = w.Get(wsman.CIMKVMRedirectionSAPURI, nil, &kvm)
+	if err != nil {
+		return features, err
+	}
+	kvmSettings := wsman.IPSKVMRedirectionSettingData{}
+	err = w.Get(wsman.IPSKVMRedirectionSettingDataURI, nil, &kvmSettings)
+	if err != nil {
+		return features, err
+	}
+	err = w.Get(wsman.IPSOptInServiceURI, nil, &features.optIn)
+	if err != nil {
+		return features, err
+	}
+	state := features.redirection.EnabledState - redirectionDisabled
+	if state < 0 || state > redirectionIDER|redirectionSOL {
+		return features, fmt.Errorf("unexpected redirection service enabled state %d", features.redirection.EnabledState)
+	}
+	features.RedirectionListener = features.redirection.ListenerEnabled
+	features.IDER = state&redirectionIDER != 0
+	features.SOL = state&redirectionSOL != 0
+	features.KVM = kvm.EnabledState == kvmEnabled || kvm.EnabledState == kvmEnabledButOffline
+	features.KVMEnabledByMEBx = kvmSettings.EnabledByMEBx
+	features.UserConsent = userConsentName(features.optIn.OptInRequired)
+	features.CanModifyUserConsent = features.optIn.CanModifyOptInPolicy == 1
+	features.OptInState = optInStates[features.optIn.OptInState]
+	return features, nil
+}
+
+// SetFeatures applies changes and returns the resulting configuration with the result of each change
+func SetFeatures(w WSMan, changes FeatureChanges) (Features, error) {
+	current, err := GetFeatures(w)
+	if err != nil {
+		return current, err
+	}
+	results := Changes{}
+	ider, sol, kvm := current.IDER, current.SOL, current.KVM
+	if changes.IDER != nil {
+		ider = *changes.IDER
+	}
+	if changes.SOL != nil {
+		sol = *changes.SOL
+	}
+	if changes.KVM != nil {
+		kvm = *changes.KVM
+	}
+	if changes.UserConsent != "" {
+		required, ok := UserConsentPolicies[changes.UserConsent]
+		if !ok {
+			return current, fmt.Errorf("unknown user consent policy %s", changes.UserConsent)
+		}
+		if required != current.optIn.OptInRequired && !current.CanModifyUserConsent {
+			return current, fmt.Errorf("the user consent policy can only be changed in admin control mode")
+		}
+	}
+	// the listener has to be enabled for any redirection session to start
+	listener := ider || sol || kvm
+	if listener != current.RedirectionListener {
+		service := current.redirection
+		service.ListenerEnabled = listener
+		err = w.Put(wsman.AMTRedirectionServiceURI, nil, service, nil)
+		if err != nil {
+			current.Changes = results
+			return current, err
+		}
+		results = append(results, Change{Setting: "redirectionListener", Result: newActionResult(0)})
+	}
+	if ider != current.IDER || sol != current.SOL {
+		state := redirectionDisabled
+		if ider {
+			state += redirectionIDER
+		}
+		if sol {
+			state += redirectionSOL
+		}
+		result, err := requestStateChange(w, wsman.AMTRedirectionServiceURI, state)
+		if err != nil {
+			current.Changes = results
+			return current, err
+		}
+		results = append(results, Change{Setting: "sol/ider", Result: result})
+	}
+	if kvm != current.KVM {
+		state := kvmDisabled
+		if kvm {
+			state = kvmEnabled
+		}
+		result, err := requestStateChange(w, wsman.CIMKVMRedirectionSAPURI, state)
+		if err != nil {
+			current.Changes = results
+			return current, err
+		}
+		results = append(results, Change{Setting: "kvm", Result: result})
+	}
+	if changes.UserConsent != "" && UserConsentPolicies[changes.UserConsent] != current.optIn.OptInRequired {
+		service := current.optIn
+		service.OptInRequired = UserConsentPolicies[changes.UserConsent]
+		err = w.Put(wsman.IPSOptInServiceURI, nil, service, nil)
+		if err != nil {
+			current.Changes = results
+			return current, err
+		}
+		results = append(results, Change{Setting: "userConsent", Result: newActionResult(0)})
+	}
+	updated, err := GetFeatures(w)
+	updated.Changes = results
+	return updated, err
+}
+
+func requestStateChange(w WSMan, resourceURI string, state int) (*ActionResult, error) {
+	output := wsman.MethodOutput{}
+	err := w.Invoke(resourceURI, "RequestStateChange", nil, wsman.NewRequestStateChange(resourceURI, state), &output)
+	if err != nil {
+		return nil, err
+	}
+	return newActionResult(output.ReturnValue), nil
+}
+
+func userConsentName(required uint32) string {
+	for name, value := range UserConsentPolicies {
+		if value == required {
+			return name
+		}
+	}
+	return fmt.Sprintf("unknown (%d)", required)
+}
+
+func (f Features) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Redirection Listener\t: %s\n", enabledString(f.RedirectionListener))
+	fmt.Fprintf(&b, "KVM\t\t\t: %s\n", enabledString(f.KVM))
+	fmt.Fprintf(&b, "KVM Enabled in MEBx\t: %t\n", f.KVMEnabledByMEBx)
+	fmt.Fprintf(&b, "SOL\t\t\t: %s\n", enabledString(f.SOL))
+	fmt.Fprintf(&b, "IDE-R\t\t\t: %s\n", enabledString(f.IDER))
+	fmt.Fprintf(&b, "User Consent\t\t: %s\n", f.UserConsent)
+	fmt.Fprintf(&b, "Opt-In State\t\t: %s\n", f.OptInState)
+	f.Changes.write(&b)
+	return b.String()
+}
+
+func enabledString(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
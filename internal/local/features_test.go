@@ -0,0 +1,111 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package local
+
+import (
+	"rpc/pkg/wsman"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFeaturesMock(redirectionState string, listener string, kvmState string, canModify string) *mockWSMan {
+	m := newMockWSMan()
+	m.instances[wsman.AMTRedirectionServiceURI] = `<g:AMT_RedirectionService xmlns:g="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_RedirectionService"><g:CreationClassName>AMT_RedirectionService</g:CreationClassName><g:ElementName>Intel(r) AMT Redirection Service</g:ElementName><g:EnabledState>` + redirectionState + `</g:EnabledState><g:ListenerEnabled>` + listener + `</g:ListenerEnabled><g:Name>Intel(r) AMT Redirection Service</g:Name><g:SystemCreationClassName>CIM_ComputerSystem</g:SystemCreationClassName><g:SystemName>Intel(r) AMT</g:SystemName></g:AMT_RedirectionService>`
+	m.instances[wsman.CIMKVMRedirectionSAPURI] = `<g:CIM_KVMRedirectionSAP xmlns:g="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_KVMRedirectionSAP"><g:CreationClassName>CIM_KVMRedirectionSAP</g:CreationClassName><g:ElementName>KVM Redirection Service Access Point</g:ElementName><g:EnabledState>` + kvmState + `</g:EnabledState><g:KVMProtocol>4</g:KVMProtocol><g:Name>KVM Redirection Service Access Point</g:Name><g:RequestedState>5</g:RequestedState><g:SystemCreationClassName>CIM_ComputerSystem</g:SystemCreationClassName></g:CIM_KVMRedirectionSAP>`
+	m.instances[wsman.IPSKVMRedirectionSettingDataURI] = `<g:IPS_KVMRedirectionSettingData xmlns:g="http://intel.com/wbem/wscim/1/ips-schema/1/IPS_KVMRedirectionSettingData"><g:DefaultScreen>0</g:DefaultScreen><g:ElementName>Intel(r) KVM Redirection Settings</g:ElementName><g:EnabledByMEBx>true</g:EnabledByMEBx><g:InstanceID>Intel(r) KVM Redirection Settings</g:InstanceID><g:Is5900PortEnabled>false</g:Is5900PortEnabled><g:OptInPolicy>false</g:OptInPolicy><g:SessionTimeout>0</g:SessionTimeout></g:IPS_KVMRedirectionSettingData>`
+	m.instances[wsman.IPSOptInServiceURI] = `<g:IPS_OptInService xmlns:g="http://intel.com/wbem/wscim/1/ips-schema/1/IPS_OptInService"><g:CanModifyOptInPolicy>` + canModify + `</g:CanModifyOptInPolicy><g:CreationClassName>IPS_OptInService</g:CreationClassName><g:ElementName>Intel(r) AMT OptIn Service</g:ElementName><g:Name>Intel(r) AMT OptIn Service</g:Name><g:OptInCodeTimeout>120</g:OptInCodeTimeout><g:OptInDisplayTimeout>300</g:OptInDisplayTimeout><g:OptInRequired>4294967295</g:OptInRequired><g:OptInState>0</g:OptInState><g:SystemCreationClassName>CIM_ComputerSystem</g:SystemCreationClassName><g:SystemName>Intel(r) AMT</g:SystemName></g:IPS_OptInService>`
+	output := `<g:RequestStateChange_OUTPUT xmlns:g="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_RedirectionService"><g:ReturnValue>0</g:ReturnValue></g:RequestStateChange_OUTPUT>`
+	m.outputs[wsman.AMTRedirectionServiceURI+"/RequestStateChange"] = output
+	m.outputs[wsman.CIMKVMRedirectionSAPURI+"/RequestStateChange"] = output
+	return m
+}
+
+func TestGetFeatures(t *testing.T) {
+	features, err := GetFeatures(newFeaturesMock("32770", "true", "2", "1"))
+	assert.NoError(t, err)
+	assert.True(t, features.RedirectionListener)
+	assert.True(t, features.SOL)
+	assert.False(t, features.IDER)
+	assert.True(t, features.KVM)
+	assert.True(t, features.KVMEnabledByMEBx)
+	assert.Equal(t, "all", features.UserConsent)
+	assert.True(t, features.CanModifyUserConsent)
+	assert.Equal(t, "Not Started", features.OptInState)
+	assert.False(t, features.Failed())
+}
+
+func TestGetFeaturesUnexpectedRedirectionState(t *testing.T) {
+	_, err := GetFeatures(newFeaturesMock("2", "true", "2", "1"))
+	assert.EqualError(t, err, "unexpected redirection service enabled state 2")
+	_, err = GetFeatures(newFeaturesMock("32772", "true", "2", "1"))
+	assert.EqualError(t, err, "unexpected redirection service enabled state 32772")
+}
+
+func TestSetFeaturesEnableKVM(t *testing.T) {
+	m := newFeaturesMock("32768", "false", "3", "1")
+	enable := true
+	features, err := SetFeatures(m, FeatureChanges{KVM: &enable})
+	assert.NoError(t, err)
+	assert.Len(t, m.invocations, 2)
+	assert.Equal(t, "Put", m.invocations[0].Method)
+	assert.Contains(t, m.invocations[0].Body, "<ListenerEnabled>true</ListenerEnabled>")
+	assert.Equal(t, wsman.CIMKVMRedirectionSAPURI, m.invocations[1].ResourceURI)
+	assert.Equal(t, `<RequestStateChange_INPUT xmlns="`+wsman.CIMKVMRedirectionSAPURI+`"><RequestedState>2</RequestedState></RequestStateChange_INPUT>`, m.invocations[1].Body)
+	assert.Len(t, features.Changes, 2)
+	assert.False(t, features.Failed())
+}
+
+func TestSetFeaturesKeepsChangesOnError(t *testing.T) {
+	m := newFeaturesMock("32768", "false", "3", "1")
+	delete(m.outputs, wsman.CIMKVMRedirectionSAPURI+"/RequestStateChange")
+	enable := true
+	features, err := SetFeatures(m, FeatureChanges{KVM: &enable})
+	assert.EqualError(t, err, "unexpected call to RequestStateChange")
+	assert.Equal(t, Changes{{Setting: "redirectionListener", Result: newActionResult(0)}}, features.Changes)
+	var output ChangeOutput = features
+	assert.True(t, output.Changed())
+}
+
+func TestSetFeaturesSOLAndIDER(t *testing.T) {
+	m := newFeaturesMock("32770", "true", "2", "1")
+	enable := true
+	_, err := SetFeatures(m, FeatureChanges{IDER: &enable})
+	assert.NoError(t, err)
+	assert.Len(t, m.invocations, 1)
+	assert.Contains(t, m.invocations[0].Body, "<RequestedState>32771</RequestedState>")
+}
+
+func TestSetFeaturesDisableAll(t *testing.T) {
+	m := newFeaturesMock("32770", "true", "2", "1")
+	disable := false
+	_, err := SetFeatures(m, FeatureChanges{KVM: &disable, SOL: &disable})
+	assert.NoError(t, err)
+	assert.Len(t, m.invocations, 3)
+	assert.Contains(t, m.invocations[0].Body, "<ListenerEnabled>false</ListenerEnabled>")
+	assert.Contains(t, m.invocations[1].Body, "<RequestedState>32768</RequestedState>")
+	assert.Contains(t, m.invocations[2].Body, "<RequestedState>3</RequestedState>")
+}
+
+func TestSetFeaturesUserConsent(t *testing.T) {
+	m := newFeaturesMock("32770", "true", "2", "1")
+	_, err := SetFeatures(m, FeatureChanges{UserConsent: "kvm"})
+	assert.NoError(t, err)
+	assert.Len(t, m.invocations, 1)
+	assert.Equal(t, wsman.IPSOptInServiceURI, m.invocations[0].ResourceURI)
+	assert.Contains(t, m.invocations[0].Body, "<OptInRequired>1</OptInRequired>")
+}
+
+func TestSetFeaturesUserConsentNotModifiable(t *testing.T) {
+	m := newFeaturesMock("32770", "true", "2", "0")
+	_, err := SetFeatures(m, FeatureChanges{UserConsent: "none"})
+	assert.EqualError(t, err, "the user consent policy can only be changed in admin control mode")
+	assert.Len(t, m.invocations, 0)
+}
+
+func TestSetFeaturesUnknownUserConsent(t *testing.T) {
+	_, err := SetFeatures(newFeaturesMock("32770", "true", "2", "1"), FeatureChanges{UserConsent: "sometimes"})
+	assert.EqualError(t, err, "unknown user consent policy sometimes")
+}
@@ -0,0 +1,35 @@
+//go:build go1.18
+// +build go1.18
+
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package local
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func decodeSeed(encoded string) []byte {
+	data, _ := base64.StdEncoding.DecodeString(encoded)
+	return data
+}
+
+func FuzzDecodeAuditRecord(f *testing.F) {
+	f.Add(decodeSeed(auditRecord(18, 8, "admin", time.Unix(0, 0), "192.168.1.10")))
+	f.Add([]byte{0, 16, 0, 1, initiatorLocal, 0, 0, 0, 0, 2, 0, 0})
+	f.Add([]byte{0, 18, 0, 8, initiatorKerberos, 0, 0, 0, 0, 8, 1, 0, 0, 0, 0, 0, 0, 5})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		decodeAuditRecord(base64.StdEncoding.EncodeToString(data))
+	})
+}
+
+func FuzzDecodeEventRecord(f *testing.F) {
+	f.Add(decodeSeed(eventRecord(time.Unix(0, 0), 15, 2, 34)))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		decodeEventRecord(base64.StdEncoding.EncodeToString(data))
+	})
+}
@@ -0,0 +1,367 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package local
+
+import (
+	"fmt"
+	"rpc/pkg/osinfo"
+	"rpc/pkg/smbios"
+	"rpc/pkg/wsman"
+	"sort"
+	"strings"
+	"time"
+)
+
+// diskSizeTolerance is the relative difference allowed between the AMT and OS disk sizes, which round differently
+const diskSizeTolerance = 0.01
+
+// cpuStatusNames are the CIM_Processor CPUStatus values
+var cpuStatusNames = map[int]string{
+	0: "Unknown",
+	1: "Enabled",
+	2: "Disabled by User",
+	3: "Disabled by BIOS",
+	4: "Idle",
+	7: "Other",
+}
+
+// SystemInfo identifies the system
+type SystemInfo struct {
+	Manufacturer string `json:"manufacturer"`
+	Model        string `json:"model"`
+	SerialNumber string `json:"serialNumber"`
+}
+
+// BIOSInfo identifies the BIOS, with the release date formatted as 2006-01-02
+type BIOSInfo struct {
+	Vendor      string `json:"vendor"`
+	Version     string `json:"version"`
+	ReleaseDate string `json:"releaseDate"`
+}
+
+// ProcessorInfo is an installed processor
+type ProcessorInfo struct {
+	Name             string `json:"name"`
+	Family           int    `json:"family"`
+	Stepping         string `json:"stepping"`
+	MaxClockSpeedMHz int    `json:"maxClockSpeedMHz"`
+	Status           string `json:"status"`
+}
+
+// MemoryInfo is an installed memory module
+type MemoryInfo struct {
+	Slot         string `json:"slot"`
+	Manufacturer string `json:"manufacturer"`
+	PartNumber   string `json:"partNumber"`
+	SerialNumber string `json:"serialNumber"`
+	CapacityMB   uint64 `json:"capacityMB"`
+	SpeedMHz     int    `json:"speedMHz"`
+}
+
+// DiskInfo is an installed disk
+type DiskInfo struct {
+	Name          string `json:"name"`
+	Model         string `json:"model,omitempty"`
+	SerialNumber  string `json:"serialNumber,omitempty"`
+	CapacityBytes uint64 `json:"capacityBytes"`
+}
+
+// Hardware is the normalized hardware inventory reported by AMT or by the OS
+type Hardware struct {
+	System     SystemInfo      `json:"system"`
+	BIOS       BIOSInfo        `json:"bios"`
+	Processors []ProcessorInfo `json:"processors,omitempty"`
+	Memory     []MemoryInfo    `json:"memory"`
+	Disks      []DiskInfo      `json:"disks"`
+}
+
+// Difference is a mismatch between the AMT and OS inventories
+type Difference struct {
+	Component string `json:"component"`
+	AMT       string `json:"amt"`
+	OS        string `json:"os"`
+}
+
+// HardwareInventory is the AMT hardware inventory, optionally compared with the OS inventory
+type HardwareInventory struct {
+	AMT         Hardware     `json:"amt"`
+	OS          *Hardware    `json:"os,omitempty"`
+	Differences []Difference `json:"differences,omitempty"`
+}
+
+// readOSHardware is replaced in tests
+var readOSHardware = func() (Hardware, error) {
+	hardware := Hardware{Memory: []MemoryInfo{}, Disks: []DiskInfo{}}
+	info, err := smbios.Read()
+	if err != nil {
+		return hardware, err
+	}
+	hardware.System = SystemInfo{Manufacturer: info.Manufacturer, Model: info.Model, SerialNumber: info.Serial}
+	if bios, err := smbios.ReadBIOS(); err == nil {
+		hardware.BIOS = BIOSInfo{Vendor: bios.Vendor, Version: bios.Version, ReleaseDate: normalizeDate(bios.ReleaseDate)}
+	}
+	modules, err := smbios.ReadMemoryDevices()
+	if err != nil {
+		return hardware, fmt.Errorf("unable to read the SMBIOS memory devices: %v", err)
+	}
+	for _, module := range modules {
+		hardware.Memory = append(hardware.Memory, MemoryInfo{
+			Slot:         module.Locator,
+			Manufacturer: module.Manufacturer,
+			PartNumber:   module.PartNumber,
+			SerialNumber: module.SerialNumber,
+			CapacityMB:   module.SizeMB,
+			SpeedMHz:     module.Speed,
+		})
+	}
+	disks, err := osinfo.Disks()
+	if err != nil {
+		return hardware, err
+	}
+	for _, disk := range disks {
+		hardware.Disks = append(hardware.Disks, DiskInfo{Name: disk.Name, Model: disk.Model, SerialNumber: disk.SerialNumber, CapacityBytes: disk.SizeBytes})
+	}
+	return hardware, nil
+}
+
+// ReadHardwareInventory reads the AMT hardware asset tables and, when compareOS is set, compares them with the OS view
+func ReadHardwareInventory(w WSMan, compareOS bool) (HardwareInventory, error) {
+	inventory := HardwareInventory{}
+	hardware, err := readAMTHardware(w)
+	if err != nil {
+		return inventory, err
+	}
+	inventory.AMT = hardware
+	if compareOS {
+		osHardware, err := readOSHardware()
+		if err != nil {
+			return inventory, err
+		}
+		inventory.OS = &osHardware
+		inventory.Differences = compareHardware(hardware, osHardware)
+	}
+	return inventory, nil
+}
+
+func readAMTHardware(w WSMan) (Hardware, error) {
+	hardware := Hardware{Processors: []ProcessorInfo{}, Memory: []MemoryInfo{}, Disks: []DiskInfo{}}
+	items, err := w.EnumerateAll(wsman.CIMChassisURI)
+	if err != nil {
+		return hardware, err
+	}
+	if len(items) > 0 {
+		chassis := wsman.CIMChassis{}
+		if err := items[0].Decode(&chassis); err != nil {
+			return hardware, err
+		}
+		hardware.System = SystemInfo{Manufacturer: trim(chassis.Manufacturer), Model: trim(chassis.Model), SerialNumber: trim(chassis.SerialNumber)}
+	}
+
+	items, err = w.EnumerateAll(wsman.CIMBIOSElementURI)
+	if err != nil {
+		return hardware, err
+	}
+	for _, item := range items {
+		bios := wsman.CIMBIOSElement{}
+		if err := item.Decode(&bios); err != nil {
+			return hardware, err
+		}
+		if bios.PrimaryBIOS || hardware.BIOS == (BIOSInfo{}) {
+			hardware.BIOS = BIOSInfo{Vendor: trim(bios.Manufacturer), Version: trim(bios.Version), ReleaseDate: normalizeDate(bios.ReleaseDate.Datetime)}
+		}
+	}
+
+	items, err = w.EnumerateAll(wsman.CIMProcessorURI)
+	if err != nil {
+		return hardware, err
+	}
+	for _, item := range items {
+		processor := wsman.CIMProcessor{}
+		if err := item.Decode(&processor); err != nil {
+			return hardware, err
+		}
+		status, ok := cpuStatusNames[processor.CPUStatus]
+		if !ok {
+			status = fmt.Sprintf("Unknown (%d)", processor.CPUStatus)
+		}
+		hardware.Processors = append(hardware.Processors, ProcessorInfo{
+			Name:             trim(processor.ElementName),
+			Family:           processor.Family,
+			Stepping:         trim(processor.Stepping),
+			MaxClockSpeedMHz: processor.MaxClockSpeed,
+			Status:           status,
+		})
+	}
+
+	items, err = w.EnumerateAll(wsman.CIMPhysicalMemoryURI)
+	if err != nil {
+		return hardware, err
+	}
+	for _, item := range items {
+		memory := wsman.CIMPhysicalMemory{}
+		if err := item.Decode(&memory); err != nil {
+			return hardware, err
+		}
+		speed := memory.ConfiguredMemoryClockSpeed
+		if speed == 0 {
+			speed = memory.MaxMemorySpeed
+		}
+		hardware.Memory = append(hardware.Memory, MemoryInfo{
+			Slot:         trim(memory.BankLabel),
+			Manufacturer: trim(memory.Manufacturer),
+			PartNumber:   trim(memory.PartNumber),
+			SerialNumber: trim(memory.SerialNumber),
+			CapacityMB:   memory.Capacity / (1024 * 1024),
+			SpeedMHz:     speed,
+		})
+	}
+
+	items, err = w.EnumerateAll(wsman.CIMMediaAccessDeviceURI)
+	if err != nil {
+		return hardware, err
+	}
+	for _, item := range items {
+		device := wsman.CIMMediaAccessDevice{}
+		if err := item.Decode(&device); err != nil {
+			return hardware, err
+		}
+		hardware.Disks = append(hardware.Disks, DiskInfo{Name: trim(device.DeviceID), Model: trim(device.ElementName), CapacityBytes: device.MaxMediaSize * 1024})
+	}
+	return hardware, nil
+}
+
+// trim removes the padding AMT and SMBIOS leave around string properties
+func trim(value string) string {
+	return strings.TrimSpace(strings.Trim(value, "\x00"))
+}
+
+// normalizeDate formats the CIM datetime and SMBIOS MM/DD/YYYY dates as 2006-01-02
+func normalizeDate(value string) string {
+	for _, layout := range []string{time.RFC3339, "2006-01-02", "01/02/2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	return value
+}
+
+// compareHardware lists the system, BIOS, memory and disk differences between the AMT and OS inventories
+func compareHardware(amt Hardware, os Hardware) []Difference {
+	differences := []Difference{}
+	compare := func(component string, amtValue string, osValue string) {
+		if !strings.EqualFold(trim(amtValue), trim(osValue)) {
+			differences = append(differences, Difference{Component: component, AMT: amtValue, OS: osValue})
+		}
+	}
+	compare("system serial number", amt.System.SerialNumber, os.System.SerialNumber)
+	compare("bios version", amt.BIOS.Version, os.BIOS.Version)
+
+	unmatched := map[string][]MemoryInfo{}
+	for _, module := range os.Memory {
+		key := moduleKey(module)
+		unmatched[key] = append(unmatched[key], module)
+	}
+	for _, module := range amt.Memory {
+		key := moduleKey(module)
+		if len(unmatched[key]) > 0 {
+			unmatched[key] = unmatched[key][1:]
+			continue
+		}
+		differences = append(differences, Difference{Component: "memory module", AMT: describeModule(module)})
+	}
+	for _, module := range os.Memory {
+		key := moduleKey(module)
+		for i, candidate := range unmatched[key] {
+			if candidate == module {
+				unmatched[key] = append(unmatched[key][:i], unmatched[key][i+1:]...)
+				differences = append(differences, Difference{Component: "memory module", OS: describeModule(module)})
+				break
+			}
+		}
+	}
+
+	// AMT does not report disk serial numbers, so disks are compared by count and size
+	amtSizes := diskSizes(amt.Disks)
+	osSizes := diskSizes(os.Disks)
+	if len(amtSizes) != len(osSizes) {
+		differences = append(differences, Difference{Component: "disk count", AMT: fmt.Sprint(len(amtSizes)), OS: fmt.Sprint(len(osSizes))})
+	} else {
+		for i := range amtSizes {
+			larger, smaller := amtSizes[i], osSizes[i]
+			if smaller > larger {
+				larger, smaller = smaller, larger
+			}
+			if float64(larger-smaller) > float64(larger)*diskSizeTolerance {
+				differences = append(differences, Difference{Component: "disk size", AMT: fmt.Sprint(amtSizes[i]), OS: fmt.Sprint(osSizes[i])})
+			}
+		}
+	}
+	return differences
+}
+
+// placeholderSerials are reported by firmware for memory modules without a serial number
+var placeholderSerials = map[string]bool{
+	"":              true,
+	"UNKNOWN":       true,
+	"NOT SPECIFIED": true,
+	"NONE":          true,
+	"N/A":           true,
+	"SERNUM":        true,
+}
+
+// moduleKey identifies a memory module by its serial number, or by slot, capacity and part number when the serial is unknown
+func moduleKey(module MemoryInfo) string {
+	serial := strings.ToUpper(trim(module.SerialNumber))
+	if !placeholderSerials[serial] && strings.Trim(serial, "0") != "" && strings.Trim(serial, "F") != "" {
+		return "serial " + serial
+	}
+	return fmt.Sprintf("slot %s %dMB %s", strings.ToUpper(trim(module.Slot)), module.CapacityMB, strings.ToUpper(trim(module.PartNumber)))
+}
+
+func describeModule(module MemoryInfo) string {
+	return fmt.Sprintf("%s %s %dMB serial %s", module.Slot, module.PartNumber, module.CapacityMB, module.SerialNumber)
+}
+
+// diskSizes returns the sizes of the disks with media, largest first
+func diskSizes(disks []DiskInfo) []uint64 {
+	sizes := []uint64{}
+	for _, disk := range disks {
+		if disk.CapacityBytes > 0 {
+			sizes = append(sizes, disk.CapacityBytes)
+		}
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] > sizes[j] })
+	return sizes
+}
+
+// Failed reports whether the AMT and OS inventories differ
+func (i HardwareInventory) Failed() bool {
+	return len(i.Differences) > 0
+}
+
+func (i HardwareInventory) String() string {
+	var b strings.Builder
+	h := i.AMT
+	fmt.Fprintf(&b, "System\t\t: %s %s, serial %s\n", h.System.Manufacturer, h.System.Model, h.System.SerialNumber)
+	fmt.Fprintf(&b, "BIOS\t\t: %s %s (%s)\n", h.BIOS.Vendor, h.BIOS.Version, h.BIOS.ReleaseDate)
+	for _, p := range h.Processors {
+		fmt.Fprintf(&b, "Processor\t: %s, family %d, stepping %s, %d MHz, %s\n", p.Name, p.Family, p.Stepping, p.MaxClockSpeedMHz, p.Status)
+	}
+	for _, m := range h.Memory {
+		fmt.Fprintf(&b, "Memory\t\t: %s %s %s %d MB %d MHz, serial %s\n", m.Slot, m.Manufacturer, m.PartNumber, m.CapacityMB, m.SpeedMHz, m.SerialNumber)
+	}
+	for _, d := range h.Disks {
+		fmt.Fprintf(&b, "Disk\t\t: %s %s %d bytes\n", d.Name, d.Model, d.CapacityBytes)
+	}
+	if i.OS != nil {
+		if len(i.Differences) == 0 {
+			b.WriteString("The AMT and OS inventories match\n")
+		}
+		for _, d := range i.Differences {
+			fmt.Fprintf(&b, "Difference\t: %s, AMT %q, OS %q\n", d.Component, d.AMT, d.OS)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,131 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package local
+
+import (
+	"errors"
+	"rpc/pkg/wsman"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newHardwareMock() *mockWSMan {
+	m := newMockWSMan()
+	m.enumeration[wsman.CIMChassisURI] = []string{`<h:CIM_Chassis xmlns:h="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_Chassis"><h:ChassisPackageType>10</h:ChassisPackageType><h:ElementName>Managed System Chassis</h:ElementName><h:Manufacturer>LENOVO</h:Manufacturer><h:Model>20XW0055US</h:Model><h:SerialNumber>PF2ABCDE</h:SerialNumber><h:Tag>CIM_Chassis</h:Tag><h:Version>ThinkPad X1</h:Version></h:CIM_Chassis>`}
+	m.enumeration[wsman.CIMBIOSElementURI] = []string{`<h:CIM_BIOSElement xmlns:h="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_BIOSElement"><h:ElementName>Primary BIOS</h:ElementName><h:Manufacturer>LENOVO</h:Manufacturer><h:PrimaryBIOS>true</h:PrimaryBIOS><h:ReleaseDate><Datetime xmlns="http://schemas.dmtf.org/wbem/wscim/1/common">2021-03-05T00:00:00Z</Datetime></h:ReleaseDate><h:Version>N32ET75W (1.51 )</h:Version></h:CIM_BIOSElement>`}
+	m.enumeration[wsman.CIMProcessorURI] = []string{`<h:CIM_Processor xmlns:h="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_Processor"><h:CPUStatus>1</h:CPUStatus><h:CurrentClockSpeed>2800</h:CurrentClockSpeed><h:DeviceID>CPU 0</h:DeviceID><h:ElementName>Managed System CPU</h:ElementName><h:Family>198</h:Family><h:MaxClockSpeed>8300</h:MaxClockSpeed><h:Stepping>1</h:Stepping></h:CIM_Processor>`}
+	m.enumeration[wsman.CIMPhysicalMemoryURI] = []string{
+		`<h:CIM_PhysicalMemory xmlns:h="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_PhysicalMemory"><h:BankLabel>BANK 0</h:BankLabel><h:Capacity>17179869184</h:Capacity><h:ConfiguredMemoryClockSpeed>3200</h:ConfiguredMemoryClockSpeed><h:Manufacturer>Samsung</h:Manufacturer><h:PartNumber>M471A2K43DB1-CWE    </h:PartNumber><h:SerialNumber>12345678</h:SerialNumber></h:CIM_PhysicalMemory>`,
+		`<h:CIM_PhysicalMemory xmlns:h="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_PhysicalMemory"><h:BankLabel>BANK 1</h:BankLabel><h:Capacity>17179869184</h:Capacity><h:MaxMemorySpeed>3200</h:MaxMemorySpeed><h:Manufacturer>Samsung</h:Manufacturer><h:PartNumber>M471A2K43DB1-CWE</h:PartNumber><h:SerialNumber>87654321</h:SerialNumber></h:CIM_PhysicalMemory>`,
+	}
+	m.enumeration[wsman.CIMMediaAccessDeviceURI] = []string{`<h:CIM_MediaAccessDevice xmlns:h="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_MediaAccessDevice"><h:Capabilities>4</h:Capabilities><h:DeviceID>MEDIA DEV 0</h:DeviceID><h:ElementName>Managed System Media Access Device</h:ElementName><h:MaxMediaSize>500107608</h:MaxMediaSize></h:CIM_MediaAccessDevice>`}
+	return m
+}
+
+func osHardware() Hardware {
+	return Hardware{
+		System: SystemInfo{Manufacturer: "LENOVO", Model: "20XW0055US", SerialNumber: "PF2ABCDE"},
+		BIOS:   BIOSInfo{Vendor: "LENOVO", Version: "N32ET75W (1.51 )", ReleaseDate: "2021-03-05"},
+		Memory: []MemoryInfo{
+			{Slot: "DIMM A", PartNumber: "M471A2K43DB1-CWE", SerialNumber: "12345678", CapacityMB: 16384},
+			{Slot: "DIMM B", PartNumber: "M471A2K43DB1-CWE", SerialNumber: "87654321", CapacityMB: 16384},
+		},
+		Disks: []DiskInfo{{Name: "nvme0n1", CapacityBytes: 512110190592}},
+	}
+}
+
+func TestReadHardwareInventory(t *testing.T) {
+	inventory, err := ReadHardwareInventory(newHardwareMock(), false)
+	assert.NoError(t, err)
+	assert.Nil(t, inventory.OS)
+	assert.False(t, inventory.Failed())
+	h := inventory.AMT
+	assert.Equal(t, SystemInfo{Manufacturer: "LENOVO", Model: "20XW0055US", SerialNumber: "PF2ABCDE"}, h.System)
+	assert.Equal(t, BIOSInfo{Vendor: "LENOVO", Version: "N32ET75W (1.51 )", ReleaseDate: "2021-03-05"}, h.BIOS)
+	assert.Equal(t, []ProcessorInfo{{Name: "Managed System CPU", Family: 198, Stepping: "1", MaxClockSpeedMHz: 8300, Status: "Enabled"}}, h.Processors)
+	assert.Equal(t, MemoryInfo{Slot: "BANK 0", Manufacturer: "Samsung", PartNumber: "M471A2K43DB1-CWE", SerialNumber: "12345678", CapacityMB: 16384, SpeedMHz: 3200}, h.Memory[0])
+	assert.Equal(t, 3200, h.Memory[1].SpeedMHz)
+	assert.Equal(t, uint64(512110190592), h.Disks[0].CapacityBytes)
+	assert.Contains(t, inventory.String(), "Memory\t\t: BANK 1 Samsung M471A2K43DB1-CWE 16384 MB 3200 MHz, serial 87654321\n")
+}
+
+func TestReadHardwareInventoryCompareMatch(t *testing.T) {
+	original := readOSHardware
+	defer func() { readOSHardware = original }()
+	readOSHardware = func() (Hardware, error) { return osHardware(), nil }
+	inventory, err := ReadHardwareInventory(newHardwareMock(), true)
+	assert.NoError(t, err)
+	assert.NotNil(t, inventory.OS)
+	assert.Len(t, inventory.Differences, 0)
+	assert.False(t, inventory.Failed())
+	assert.Contains(t, inventory.String(), "The AMT and OS inventories match")
+}
+
+func TestReadHardwareInventoryCompareSwappedDIMM(t *testing.T) {
+	original := readOSHardware
+	defer func() { readOSHardware = original }()
+	readOSHardware = func() (Hardware, error) {
+		h := osHardware()
+		h.Memory[1].SerialNumber = "AAAA0000"
+		h.Disks = append(h.Disks, DiskInfo{Name: "sda", CapacityBytes: 1000204886016})
+		return h, nil
+	}
+	inventory, err := ReadHardwareInventory(newHardwareMock(), true)
+	assert.NoError(t, err)
+	assert.True(t, inventory.Failed())
+	assert.Equal(t, []Difference{
+		{Component: "memory module", AMT: "BANK 1 M471A2K43DB1-CWE 16384MB serial 87654321"},
+		{Component: "memory module", OS: "DIMM B M471A2K43DB1-CWE 16384MB serial AAAA0000"},
+		{Component: "disk count", AMT: "1", OS: "2"},
+	}, inventory.Differences)
+}
+
+func TestReadHardwareInventoryCompareError(t *testing.T) {
+	original := readOSHardware
+	defer func() { readOSHardware = original }()
+	readOSHardware = func() (Hardware, error) { return Hardware{}, errors.New("permission denied") }
+	_, err := ReadHardwareInventory(newHardwareMock(), true)
+	assert.EqualError(t, err, "permission denied")
+}
+
+func TestCompareHardware(t *testing.T) {
+	amt := osHardware()
+	os := osHardware()
+	os.System.SerialNumber = "pf2abcde "
+	os.Disks[0].CapacityBytes = 512000000000
+	assert.Len(t, compareHardware(amt, os), 0)
+	os.BIOS.Version = "N32ET80W (1.56 )"
+	os.Disks[0].CapacityBytes = 256060514304
+	assert.Equal(t, []Difference{
+		{Component: "bios version", AMT: "N32ET75W (1.51 )", OS: "N32ET80W (1.56 )"},
+		{Component: "disk size", AMT: "512110190592", OS: "256060514304"},
+	}, compareHardware(amt, os))
+}
+
+func TestCompareHardwarePlaceholderSerials(t *testing.T) {
+	amt := osHardware()
+	amt.Memory[0].SerialNumber = "00000000"
+	amt.Memory[1].SerialNumber = "00000000"
+	os := osHardware()
+	os.Memory[0].SerialNumber = "00000000"
+	os.Memory[1].SerialNumber = "Not Specified"
+	assert.Len(t, compareHardware(amt, os), 0)
+	os.Memory[1].Slot = "DIMM C"
+	assert.Equal(t, []Difference{
+		{Component: "memory module", AMT: "DIMM B M471A2K43DB1-CWE 16384MB serial 00000000"},
+		{Component: "memory module", OS: "DIMM C M471A2K43DB1-CWE 16384MB serial Not Specified"},
+	}, compareHardware(amt, os))
+	os.Memory = os.Memory[:1]
+	assert.Equal(t, []Difference{
+		{Component: "memory module", AMT: "DIMM B M471A2K43DB1-CWE 16384MB serial 00000000"},
+	}, compareHardware(amt, os))
+}
+
+func TestNormalizeDate(t *testing.T) {
+	assert.Equal(t, "2021-03-05", normalizeDate("03/05/2021"))
+	assert.Equal(t, "2021-03-05", normalizeDate("2021-03-05T00:00:00Z"))
+	assert.Equal(t, "unknown", normalizeDate("unknown"))
+}
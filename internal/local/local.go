@@ -0,0 +1,128 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package local
+
+import (
+	"fmt"
+	"rpc/pkg/wsman"
+	"strings"
+	"time"
+)
+
+// AdminUser is the AMT account authenticated with the AMT password
+const AdminUser = "admin"
+
+// WSMan is the part of the WS-Management client used by the local commands
+type WSMan interface {
+	Get(resourceURI string, selectors []wsman.Selector, out interface{}) error
+	Put(resourceURI string, selectors []wsman.Selector, in interface{}, out interface{}) error
+	Invoke(resourceURI string, method string, selectors []wsman.Selector, in interface{}, out interface{}) error
+	Delete(resourceURI string, selectors []wsman.Selector) error
+	EnumerateAll(resourceURI string) ([]wsman.Item, error)
+}
+
+// Output is the result of a local command, printed as text or marshalled to JSON
+type Output interface {
+	String() string
+	Failed() bool
+}
+
+// ChangeOutput is implemented by outputs that record the changes a command made, which are kept when a later step fails
+type ChangeOutput interface {
+	Output
+	Changed() bool
+}
+
+// CSVOutput is implemented by outputs that can also be printed as CSV
+type CSVOutput interface {
+	CSV() (string, error)
+}
+
+// NewWSMan connects to AMT through LMS as the administrator
+func NewWSMan(password string) WSMan {
+	return wsman.NewClient(wsman.DefaultEndpoint, AdminUser, password)
+}
+
+// ActionResult is the return value of a method that changes the device configuration
+type ActionResult struct {
+	ReturnValue int    `json:"returnValue"`
+	Message     string `json:"message"`
+}
+
+// Change is the result of one change to the device configuration
+type Change struct {
+	Setting string        `json:"setting"`
+	Result  *ActionResult `json:"result"`
+}
+
+// Changes are the results of the changes a command made to the device configuration
+type Changes []Change
+
+// Failed reports whether any change was rejected
+func (c Changes) Failed() bool {
+	for _, change := range c {
+		if change.Result.Failed() {
+			return true
+		}
+	}
+	return false
+}
+
+// Changed reports whether any change was made
+func (c Changes) Changed() bool {
+	return len(c) > 0
+}
+
+func (c Changes) write(b *strings.Builder) {
+	for _, change := range c {
+		fmt.Fprintf(b, "Changed %s\t: %s\n", change.Setting, change.Result)
+	}
+}
+
+// returnValueMessages are the return values shared by the CIM methods
+var returnValueMessages = map[int]string{
+	0:    "Completed with No Error",
+	1:    "Not Supported",
+	2:    "Unknown or Unspecified Error",
+	3:    "Cannot complete within Timeout Period",
+	4:    "Failed",
+	5:    "Invalid Parameter",
+	6:    "In Use",
+	4096: "Method Parameters Checked - Job Started",
+	4097: "Invalid State Transition",
+	4098: "Use of Timeout Parameter Not Supported",
+	4099: "Busy",
+}
+
+func newActionResult(returnValue int) *ActionResult {
+	message, ok := returnValueMessages[returnValue]
+	if !ok {
+		message = fmt.Sprintf("Unknown return value %d", returnValue)
+	}
+	return &ActionResult{ReturnValue: returnValue, Message: message}
+}
+
+// Failed reports whether the method returned an error
+func (r *ActionResult) Failed() bool {
+	return r != nil && r.ReturnValue != 0 && r.ReturnValue != 4096
+}
+
+func (r *ActionResult) String() string {
+	return fmt.Sprintf("%s (%d)", r.Message, r.ReturnValue)
+}
+
+// ParseSince parses a --since value, either an RFC 3339 time, a date or a duration before now such as 24h
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %s, expected a time such as 2021-06-01T08:00:00Z, a date such as 2021-06-01 or a duration such as 24h", value)
+}
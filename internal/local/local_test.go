@@ -0,0 +1,104 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package local
+
+import (
+	"encoding/xml"
+	"errors"
+	"rpc/pkg/wsman"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// invocation records a method call or put made against mockWSMan
+type invocation struct {
+	ResourceURI string
+	Method      string
+	Selectors   []wsman.Selector
+	Body        string
+}
+
+// mockWSMan answers with canned XML keyed by resource URI, or by resource URI and method for Invoke
+type mockWSMan struct {
+	instances   map[string]string
+	enumeration map[string][]string
+	outputs     map[string]string
+	invocations []invocation
+}
+
+func newMockWSMan() *mockWSMan {
+	return &mockWSMan{instances: map[string]string{}, enumeration: map[string][]string{}, outputs: map[string]string{}}
+}
+
+func (m *mockWSMan) Get(resourceURI string, selectors []wsman.Selector, out interface{}) error {
+	data, ok := m.instances[resourceURI]
+	if !ok {
+		return errors.New("no instance of " + resourceURI)
+	}
+	return xml.Unmarshal([]byte(data), out)
+}
+
+func (m *mockWSMan) Put(resourceURI string, selectors []wsman.Selector, in interface{}, out interface{}) error {
+	return m.record(resourceURI, "Put", selectors, in, out)
+}
+
+func (m *mockWSMan) Invoke(resourceURI string, method string, selectors []wsman.Selector, in interface{}, out interface{}) error {
+	return m.record(resourceURI, method, selectors, in, out)
+}
+
+func (m *mockWSMan) Delete(resourceURI string, selectors []wsman.Selector) error {
+	m.invocations = append(m.invocations, invocation{ResourceURI: resourceURI, Method: "Delete", Selectors: selectors})
+	return nil
+}
+
+func (m *mockWSMan) record(resourceURI string, method string, selectors []wsman.Selector, in interface{}, out interface{}) error {
+	body, err := xml.Marshal(in)
+	if err != nil {
+		return err
+	}
+	m.invocations = append(m.invocations, invocation{ResourceURI: resourceURI, Method: method, Selectors: selectors, Body: string(body)})
+	if out == nil {
+		return nil
+	}
+	data, ok := m.outputs[resourceURI+"/"+method]
+	if !ok {
+		return errors.New("unexpected call to " + method)
+	}
+	return xml.Unmarshal([]byte(data), out)
+}
+
+func (m *mockWSMan) EnumerateAll(resourceURI string) ([]wsman.Item, error) {
+	data, ok := m.enumeration[resourceURI]
+	if !ok {
+		return nil, errors.New("no instances of " + resourceURI)
+	}
+	items := []wsman.Item{}
+	for _, item := range data {
+		items = append(items, wsman.Item(item))
+	}
+	return items, nil
+}
+
+func TestActionResult(t *testing.T) {
+	assert.False(t, newActionResult(0).Failed())
+	assert.False(t, newActionResult(4096).Failed())
+	assert.True(t, newActionResult(2).Failed())
+	assert.Equal(t, "Invalid Parameter (5)", newActionResult(5).String())
+	assert.Equal(t, "Unknown return value 42", newActionResult(42).Message)
+	var missing *ActionResult
+	assert.False(t, missing.Failed())
+}
+
+func TestChanges(t *testing.T) {
+	changes := Changes{{Setting: "sol", Result: newActionResult(0)}}
+	assert.False(t, changes.Failed())
+	changes = append(changes, Change{Setting: "kvm", Result: newActionResult(2)})
+	assert.True(t, changes.Failed())
+	var b strings.Builder
+	changes.write(&b)
+	assert.Equal(t, "Changed sol\t: Completed with No Error (0)\nChanged kvm\t: Unknown or Unspecified Error (2)\n", b.String())
+}
@@ -0,0 +1,156 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package local
+
+import (
+	"encoding/base64"
+	"rpc/pkg/wsman"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func eventRecord(timestamp time.Time, sensorType byte, severity byte, entity byte) string {
+	data := []byte{0, 0, 0, 0, 0x6e, sensorType, 0x6f, 2, 0x68, severity, 0xff, entity, 0, 0x40, 0x13, 0, 0, 0, 0, 0, 0}
+	unix := uint32(timestamp.Unix())
+	data[0], data[1], data[2], data[3] = byte(unix), byte(unix>>8), byte(unix>>16), byte(unix>>24)
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func auditRecord(app uint16, event uint16, user string, timestamp time.Time, address string) string {
+	data := []byte{byte(app >> 8), byte(app), byte(event >> 8), byte(event), initiatorDigest, byte(len(user))}
+	data = append(data, user...)
+	unix := uint32(timestamp.Unix())
+	data = append(data, byte(unix>>24), byte(unix>>16), byte(unix>>8), byte(unix), 1, byte(len(address)))
+	data = append(data, address...)
+	data = append(data, 2, 0xab, 0xcd)
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func TestDecodeEventRecord(t *testing.T) {
+	timestamp := time.Date(2021, 6, 1, 8, 0, 0, 0, time.UTC)
+	record, err := decodeEventRecord(eventRecord(timestamp, 15, 2, 34))
+	assert.NoError(t, err)
+	assert.Equal(t, timestamp, record.Time)
+	assert.Equal(t, "System Firmware Progress", record.SensorType)
+	assert.Equal(t, "Information", record.Severity)
+	assert.Equal(t, "BIOS", record.EntityName)
+	assert.Equal(t, "4013000000000000", record.EventData)
+}
+
+func TestDecodeEventRecordInvalid(t *testing.T) {
+	_, err := decodeEventRecord(base64.StdEncoding.EncodeToString([]byte{1, 2, 3}))
+	assert.EqualError(t, err, "event record is 3 bytes, expected 21")
+	_, err = decodeEventRecord("not base64!")
+	assert.Error(t, err)
+	record, err := decodeEventRecord(eventRecord(time.Unix(0, 0), 200, 3, 200))
+	assert.NoError(t, err)
+	assert.Equal(t, "Unknown (200)", record.SensorType)
+	assert.Equal(t, "Unknown (3)", record.Severity)
+	assert.Equal(t, "Unknown (200)", record.EntityName)
+}
+
+func TestReadEventLog(t *testing.T) {
+	m := newMockWSMan()
+	m.outputs[wsman.AMTMessageLogURI+"/PositionToFirstRecord"] = `<g:PositionToFirstRecord_OUTPUT xmlns:g="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_MessageLog"><g:IterationIdentifier>1</g:IterationIdentifier><g:ReturnValue>0</g:ReturnValue></g:PositionToFirstRecord_OUTPUT>`
+	old := time.Date(2021, 5, 1, 8, 0, 0, 0, time.UTC)
+	recent := time.Date(2021, 6, 1, 8, 0, 0, 0, time.UTC)
+	m.outputs[wsman.AMTMessageLogURI+"/GetRecords"] = `<g:GetRecords_OUTPUT xmlns:g="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_MessageLog"><g:IterationIdentifier>3</g:IterationIdentifier><g:NoMoreRecords>true</g:NoMoreRecords><g:RecordArray>` + eventRecord(recent, 6, 8, 35) + `</g:RecordArray><g:RecordArray>` + eventRecord(old, 15, 2, 34) + `</g:RecordArray><g:ReturnValue>0</g:ReturnValue></g:GetRecords_OUTPUT>`
+	log, err := ReadEventLog(m, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, log.Records, 2)
+	assert.Contains(t, m.invocations[1].Body, "<IterationIdentifier>1</IterationIdentifier><MaxReadRecords>390</MaxReadRecords>")
+
+	log, err = ReadEventLog(m, time.Date(2021, 5, 15, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Len(t, log.Records, 1)
+	assert.Equal(t, "Platform Security Violation Attempt", log.Records[0].SensorType)
+	assert.Equal(t, "2021-06-01T08:00:00Z  Non-critical    Intel(r) ME                  Platform Security Violation Attempt\n", log.String())
+	csv, err := log.CSV()
+	assert.NoError(t, err)
+	assert.Equal(t, "2021-06-01T08:00:00Z,Non-critical,Intel(r) ME,0,Platform Security Violation Attempt,255,111,2,104,110,4013000000000000", strings.Split(csv, "\n")[1])
+}
+
+func TestReadEventLogError(t *testing.T) {
+	m := newMockWSMan()
+	m.outputs[wsman.AMTMessageLogURI+"/PositionToFirstRecord"] = `<g:PositionToFirstRecord_OUTPUT xmlns:g="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_MessageLog"><g:ReturnValue>2</g:ReturnValue></g:PositionToFirstRecord_OUTPUT>`
+	_, err := ReadEventLog(m, time.Time{})
+	assert.EqualError(t, err, "unable to position to the first event record: Unknown or Unspecified Error (2)")
+}
+
+func TestDecodeAuditRecord(t *testing.T) {
+	timestamp := time.Date(2021, 6, 1, 8, 0, 0, 0, time.UTC)
+	record, err := decodeAuditRecord(auditRecord(18, 8, "admin", timestamp, "192.168.1.10"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Redirection Manager", record.AuditApp)
+	assert.Equal(t, "KVM Session Started", record.Event)
+	assert.Equal(t, "HTTP Digest", record.InitiatorName)
+	assert.Equal(t, "admin", record.Initiator)
+	assert.Equal(t, timestamp, record.Time)
+	assert.Equal(t, "192.168.1.10", record.NetAddress)
+	assert.Equal(t, "abcd", record.ExtendedData)
+}
+
+func TestDecodeAuditRecordLocal(t *testing.T) {
+	data := []byte{0, 16, 0, 1, initiatorLocal, 0x60, 0xb5, 0xe8, 0x80, 2, 0, 0}
+	record, err := decodeAuditRecord(base64.StdEncoding.EncodeToString(data))
+	assert.NoError(t, err)
+	assert.Equal(t, "Provisioning Completed", record.Event)
+	assert.Equal(t, "Local", record.Initiator)
+}
+
+func TestDecodeAuditRecordKerberos(t *testing.T) {
+	sid := []byte{1, 2, 0, 0, 0, 0, 0, 5, 21, 0, 0, 0, 0xe9, 3, 0, 0}
+	data := append([]byte{0, 18, 0, 8, initiatorKerberos, 0, 0, 0, 0, byte(len(sid))}, sid...)
+	data = append(data, 0x60, 0xb5, 0xe8, 0x80, 1, 0, 0)
+	record, err := decodeAuditRecord(base64.StdEncoding.EncodeToString(data))
+	assert.NoError(t, err)
+	assert.Equal(t, "S-1-5-21-1001", record.Initiator)
+}
+
+func TestDecodeAuditRecordInvalid(t *testing.T) {
+	_, err := decodeAuditRecord(base64.StdEncoding.EncodeToString([]byte{0, 18, 0, 8, initiatorDigest, 20, 'a'}))
+	assert.EqualError(t, err, "audit record truncated, 1 bytes left but 20 expected")
+	_, err = decodeAuditRecord(base64.StdEncoding.EncodeToString([]byte{0, 18, 0, 8, 9}))
+	assert.EqualError(t, err, "unknown audit record initiator type 9")
+	record, err := decodeAuditRecord(auditRecord(99, 7, "admin", time.Unix(0, 0), ""))
+	assert.NoError(t, err)
+	assert.Equal(t, "Unknown (99)", record.AuditApp)
+	assert.Equal(t, "Event 7", record.Event)
+}
+
+func TestReadAuditLog(t *testing.T) {
+	m := newMockWSMan()
+	timestamp := time.Date(2021, 6, 1, 8, 0, 0, 0, time.UTC)
+	m.outputs[wsman.AMTAuditLogURI+"/ReadRecords"] = `<g:ReadRecords_OUTPUT xmlns:g="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_AuditLog"><g:TotalRecordCount>2</g:TotalRecordCount><g:RecordsReturned>2</g:RecordsReturned><g:EventRecords>` + auditRecord(18, 8, "admin", timestamp, "192.168.1.10") + `</g:EventRecords><g:EventRecords>` + auditRecord(18, 9, "admin", timestamp.Add(time.Hour), "192.168.1.10") + `</g:EventRecords><g:ReturnValue>0</g:ReturnValue></g:ReadRecords_OUTPUT>`
+	log, err := ReadAuditLog(m, timestamp.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, m.invocations, 1)
+	assert.Contains(t, m.invocations[0].Body, "<StartIndex>1</StartIndex>")
+	assert.Equal(t, 2, log.TotalRecordCount)
+	assert.Len(t, log.Records, 1)
+	assert.Equal(t, "KVM Session Ended", log.Records[0].Event)
+	assert.Contains(t, log.String(), "KVM Session Ended")
+	csv, err := log.CSV()
+	assert.NoError(t, err)
+	assert.Equal(t, "2021-06-01T09:00:00Z,18,Redirection Manager,9,KVM Session Ended,HTTP Digest,admin,1,192.168.1.10,abcd", strings.Split(csv, "\n")[1])
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2021, 6, 2, 8, 0, 0, 0, time.UTC)
+	since, err := ParseSince("2021-06-01T08:00:00Z", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 6, 1, 8, 0, 0, 0, time.UTC), since)
+	since, err = ParseSince("2021-06-01", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), since)
+	since, err = ParseSince("24h", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 6, 1, 8, 0, 0, 0, time.UTC), since)
+	_, err = ParseSince("yesterday", now)
+	assert.Error(t, err)
+}
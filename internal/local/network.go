@@ -0,0 +1,545 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package local
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"regexp"
+	"rpc/internal/amt"
+	"rpc/pkg/wsman"
+	"strings"
+)
+
+const (
+	wiredPortID       = "Intel(r) AMT Ethernet Port Settings 0"
+	wirelessPortID    = "Intel(r) AMT Ethernet Port Settings 1"
+	wifiProfilePrefix = "Intel(r) AMT:WiFi Endpoint Settings "
+	ieee8021xPrefix   = "Intel(r) AMT:IEEE 802.1X Settings "
+	wifiEndpointName  = "WiFi Endpoint 0"
+	// encryptionCCMP is the AES encryption WPA2 uses
+	encryptionCCMP = 4
+)
+
+// CIM_WiFiPort states, AMT only uses the wireless port in the enabled states
+const (
+	wifiDisabled       = 3
+	wifiEnabledS0      = 32769
+	wifiEnabledS0SxAC  = 32770
+	wifiStateUnchanged = 0
+)
+
+// localProfileSynchronizationEnabled values
+const (
+	syncDisabled = 0
+	syncEnabled  = 1
+)
+
+var wifiStates = map[int]string{
+	wifiDisabled:      "disabled",
+	32768:             "disabled",
+	wifiEnabledS0:     "enabled in S0",
+	wifiEnabledS0SxAC: "enabled in S0 and Sx/AC",
+}
+
+// WiFiAuthentication maps the authentication names to CIM_WiFiEndpointSettings AuthenticationMethod values
+var WiFiAuthentication = map[string]int{
+	"wpa2-psk":       6,
+	"wpa2-ieee8021x": 7,
+}
+
+// EAPMethods maps the 802.1x methods that authenticate with a username and password to CIM_IEEE8021xSettings AuthenticationProtocol values
+var EAPMethods = map[string]int{
+	"eap-ttls-mschapv2": 1,
+	"peap-mschapv2":     2,
+}
+
+// profileName is what AMT accepts as the name of a wireless profile
+var profileName = regexp.MustCompile(`^[a-zA-Z0-9]{1,32}$`)
+
+// WiredConfig is the addressing of the wired port. With DHCP or Shared the address fields are left empty,
+// Shared makes AMT use the static IP of the OS instead of a dedicated one.
+type WiredConfig struct {
+	DHCP           bool
+	Shared         bool
+	IPAddress      string
+	SubnetMask     string
+	DefaultGateway string
+	PrimaryDNS     string
+	SecondaryDNS   string
+}
+
+// WirelessChanges holds the wireless options to change, nil fields are left unchanged
+type WirelessChanges struct {
+	Enabled *bool
+	SyncOS  *bool
+}
+
+// WiFiProfileConfig is a wireless profile to add, CACertificate is the PEM certificate of the 802.1x server's CA
+type WiFiProfileConfig struct {
+	Name           string
+	SSID           string
+	Priority       int
+	Authentication string
+	PassPhrase     string
+	EAPMethod      string
+	Username       string
+	Password       string
+	CACertificate  []byte
+}
+
+// PortSettings is the addressing of an AMT network port
+type PortSettings struct {
+	MACAddress     string `json:"macAddress"`
+	LinkUp         bool   `json:"linkUp"`
+	DHCP           bool   `json:"dhcp"`
+	IPSync         bool   `json:"ipSync"`
+	SharedStaticIP bool   `json:"sharedStaticIp"`
+	IPAddress      string `json:"ipAddress,omitempty"`
+	SubnetMask     string `json:"subnetMask,omitempty"`
+	DefaultGateway string `json:"defaultGateway,omitempty"`
+	PrimaryDNS     string `json:"primaryDns,omitempty"`
+	SecondaryDNS   string `json:"secondaryDns,omitempty"`
+	settings       wsman.AMTEthernetPortSettings
+}
+
+// WiFiProfile is a wireless profile stored in AMT
+type WiFiProfile struct {
+	Name           string `json:"name"`
+	SSID           string `json:"ssid"`
+	Authentication string `json:"authentication"`
+	Priority       int    `json:"priority"`
+	instanceID     string
+}
+
+// NetworkSettings is the configuration of the wired and wireless AMT ports
+type NetworkSettings struct {
+	Wired    *PortSettings `json:"wired,omitempty"`
+	Wireless *PortSettings `json:"wireless,omitempty"`
+	WiFi     string        `json:"wifi,omitempty"`
+	SyncOS   bool          `json:"syncOS"`
+	Profiles []WiFiProfile `json:"profiles"`
+	Changes  `json:"changes,omitempty"`
+	wifiPort wsman.CIMWiFiPort
+	service  wsman.AMTWiFiPortConfigurationService
+}
+
+// GetNetworkSettings reads the port settings and, when the device has a wireless port, the wireless profiles and options
+func GetNetworkSettings(w WSMan) (NetworkSettings, error) {
+	settings := NetworkSettings{Profiles: []WiFiProfile{}}
+	items, err := w.EnumerateAll(wsman.AMTEthernetPortSettingsURI)
+	if err != nil {
+		return settings, err
+	}
+	for _, item := range items {
+		port := wsman.AMTEthernetPortSettings{}
+		if err := item.Decode(&port); err != nil {
+			return settings, err
+		}
+		switch port.InstanceID {
+		case wiredPortID:
+			settings.Wired = newPortSettings(port)
+		case wirelessPortID:
+			settings.Wireless = newPortSettings(port)
+		}
+	}
+	if settings.Wireless == nil {
+		return settings, nil
+	}
+	err = w.Get(wsman.CIMWiFiPortURI, nil, &settings.wifiPort)
+	if err != nil {
+		return settings, err
+	}
+	settings.WiFi = wifiStates[settings.wifiPort.EnabledState]
+	err = w.Get(wsman.AMTWiFiPortConfigurationServiceURI, nil, &settings.service)
+	if err != nil {
+		return settings, err
+	}
+	settings.SyncOS = settings.service.LocalProfileSynchronizationEnabled != syncDisabled
+	items, err = w.EnumerateAll(wsman.CIMWiFiEndpointSettingsURI)
+	if err != nil {
+		return settings, err
+	}
+	for _, item := range items {
+		profile := wsman.CIMWiFiEndpointSettings{}
+		if err := item.Decode(&profile); err != nil {
+			return settings, err
+		}
+		settings.Profiles = append(settings.Profiles, WiFiProfile{
+			Name:           profile.ElementName,
+			SSID:           profile.SSID,
+			Authentication: authenticationName(profile.AuthenticationMethod),
+			Priority:       profile.Priority,
+			instanceID:     profile.InstanceID,
+		})
+	}
+	return settings, nil
+}
+
+// SetWired changes the addressing of the wired port, nothing is sent when it already matches config
+func SetWired(w WSMan, config WiredConfig) (NetworkSettings, error) {
+	if err := validateWired(config); err != nil {
+		return NetworkSettings{}, err
+	}
+	current, err := GetNetworkSettings(w)
+	if err != nil {
+		return current, err
+	}
+	if current.Wired == nil {
+		return current, fmt.Errorf("the device has no wired AMT port")
+	}
+	port := current.Wired.settings
+	port.DHCPEnabled = config.DHCP
+	// DHCP and shared static addressing both follow the OS, a dedicated static IP does not
+	port.IpSyncEnabled = config.DHCP || config.Shared
+	port.SharedStaticIp = !config.DHCP && config.Shared
+	port.IPAddress = config.IPAddress
+	port.SubnetMask = config.SubnetMask
+	port.DefaultGateway = config.DefaultGateway
+	port.PrimaryDNS = config.PrimaryDNS
+	port.SecondaryDNS = config.SecondaryDNS
+	if samePortAddressing(port, current.Wired.settings) {
+		return current, nil
+	}
+	err = w.Put(wsman.AMTEthernetPortSettingsURI, []wsman.Selector{{Name: "InstanceID", Value: wiredPortID}}, port, nil)
+	if err != nil {
+		return current, err
+	}
+	updated, err := GetNetworkSettings(w)
+	updated.Changes = Changes{{Setting: "wired", Result: newActionResult(0)}}
+	return updated, err
+}
+
+// amtVersion reads the AMT firmware version from CIM_SoftwareIdentity
+func amtVersion(w WSMan) (amt.Version, error) {
+	items, err := w.EnumerateAll(wsman.CIMSoftwareIdentityURI)
+	if err != nil {
+		return amt.Version{}, err
+	}
+	for _, item := range items {
+		identity := wsman.CIMSoftwareIdentity{}
+		if err := item.Decode(&identity); err != nil {
+			return amt.Version{}, err
+		}
+		if identity.InstanceID == "AMT" {
+			return amt.ParseVersion(identity.VersionString)
+		}
+	}
+	return amt.Version{}, fmt.Errorf("the device does not report its AMT version")
+}
+
+// SetWireless enables or disables the wireless port and the synchronization of the wireless profiles of the OS
+func SetWireless(w WSMan, changes WirelessChanges) (NetworkSettings, error) {
+	current, err := GetNetworkSettings(w)
+	if err != nil {
+		return current, err
+	}
+	if current.Wireless == nil {
+		return current, fmt.Errorf("the device has no wireless AMT port")
+	}
+	// check the firmware before changing anything so a rejected synchronization leaves the port untouched
+	changeSync := changes.SyncOS != nil && *changes.SyncOS != current.SyncOS
+	if changeSync {
+		version, err := amtVersion(w)
+		if err != nil {
+			return current, err
+		}
+		if err := amt.RequireCapability(version, amt.WirelessSync); err != nil {
+			return current, err
+		}
+	}
+	results := Changes{}
+	if changes.Enabled != nil {
+		state := wifiStateUnchanged
+		enabled := current.wifiPort.EnabledState == wifiEnabledS0 || current.wifiPort.EnabledState == wifiEnabledS0SxAC
+		if *changes.Enabled && !enabled {
+			state = wifiEnabledS0
+		}
+		if !*changes.Enabled && enabled {
+			state = wifiDisabled
+		}
+		if state != wifiStateUnchanged {
+			result, err := requestStateChange(w, wsman.CIMWiFiPortURI, state)
+			if err != nil {
+				current.Changes = results
+				return current, err
+			}
+			results = append(results, Change{Setting: "wifi", Result: result})
+		}
+	}
+	if changeSync {
+		service := current.service
+		service.LocalProfileSynchronizationEnabled = syncDisabled
+		if *changes.SyncOS {
+			service.LocalProfileSynchronizationEnabled = syncEnabled
+		}
+		err = w.Put(wsman.AMTWiFiPortConfigurationServiceURI, nil, service, nil)
+		if err != nil {
+			current.Changes = results
+			return current, err
+		}
+		results = append(results, Change{Setting: "syncOS", Result: newActionResult(0)})
+	}
+	updated, err := GetNetworkSettings(w)
+	updated.Changes = results
+	return updated, err
+}
+
+// AddWiFiProfile adds a WPA2-PSK or WPA2-Enterprise wireless profile.
+// The CA certificate of an enterprise profile is added to the AMT trusted roots unless it is already there.
+func AddWiFiProfile(w WSMan, config WiFiProfileConfig) (NetworkSettings, error) {
+	if err := validateWiFiProfile(config); err != nil {
+		return NetworkSettings{}, err
+	}
+	current, err := GetNetworkSettings(w)
+	if err != nil {
+		return current, err
+	}
+	if current.Wireless == nil {
+		return current, fmt.Errorf("the device has no wireless AMT port")
+	}
+	for _, profile := range current.Profiles {
+		if profile.Name == config.Name {
+			return current, fmt.Errorf("wireless profile %s already exists", config.Name)
+		}
+		if profile.Priority == config.Priority {
+			return current, fmt.Errorf("wireless profile %s already has priority %d", profile.Name, config.Priority)
+		}
+	}
+	results := Changes{}
+	input := wsman.AddWiFiSettingsInput{
+		WiFiEndpoint: wsman.NewReference(wsman.CIMWiFiEndpointURI, wsman.Selector{Name: "Name", Value: wifiEndpointName}),
+		WiFiEndpointSettingsInput: wsman.WiFiEndpointSettingsInput{
+			ElementName:          config.Name,
+			InstanceID:           wifiProfilePrefix + config.Name,
+			AuthenticationMethod: WiFiAuthentication[config.Authentication],
+			EncryptionMethod:     encryptionCCMP,
+			SSID:                 config.SSID,
+			Priority:             config.Priority,
+			PSKPassPhrase:        config.PassPhrase,
+		},
+	}
+	if config.Authentication == "wpa2-ieee8021x" {
+		input.IEEE8021xSettingsInput = &wsman.IEEE8021xSettingsInput{
+			ElementName:            config.Name,
+			InstanceID:             ieee8021xPrefix + config.Name,
+			AuthenticationProtocol: EAPMethods[config.EAPMethod],
+			Username:               config.Username,
+			Password:               config.Password,
+		}
+	}
+	if len(config.CACertificate) > 0 {
+		reference, change, err := addTrustedRoot(w, config.CACertificate)
+		if err != nil {
+			return current, err
+		}
+		if change != nil {
+			results = append(results, *change)
+			if change.Result.Failed() {
+				updated, err := GetNetworkSettings(w)
+				updated.Changes = results
+				return updated, err
+			}
+		}
+		input.CACredential = &reference
+	}
+	output := wsman.MethodOutput{}
+	err = w.Invoke(wsman.AMTWiFiPortConfigurationServiceURI, "AddWiFiSettings", nil, input, &output)
+	if err != nil {
+		current.Changes = results
+		return current, err
+	}
+	results = append(results, Change{Setting: "add profile " + config.Name, Result: newActionResult(output.ReturnValue)})
+	updated, err := GetNetworkSettings(w)
+	updated.Changes = results
+	return updated, err
+}
+
+// RemoveWiFiProfile removes a wireless profile by name
+func RemoveWiFiProfile(w WSMan, name string) (NetworkSettings, error) {
+	current, err := GetNetworkSettings(w)
+	if err != nil {
+		return current, err
+	}
+	for _, profile := range current.Profiles {
+		if profile.Name != name {
+			continue
+		}
+		err = w.Delete(wsman.CIMWiFiEndpointSettingsURI, []wsman.Selector{{Name: "InstanceID", Value: profile.instanceID}})
+		if err != nil {
+			return current, err
+		}
+		updated, err := GetNetworkSettings(w)
+		updated.Changes = Changes{{Setting: "remove profile " + name, Result: newActionResult(0)}}
+		return updated, err
+	}
+	return current, fmt.Errorf("wireless profile %s does not exist", name)
+}
+
+// addTrustedRoot returns a reference to the CA certificate in AMT, adding it first when AMT does not have it yet
+func addTrustedRoot(w WSMan, certificate []byte) (wsman.EndpointReference, *Change, error) {
+	block, _ := pem.Decode(certificate)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return wsman.EndpointReference{}, nil, fmt.Errorf("the CA certificate is not a PEM certificate")
+	}
+	if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+		return wsman.EndpointReference{}, nil, fmt.Errorf("invalid CA certificate: %v", err)
+	}
+	blob := base64.StdEncoding.EncodeToString(block.Bytes)
+	items, err := w.EnumerateAll(wsman.AMTPublicKeyCertificateURI)
+	if err != nil {
+		return wsman.EndpointReference{}, nil, err
+	}
+	for _, item := range items {
+		stored := wsman.AMTPublicKeyCertificate{}
+		if err := item.Decode(&stored); err != nil {
+			return wsman.EndpointReference{}, nil, err
+		}
+		if stored.X509Certificate == blob {
+			return wsman.NewReference(wsman.AMTPublicKeyCertificateURI, wsman.Selector{Name: "InstanceID", Value: stored.InstanceID}), nil, nil
+		}
+	}
+	output := wsman.AddTrustedRootCertificateOutput{}
+	err = w.Invoke(wsman.AMTPublicKeyManagementServiceURI, "AddTrustedRootCertificate", nil, wsman.AddTrustedRootCertificateInput{CertificateBlob: blob}, &output)
+	if err != nil {
+		return wsman.EndpointReference{}, nil, err
+	}
+	change := &Change{Setting: "add CA certificate", Result: newActionResult(output.ReturnValue)}
+	return output.CreatedCertificate, change, nil
+}
+
+func validateWired(config WiredConfig) error {
+	addresses := map[string]string{
+		"IP address":    config.IPAddress,
+		"subnet mask":   config.SubnetMask,
+		"gateway":       config.DefaultGateway,
+		"primary DNS":   config.PrimaryDNS,
+		"secondary DNS": config.SecondaryDNS,
+	}
+	if config.DHCP || config.Shared {
+		for name, address := range addresses {
+			if address != "" {
+				return fmt.Errorf("the %s can only be set for a dedicated static IP", name)
+			}
+		}
+		return nil
+	}
+	if config.IPAddress == "" || config.SubnetMask == "" {
+		return fmt.Errorf("a dedicated static IP requires an IP address and a subnet mask")
+	}
+	for name, address := range addresses {
+		if address != "" && net.ParseIP(address).To4() == nil {
+			return fmt.Errorf("invalid %s %s", name, address)
+		}
+	}
+	return nil
+}
+
+func validateWiFiProfile(config WiFiProfileConfig) error {
+	if !profileName.MatchString(config.Name) {
+		return fmt.Errorf("the profile name must be 1 to 32 letters or digits")
+	}
+	if len(config.SSID) == 0 || len(config.SSID) > 32 {
+		return fmt.Errorf("the SSID must be 1 to 32 characters")
+	}
+	if config.Priority < 1 {
+		return fmt.Errorf("the profile priority must be at least 1")
+	}
+	switch config.Authentication {
+	case "wpa2-psk":
+		if len(config.PassPhrase) < 8 || len(config.PassPhrase) > 63 {
+			return fmt.Errorf("the passphrase must be 8 to 63 characters")
+		}
+	case "wpa2-ieee8021x":
+		if _, ok := EAPMethods[config.EAPMethod]; !ok {
+			return fmt.Errorf("the 802.1x method must be peap-mschapv2 or eap-ttls-mschapv2")
+		}
+		if config.Username == "" || config.Password == "" {
+			return fmt.Errorf("a username and password are required for 802.1x")
+		}
+		if config.PassPhrase != "" {
+			return fmt.Errorf("a passphrase is only used with wpa2-psk")
+		}
+	default:
+		return fmt.Errorf("the authentication must be wpa2-psk or wpa2-ieee8021x")
+	}
+	return nil
+}
+
+func newPortSettings(port wsman.AMTEthernetPortSettings) *PortSettings {
+	return &PortSettings{
+		MACAddress:     port.MACAddress,
+		LinkUp:         port.LinkIsUp,
+		DHCP:           port.DHCPEnabled,
+		IPSync:         port.IpSyncEnabled,
+		SharedStaticIP: port.SharedStaticIp,
+		IPAddress:      port.IPAddress,
+		SubnetMask:     port.SubnetMask,
+		DefaultGateway: port.DefaultGateway,
+		PrimaryDNS:     port.PrimaryDNS,
+		SecondaryDNS:   port.SecondaryDNS,
+		settings:       port,
+	}
+}
+
+// samePortAddressing compares the settings SetWired changes. AMT reports the address it got from DHCP,
+// so the address fields only matter for a dedicated static IP.
+func samePortAddressing(a wsman.AMTEthernetPortSettings, b wsman.AMTEthernetPortSettings) bool {
+	if a.DHCPEnabled != b.DHCPEnabled || a.IpSyncEnabled != b.IpSyncEnabled || a.SharedStaticIp != b.SharedStaticIp {
+		return false
+	}
+	if a.DHCPEnabled || a.IpSyncEnabled {
+		return true
+	}
+	return a.IPAddress == b.IPAddress && a.SubnetMask == b.SubnetMask && a.DefaultGateway == b.DefaultGateway &&
+		a.PrimaryDNS == b.PrimaryDNS && a.SecondaryDNS == b.SecondaryDNS
+}
+
+func authenticationName(method int) string {
+	for name, value := range WiFiAuthentication {
+		if value == method {
+			return name
+		}
+	}
+	return fmt.Sprintf("other (%d)", method)
+}
+
+func (s NetworkSettings) String() string {
+	var b strings.Builder
+	s.Changes.write(&b)
+	if s.Wired != nil {
+		b.WriteString("Wired\n")
+		s.Wired.write(&b)
+	}
+	if s.Wireless == nil {
+		return b.String()
+	}
+	b.WriteString("Wireless\n")
+	s.Wireless.write(&b)
+	fmt.Fprintf(&b, "  WiFi\t\t\t: %s\n", s.WiFi)
+	fmt.Fprintf(&b, "  Sync OS Profiles\t: %t\n", s.SyncOS)
+	for _, profile := range s.Profiles {
+		fmt.Fprintf(&b, "  Profile %s\t\t: %s, %s, priority %d\n", profile.Name, profile.SSID, profile.Authentication, profile.Priority)
+	}
+	return b.String()
+}
+
+func (p *PortSettings) write(b *strings.Builder) {
+	fmt.Fprintf(b, "  MAC Address\t\t: %s\n", p.MACAddress)
+	fmt.Fprintf(b, "  Link Up\t\t: %t\n", p.LinkUp)
+	fmt.Fprintf(b, "  DHCP\t\t\t: %t\n", p.DHCP)
+	fmt.Fprintf(b, "  IP Sync\t\t: %t\n", p.IPSync)
+	fmt.Fprintf(b, "  Shared Static IP\t: %t\n", p.SharedStaticIP)
+	if p.IPAddress != "" {
+		fmt.Fprintf(b, "  IP Address\t\t: %s\n", p.IPAddress)
+		fmt.Fprintf(b, "  Subnet Mask\t\t: %s\n", p.SubnetMask)
+		fmt.Fprintf(b, "  Gateway\t\t: %s\n", p.DefaultGateway)
+		fmt.Fprintf(b, "  Primary DNS\t\t: %s\n", p.PrimaryDNS)
+		fmt.Fprintf(b, "  Secondary DNS\t\t: %s\n", p.SecondaryDNS)
+	}
+}
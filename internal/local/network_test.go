@@ -0,0 +1,237 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package local
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"rpc/pkg/wsman"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const dhcpPort = `<h:AMT_EthernetPortSettings xmlns:h="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_EthernetPortSettings"><h:DHCPEnabled>true</h:DHCPEnabled><h:DefaultGateway>192.168.1.1</h:DefaultGateway><h:ElementName>Intel(r) AMT Ethernet Port Settings</h:ElementName><h:IPAddress>192.168.1.20</h:IPAddress><h:InstanceID>Intel(r) AMT Ethernet Port Settings 0</h:InstanceID><h:IpSyncEnabled>true</h:IpSyncEnabled><h:LinkIsUp>true</h:LinkIsUp><h:LinkPolicy>1</h:LinkPolicy><h:LinkPolicy>14</h:LinkPolicy><h:MACAddress>a4-bb-6d-89-52-e4</h:MACAddress><h:PhysicalConnectionType>0</h:PhysicalConnectionType><h:PrimaryDNS>192.168.1.1</h:PrimaryDNS><h:SharedDynamicIP>true</h:SharedDynamicIP><h:SharedMAC>true</h:SharedMAC><h:SharedStaticIp>false</h:SharedStaticIp><h:SubnetMask>255.255.255.0</h:SubnetMask></h:AMT_EthernetPortSettings>`
+
+const wirelessPort = `<h:AMT_EthernetPortSettings xmlns:h="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_EthernetPortSettings"><h:DHCPEnabled>true</h:DHCPEnabled><h:ElementName>Intel(r) AMT Ethernet Port Settings</h:ElementName><h:InstanceID>Intel(r) AMT Ethernet Port Settings 1</h:InstanceID><h:LinkIsUp>false</h:LinkIsUp><h:MACAddress>00-00-00-00-00-00</h:MACAddress><h:PhysicalConnectionType>3</h:PhysicalConnectionType><h:SharedMAC>true</h:SharedMAC></h:AMT_EthernetPortSettings>`
+
+func wifiProfile(name string, ssid string, priority string) string {
+	return `<h:CIM_WiFiEndpointSettings xmlns:h="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_WiFiEndpointSettings"><h:AuthenticationMethod>6</h:AuthenticationMethod><h:BSSType>3</h:BSSType><h:ElementName>` + name + `</h:ElementName><h:EncryptionMethod>4</h:EncryptionMethod><h:InstanceID>Intel(r) AMT:WiFi Endpoint Settings ` + name + `</h:InstanceID><h:Priority>` + priority + `</h:Priority><h:SSID>` + ssid + `</h:SSID></h:CIM_WiFiEndpointSettings>`
+}
+
+func newNetworkMock(wireless bool) *mockWSMan {
+	m := newMockWSMan()
+	m.enumeration[wsman.AMTEthernetPortSettingsURI] = []string{dhcpPort}
+	if !wireless {
+		return m
+	}
+	m.enumeration[wsman.AMTEthernetPortSettingsURI] = append(m.enumeration[wsman.AMTEthernetPortSettingsURI], wirelessPort)
+	m.instances[wsman.CIMWiFiPortURI] = `<h:CIM_WiFiPort xmlns:h="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_WiFiPort"><h:DeviceID>WiFi Port 0</h:DeviceID><h:ElementName>WiFi Port 0</h:ElementName><h:EnabledState>3</h:EnabledState><h:PermanentAddress>a4bb6d8952e5</h:PermanentAddress><h:RequestedState>3</h:RequestedState></h:CIM_WiFiPort>`
+	m.instances[wsman.AMTWiFiPortConfigurationServiceURI] = `<h:AMT_WiFiPortConfigurationService xmlns:h="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_WiFiPortConfigurationService"><h:CreationClassName>AMT_WiFiPortConfigurationService</h:CreationClassName><h:ElementName>Intel(r) AMT WiFi Port Configuration Service</h:ElementName><h:EnabledState>5</h:EnabledState><h:HealthState>5</h:HealthState><h:LastConnectedSsidUnderMeControl></h:LastConnectedSsidUnderMeControl><h:Name>Intel(r) AMT WiFi Port Configuration Service</h:Name><h:NoHostCsmeSoftwarePolicy>0</h:NoHostCsmeSoftwarePolicy><h:RequestedState>5</h:RequestedState><h:SystemCreationClassName>CIM_ComputerSystem</h:SystemCreationClassName><h:SystemName>Intel(r) AMT</h:SystemName><h:localProfileSynchronizationEnabled>0</h:localProfileSynchronizationEnabled></h:AMT_WiFiPortConfigurationService>`
+	m.enumeration[wsman.CIMWiFiEndpointSettingsURI] = []string{wifiProfile("home", "HomeNet", "1")}
+	m.enumeration[wsman.AMTPublicKeyCertificateURI] = []string{}
+	m.enumeration[wsman.CIMSoftwareIdentityURI] = []string{softwareIdentity("Flash", "15.0.30"), softwareIdentity("AMT", "15.0.30")}
+	m.outputs[wsman.CIMWiFiPortURI+"/RequestStateChange"] = `<h:RequestStateChange_OUTPUT xmlns:h="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_WiFiPort"><h:ReturnValue>0</h:ReturnValue></h:RequestStateChange_OUTPUT>`
+	m.outputs[wsman.AMTWiFiPortConfigurationServiceURI+"/AddWiFiSettings"] = `<h:AddWiFiSettings_OUTPUT xmlns:h="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_WiFiPortConfigurationService"><h:ReturnValue>0</h:ReturnValue></h:AddWiFiSettings_OUTPUT>`
+	m.outputs[wsman.AMTPublicKeyManagementServiceURI+"/AddTrustedRootCertificate"] = `<h:AddTrustedRootCertificate_OUTPUT xmlns:h="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_PublicKeyManagementService" xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing" xmlns:w="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd"><h:CreatedCertificate><a:Address>http://schemas.xmlsoap.org/ws/2004/08/addressing/role/anonymous</a:Address><a:ReferenceParameters><w:ResourceURI>http://intel.com/wbem/wscim/1/amt-schema/1/AMT_PublicKeyCertificate</w:ResourceURI><w:SelectorSet><w:Selector Name="InstanceID">Intel(r) AMT Certificate: Handle: 1</w:Selector></w:SelectorSet></a:ReferenceParameters></h:CreatedCertificate><h:ReturnValue>0</h:ReturnValue></h:AddTrustedRootCertificate_OUTPUT>`
+	return m
+}
+
+func softwareIdentity(instanceID string, version string) string {
+	return `<j:CIM_SoftwareIdentity xmlns:j="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_SoftwareIdentity"><j:InstanceID>` + instanceID + `</j:InstanceID><j:IsEntity>true</j:IsEntity><j:VersionString>` + version + `</j:VersionString></j:CIM_SoftwareIdentity>`
+}
+
+func testCertificate(t *testing.T) ([]byte, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), base64.StdEncoding.EncodeToString(der)
+}
+
+func TestGetNetworkSettings(t *testing.T) {
+	settings, err := GetNetworkSettings(newNetworkMock(true))
+	assert.NoError(t, err)
+	assert.True(t, settings.Wired.DHCP)
+	assert.True(t, settings.Wired.IPSync)
+	assert.Equal(t, "192.168.1.20", settings.Wired.IPAddress)
+	assert.False(t, settings.Wireless.LinkUp)
+	assert.Equal(t, "disabled", settings.WiFi)
+	assert.False(t, settings.SyncOS)
+	assert.Equal(t, []WiFiProfile{{Name: "home", SSID: "HomeNet", Authentication: "wpa2-psk", Priority: 1, instanceID: "Intel(r) AMT:WiFi Endpoint Settings home"}}, settings.Profiles)
+	assert.Contains(t, settings.String(), "  Profile home\t\t: HomeNet, wpa2-psk, priority 1\n")
+	assert.False(t, settings.Failed())
+}
+
+func TestGetNetworkSettingsWiredOnly(t *testing.T) {
+	settings, err := GetNetworkSettings(newNetworkMock(false))
+	assert.NoError(t, err)
+	assert.NotNil(t, settings.Wired)
+	assert.Nil(t, settings.Wireless)
+	assert.NotContains(t, settings.String(), "Wireless")
+}
+
+func TestSetWiredStatic(t *testing.T) {
+	m := newNetworkMock(false)
+	config := WiredConfig{IPAddress: "192.168.1.50", SubnetMask: "255.255.255.0", DefaultGateway: "192.168.1.1", PrimaryDNS: "192.168.1.2"}
+	settings, err := SetWired(m, config)
+	assert.NoError(t, err)
+	assert.Equal(t, Changes{{Setting: "wired", Result: newActionResult(0)}}, settings.Changes)
+	assert.Len(t, m.invocations, 1)
+	put := m.invocations[0]
+	assert.Equal(t, "Put", put.Method)
+	assert.Equal(t, []wsman.Selector{{Name: "InstanceID", Value: "Intel(r) AMT Ethernet Port Settings 0"}}, put.Selectors)
+	assert.Contains(t, put.Body, "<SharedStaticIp>false</SharedStaticIp><SharedDynamicIP>true</SharedDynamicIP><IpSyncEnabled>false</IpSyncEnabled><DHCPEnabled>false</DHCPEnabled><IPAddress>192.168.1.50</IPAddress>")
+	assert.NotContains(t, put.Body, "SecondaryDNS")
+}
+
+func TestSetWiredShared(t *testing.T) {
+	m := newNetworkMock(false)
+	_, err := SetWired(m, WiredConfig{Shared: true})
+	assert.NoError(t, err)
+	body := m.invocations[0].Body
+	assert.Contains(t, body, "<SharedStaticIp>true</SharedStaticIp>")
+	assert.Contains(t, body, "<IpSyncEnabled>true</IpSyncEnabled><DHCPEnabled>false</DHCPEnabled>")
+	assert.NotContains(t, body, "IPAddress")
+}
+
+func TestSetWiredUnchanged(t *testing.T) {
+	m := newNetworkMock(false)
+	settings, err := SetWired(m, WiredConfig{DHCP: true})
+	assert.NoError(t, err)
+	assert.Empty(t, settings.Changes)
+	assert.Empty(t, m.invocations)
+}
+
+func TestSetWiredInvalid(t *testing.T) {
+	m := newNetworkMock(false)
+	_, err := SetWired(m, WiredConfig{DHCP: true, IPAddress: "192.168.1.50"})
+	assert.EqualError(t, err, "the IP address can only be set for a dedicated static IP")
+	_, err = SetWired(m, WiredConfig{IPAddress: "192.168.1.50"})
+	assert.EqualError(t, err, "a dedicated static IP requires an IP address and a subnet mask")
+	_, err = SetWired(m, WiredConfig{IPAddress: "192.168.1.50", SubnetMask: "255.255.255.0", DefaultGateway: "fe80::1"})
+	assert.EqualError(t, err, "invalid gateway fe80::1")
+	assert.Empty(t, m.invocations)
+}
+
+func TestSetWireless(t *testing.T) {
+	m := newNetworkMock(true)
+	enabled, sync := true, true
+	settings, err := SetWireless(m, WirelessChanges{Enabled: &enabled, SyncOS: &sync})
+	assert.NoError(t, err)
+	assert.Len(t, settings.Changes, 2)
+	assert.Contains(t, m.invocations[0].Body, "<RequestedState>32769</RequestedState>")
+	assert.Equal(t, wsman.AMTWiFiPortConfigurationServiceURI, m.invocations[1].ResourceURI)
+	assert.Contains(t, m.invocations[1].Body, "<localProfileSynchronizationEnabled>1</localProfileSynchronizationEnabled>")
+	assert.NotContains(t, m.invocations[1].Body, "LastConnectedSsidUnderMeControl")
+
+	m = newNetworkMock(true)
+	enabled, sync = false, false
+	settings, err = SetWireless(m, WirelessChanges{Enabled: &enabled, SyncOS: &sync})
+	assert.NoError(t, err)
+	assert.Empty(t, settings.Changes)
+	assert.Empty(t, m.invocations)
+
+	_, err = SetWireless(newNetworkMock(false), WirelessChanges{Enabled: &enabled})
+	assert.EqualError(t, err, "the device has no wireless AMT port")
+}
+
+func TestSetWirelessSyncUnsupported(t *testing.T) {
+	m := newNetworkMock(true)
+	m.enumeration[wsman.CIMSoftwareIdentityURI] = []string{softwareIdentity("AMT", "10.0.55")}
+	enabled, sync := true, true
+	_, err := SetWireless(m, WirelessChanges{Enabled: &enabled, SyncOS: &sync})
+	assert.EqualError(t, err, "AMT 10.0.55 does not support wireless profile synchronization, which requires AMT 11.0 or later")
+	assert.Empty(t, m.invocations)
+
+	m.enumeration[wsman.CIMSoftwareIdentityURI] = []string{}
+	_, err = SetWireless(m, WirelessChanges{SyncOS: &sync})
+	assert.EqualError(t, err, "the device does not report its AMT version")
+}
+
+func TestAddWiFiProfilePSK(t *testing.T) {
+	m := newNetworkMock(true)
+	settings, err := AddWiFiProfile(m, WiFiProfileConfig{Name: "office", SSID: "Office", Priority: 2, Authentication: "wpa2-psk", PassPhrase: "Passw0rd!"})
+	assert.NoError(t, err)
+	assert.Equal(t, Changes{{Setting: "add profile office", Result: newActionResult(0)}}, settings.Changes)
+	assert.Len(t, m.invocations, 1)
+	body := m.invocations[0].Body
+	assert.Contains(t, body, `Name="Name">WiFi Endpoint 0</Selector>`)
+	assert.Contains(t, body, `<WiFiEndpointSettingsInput xmlns="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_WiFiEndpointSettings"><ElementName>office</ElementName><InstanceID>Intel(r) AMT:WiFi Endpoint Settings office</InstanceID><AuthenticationMethod>6</AuthenticationMethod><EncryptionMethod>4</EncryptionMethod><SSID>Office</SSID><Priority>2</Priority><PSKPassPhrase>Passw0rd!</PSKPassPhrase></WiFiEndpointSettingsInput>`)
+	assert.NotContains(t, body, "IEEE8021xSettingsInput")
+	assert.NotContains(t, body, "CACredential")
+}
+
+func TestAddWiFiProfileEnterprise(t *testing.T) {
+	m := newNetworkMock(true)
+	certificate, blob := testCertificate(t)
+	config := WiFiProfileConfig{Name: "corp", SSID: "Corp", Priority: 3, Authentication: "wpa2-ieee8021x", EAPMethod: "peap-mschapv2", Username: "device1", Password: "Secret1!", CACertificate: certificate}
+	settings, err := AddWiFiProfile(m, config)
+	assert.NoError(t, err)
+	assert.False(t, settings.Failed())
+	assert.Len(t, m.invocations, 2)
+	assert.Equal(t, "AddTrustedRootCertificate", m.invocations[0].Method)
+	assert.Contains(t, m.invocations[0].Body, "<CertificateBlob>"+blob+"</CertificateBlob>")
+	body := m.invocations[1].Body
+	assert.Contains(t, body, `<IEEE8021xSettingsInput xmlns="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_IEEE8021xSettings"><ElementName>corp</ElementName><InstanceID>Intel(r) AMT:IEEE 802.1X Settings corp</InstanceID><AuthenticationProtocol>2</AuthenticationProtocol><Username>device1</Username><Password>Secret1!</Password></IEEE8021xSettingsInput>`)
+	assert.Contains(t, body, `Name="InstanceID">Intel(r) AMT Certificate: Handle: 1</Selector>`)
+	assert.Contains(t, body, "<AuthenticationMethod>7</AuthenticationMethod>")
+
+	// a CA certificate AMT already trusts is referenced instead of added again
+	m = newNetworkMock(true)
+	m.enumeration[wsman.AMTPublicKeyCertificateURI] = []string{`<h:AMT_PublicKeyCertificate xmlns:h="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_PublicKeyCertificate"><h:ElementName>Intel(r) AMT Certificate</h:ElementName><h:InstanceID>Intel(r) AMT Certificate: Handle: 4</h:InstanceID><h:TrustedRootCertficate>true</h:TrustedRootCertficate><h:X509Certificate>` + blob + `</h:X509Certificate></h:AMT_PublicKeyCertificate>`}
+	_, err = AddWiFiProfile(m, config)
+	assert.NoError(t, err)
+	assert.Len(t, m.invocations, 1)
+	assert.Contains(t, m.invocations[0].Body, `Name="InstanceID">Intel(r) AMT Certificate: Handle: 4</Selector>`)
+}
+
+func TestAddWiFiProfileInvalid(t *testing.T) {
+	m := newNetworkMock(true)
+	invalid := map[string]WiFiProfileConfig{
+		"the profile name must be 1 to 32 letters or digits":           {Name: "my profile", SSID: "Office", Priority: 2, Authentication: "wpa2-psk", PassPhrase: "Passw0rd!"},
+		"the passphrase must be 8 to 63 characters":                    {Name: "office", SSID: "Office", Priority: 2, Authentication: "wpa2-psk", PassPhrase: "short"},
+		"the authentication must be wpa2-psk or wpa2-ieee8021x":        {Name: "office", SSID: "Office", Priority: 2, Authentication: "wep"},
+		"the 802.1x method must be peap-mschapv2 or eap-ttls-mschapv2": {Name: "office", SSID: "Office", Priority: 2, Authentication: "wpa2-ieee8021x", EAPMethod: "eap-tls"},
+		"wireless profile home already exists":                         {Name: "home", SSID: "Office", Priority: 2, Authentication: "wpa2-psk", PassPhrase: "Passw0rd!"},
+		"wireless profile home already has priority 1":                 {Name: "office", SSID: "Office", Priority: 1, Authentication: "wpa2-psk", PassPhrase: "Passw0rd!"},
+		"the CA certificate is not a PEM certificate":                  {Name: "corp", SSID: "Corp", Priority: 3, Authentication: "wpa2-ieee8021x", EAPMethod: "peap-mschapv2", Username: "device1", Password: "Secret1!", CACertificate: []byte("not a certificate")},
+		"a username and password are required for 802.1x":              {Name: "corp", SSID: "Corp", Priority: 3, Authentication: "wpa2-ieee8021x", EAPMethod: "peap-mschapv2"},
+		"the SSID must be 1 to 32 characters":                          {Name: "office", Priority: 2, Authentication: "wpa2-psk", PassPhrase: "Passw0rd!"},
+		"the profile priority must be at least 1":                      {Name: "office", SSID: "Office", Authentication: "wpa2-psk", PassPhrase: "Passw0rd!"},
+		"a passphrase is only used with wpa2-psk":                      {Name: "corp", SSID: "Corp", Priority: 3, Authentication: "wpa2-ieee8021x", EAPMethod: "peap-mschapv2", Username: "device1", Password: "Secret1!", PassPhrase: "Passw0rd!"},
+	}
+	for message, config := range invalid {
+		_, err := AddWiFiProfile(m, config)
+		assert.EqualError(t, err, message)
+	}
+	assert.Empty(t, m.invocations)
+}
+
+func TestRemoveWiFiProfile(t *testing.T) {
+	m := newNetworkMock(true)
+	settings, err := RemoveWiFiProfile(m, "home")
+	assert.NoError(t, err)
+	assert.Equal(t, []invocation{{ResourceURI: wsman.CIMWiFiEndpointSettingsURI, Method: "Delete", Selectors: []wsman.Selector{{Name: "InstanceID", Value: "Intel(r) AMT:WiFi Endpoint Settings home"}}}}, m.invocations)
+	assert.Equal(t, "remove profile home", settings.Changes[0].Setting)
+
+	_, err = RemoveWiFiProfile(m, "office")
+	assert.EqualError(t, err, "wireless profile office does not exist")
+}
@@ -0,0 +1,122 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package local
+
+import (
+	"fmt"
+	"rpc/pkg/wsman"
+	"strings"
+)
+
+// PowerActions maps the power command actions to CIM_PowerManagementService power states
+var PowerActions = map[string]int{
+	"on":    2,
+	"sleep": 4,
+	"cycle": 5,
+	"off":   8,
+	"reset": 10,
+}
+
+// powerStateNames are the CIM_AssociatedPowerManagementService power states
+var powerStateNames = map[int]string{
+	1:  "Other",
+	2:  "On",
+	3:  "Sleep - Light",
+	4:  "Sleep - Deep",
+	5:  "Power Cycle (Off - Soft)",
+	6:  "Off - Hard",
+	7:  "Hibernate (Off - Soft)",
+	8:  "Off - Soft",
+	9:  "Power Cycle (Off - Hard)",
+	10: "Master Bus Reset",
+	11: "Diagnostic Interrupt (NMI)",
+	12: "Off - Soft Graceful",
+	13: "Off - Hard Graceful",
+	14: "Master Bus Reset Graceful",
+	15: "Power Cycle (Off - Soft Graceful)",
+	16: "Power Cycle (Off - Hard Graceful)",
+}
+
+// PowerStatus is the host power state and, after an action, the result of the state change request
+type PowerStatus struct {
+	Action           string        `json:"action"`
+	PowerState       int           `json:"powerState"`
+	PowerStateName   string        `json:"powerStateName"`
+	AvailableActions []string      `json:"availableActions"`
+	Result           *ActionResult `json:"result,omitempty"`
+}
+
+// GetPowerStatus reads the current host power state
+func GetPowerStatus(w WSMan) (PowerStatus, error) {
+	items, err := w.EnumerateAll(wsman.CIMAssociatedPowerURI)
+	if err != nil {
+		return PowerStatus{}, err
+	}
+	if len(items) == 0 {
+		return PowerStatus{}, fmt.Errorf("AMT returned no power management service")
+	}
+	service := wsman.CIMAssociatedPowerManagementService{}
+	if err := items[0].Decode(&service); err != nil {
+		return PowerStatus{}, err
+	}
+	status := PowerStatus{
+		Action:           "status",
+		PowerState:       service.PowerState,
+		PowerStateName:   powerStateName(service.PowerState),
+		AvailableActions: []string{},
+	}
+	for _, state := range service.AvailableRequestedPowerStates {
+		for action, value := range PowerActions {
+			if value == state {
+				status.AvailableActions = append(status.AvailableActions, action)
+			}
+		}
+	}
+	return status, nil
+}
+
+// RequestPowerAction changes the host power state and reports the state it was in when the request was made
+func RequestPowerAction(w WSMan, action string) (PowerStatus, error) {
+	state, ok := PowerActions[action]
+	if !ok {
+		return PowerStatus{}, fmt.Errorf("unknown power action %s", action)
+	}
+	status, err := GetPowerStatus(w)
+	if err != nil {
+		return PowerStatus{}, err
+	}
+	input := wsman.RequestPowerStateChangeInput{PowerState: state, ManagedElement: wsman.ManagedSystem}
+	output := wsman.MethodOutput{}
+	err = w.Invoke(wsman.CIMPowerManagementServiceURI, "RequestPowerStateChange", nil, input, &output)
+	if err != nil {
+		return PowerStatus{}, err
+	}
+	status.Action = action
+	status.Result = newActionResult(output.ReturnValue)
+	return status, nil
+}
+
+func powerStateName(state int) string {
+	if name, ok := powerStateNames[state]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (%d)", state)
+}
+
+// Failed reports whether the power action was rejected
+func (s PowerStatus) Failed() bool {
+	return s.Result.Failed()
+}
+
+func (s PowerStatus) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Power State\t\t: %s (%d)\n", s.PowerStateName, s.PowerState)
+	fmt.Fprintf(&b, "Available Actions\t: %s\n", strings.Join(s.AvailableActions, ", "))
+	if s.Result != nil {
+		fmt.Fprintf(&b, "Power Action\t\t: %s\n", s.Action)
+		fmt.Fprintf(&b, "Result\t\t\t: %s\n", s.Result)
+	}
+	return b.String()
+}
@@ -0,0 +1,67 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package local
+
+import (
+	"encoding/json"
+	"rpc/pkg/wsman"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const associatedPower = `<h:CIM_AssociatedPowerManagementService xmlns:h="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_AssociatedPowerManagementService"><h:AvailableRequestedPowerStates>8</h:AvailableRequestedPowerStates><h:AvailableRequestedPowerStates>10</h:AvailableRequestedPowerStates><h:AvailableRequestedPowerStates>5</h:AvailableRequestedPowerStates><h:PowerState>2</h:PowerState><h:RequestedPowerState>2</h:RequestedPowerState></h:CIM_AssociatedPowerManagementService>`
+
+func newPowerMock(returnValue string) *mockWSMan {
+	m := newMockWSMan()
+	m.enumeration[wsman.CIMAssociatedPowerURI] = []string{associatedPower}
+	m.outputs[wsman.CIMPowerManagementServiceURI+"/RequestPowerStateChange"] = `<g:RequestPowerStateChange_OUTPUT xmlns:g="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_PowerManagementService"><g:ReturnValue>` + returnValue + `</g:ReturnValue></g:RequestPowerStateChange_OUTPUT>`
+	return m
+}
+
+func TestGetPowerStatus(t *testing.T) {
+	status, err := GetPowerStatus(newPowerMock("0"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, status.PowerState)
+	assert.Equal(t, "On", status.PowerStateName)
+	assert.Equal(t, []string{"off", "reset", "cycle"}, status.AvailableActions)
+	assert.Nil(t, status.Result)
+	assert.False(t, status.Failed())
+	data, _ := json.Marshal(status)
+	assert.Equal(t, `{"action":"status","powerState":2,"powerStateName":"On","availableActions":["off","reset","cycle"]}`, string(data))
+}
+
+func TestGetPowerStatusEmpty(t *testing.T) {
+	m := newMockWSMan()
+	m.enumeration[wsman.CIMAssociatedPowerURI] = []string{}
+	_, err := GetPowerStatus(m)
+	assert.Error(t, err)
+}
+
+func TestRequestPowerAction(t *testing.T) {
+	m := newPowerMock("0")
+	status, err := RequestPowerAction(m, "reset")
+	assert.NoError(t, err)
+	assert.Equal(t, "reset", status.Action)
+	assert.Equal(t, 0, status.Result.ReturnValue)
+	assert.False(t, status.Failed())
+	assert.Len(t, m.invocations, 1)
+	assert.Equal(t, "RequestPowerStateChange", m.invocations[0].Method)
+	assert.Contains(t, m.invocations[0].Body, ">10</PowerState>")
+	assert.Contains(t, m.invocations[0].Body, `<Selector xmlns="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd" Name="Name">ManagedSystem</Selector>`)
+	assert.Contains(t, status.String(), "Result\t\t\t: Completed with No Error (0)")
+}
+
+func TestRequestPowerActionFailed(t *testing.T) {
+	status, err := RequestPowerAction(newPowerMock("2"), "on")
+	assert.NoError(t, err)
+	assert.True(t, status.Failed())
+	assert.Equal(t, "Unknown or Unspecified Error", status.Result.Message)
+}
+
+func TestRequestPowerActionUnknown(t *testing.T) {
+	_, err := RequestPowerAction(newPowerMock("0"), "hibernate")
+	assert.EqualError(t, err, "unknown power action hibernate")
+}
@@ -0,0 +1,445 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package local
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"rpc/pkg/wsman"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxUsernameLength is the longest digest username AMT accepts
+const maxUsernameLength = 16
+
+// UserAccess maps the access names to AMT_AuthorizationService AccessPermission values
+var UserAccess = map[string]int{
+	"local":   0,
+	"network": 1,
+	"any":     2,
+}
+
+// Realms maps the realm names to the AMT realms a user can be granted
+var Realms = map[string]int{
+	"redirection":                   2,
+	"pt-administration":             3,
+	"hardware-asset":                4,
+	"remote-control":                5,
+	"storage":                       6,
+	"event-manager":                 7,
+	"storage-admin":                 8,
+	"agent-presence-local":          9,
+	"agent-presence-remote":         10,
+	"circuit-breaker":               11,
+	"network-time":                  12,
+	"general-info":                  13,
+	"firmware-update":               15,
+	"eit":                           16,
+	"local-un":                      17,
+	"endpoint-access-control":       18,
+	"endpoint-access-control-admin": 19,
+	"event-log-reader":              20,
+	"audit-log":                     21,
+	"acl":                           22,
+	"local-system":                  24,
+}
+
+// userStatusMessages are the AMT status codes returned by the AMT_AuthorizationService methods
+var userStatusMessages = map[int]string{
+	1:    "Internal Error",
+	16:   "Not Permitted",
+	23:   "Maximum Number of Users Reached",
+	36:   "Invalid Parameter",
+	2065: "Duplicate User",
+	2075: "Audit Failed",
+}
+
+// User is an AMT digest user, the password is only used to add or update the user and is never read back
+type User struct {
+	Username string   `json:"username" yaml:"username"`
+	Password string   `json:"-" yaml:"password"`
+	Access   string   `json:"access" yaml:"access"`
+	Realms   []string `json:"realms" yaml:"realms"`
+	handle   int
+}
+
+// UserConfig is the YAML file listing the users a device should have
+type UserConfig struct {
+	Users []User `yaml:"users"`
+}
+
+// Users is the list of AMT digest users with the result of each change made to it
+type Users struct {
+	Users   []User `json:"users"`
+	Changes `json:"changes,omitempty"`
+}
+
+// ReadUserConfig reads and validates a user configuration file
+func ReadUserConfig(path string) (UserConfig, error) {
+	config := UserConfig{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return config, fmt.Errorf("invalid user configuration %s: %v", path, err)
+	}
+	seen := map[string]bool{}
+	for _, user := range config.Users {
+		if err := validateUser(user); err != nil {
+			return config, err
+		}
+		if seen[user.Username] {
+			return config, fmt.Errorf("user %s is listed more than once", user.Username)
+		}
+		seen[user.Username] = true
+	}
+	return config, nil
+}
+
+// validateUser checks the username, access and realms of a user to add or reconcile
+func validateUser(user User) error {
+	if user.Username == "" {
+		return fmt.Errorf("a username is required")
+	}
+	if len(user.Username) > maxUsernameLength {
+		return fmt.Errorf("username %s is longer than %d characters", user.Username, maxUsernameLength)
+	}
+	if user.Username == AdminUser {
+		return fmt.Errorf("the %s user is managed with the AMT password", AdminUser)
+	}
+	if _, ok := UserAccess[user.Access]; !ok {
+		return fmt.Errorf("user %s: access must be local, network or any", user.Username)
+	}
+	if len(user.Realms) == 0 {
+		return fmt.Errorf("user %s: at least one realm is required", user.Username)
+	}
+	for _, realm := range user.Realms {
+		if _, err := ParseRealm(realm); err != nil {
+			return fmt.Errorf("user %s: %v", user.Username, err)
+		}
+	}
+	return nil
+}
+
+// ListUsers reads the digest users from the AMT access control list
+func ListUsers(w WSMan) (Users, error) {
+	users := Users{Users: []User{}}
+	handles := []int{}
+	for {
+		input := wsman.EnumerateUserAclEntriesInput{StartIndex: len(handles) + 1}
+		output := wsman.EnumerateUserAclEntriesOutput{}
+		err := w.Invoke(wsman.AMTAuthorizationServiceURI, "EnumerateUserAclEntries", nil, input, &output)
+		if err != nil {
+			return users, err
+		}
+		if output.ReturnValue != 0 {
+			return users, fmt.Errorf("failed to enumerate the AMT users: %s", newUserResult(output.ReturnValue))
+		}
+		handles = append(handles, output.Handles...)
+		if len(output.Handles) == 0 || len(handles) >= output.TotalCount {
+			break
+		}
+	}
+	for _, handle := range handles {
+		output := wsman.GetUserAclEntryExOutput{}
+		err := w.Invoke(wsman.AMTAuthorizationServiceURI, "GetUserAclEntryEx", nil, wsman.GetUserAclEntryExInput{Handle: handle}, &output)
+		if err != nil {
+			return users, err
+		}
+		if output.ReturnValue != 0 {
+			return users, fmt.Errorf("failed to read AMT user %d: %s", handle, newUserResult(output.ReturnValue))
+		}
+		// Kerberos users have no digest username and are not managed here
+		if output.DigestUsername == "" {
+			continue
+		}
+		users.Users = append(users.Users, User{
+			Username: output.DigestUsername,
+			Access:   accessName(output.AccessPermission),
+			Realms:   realmNames(output.Realms),
+			handle:   handle,
+		})
+	}
+	return users, nil
+}
+
+// AddUser adds a digest user, the password is required
+func AddUser(w WSMan, user User) (Users, error) {
+	current, err := ListUsers(w)
+	if err != nil {
+		return current, err
+	}
+	if current.find(user.Username) != nil {
+		return current, fmt.Errorf("user %s already exists", user.Username)
+	}
+	change, err := addUser(w, user)
+	if err != nil {
+		return current, err
+	}
+	updated, err := ListUsers(w)
+	updated.Changes = Changes{change}
+	return updated, err
+}
+
+// UpdateUser changes the password, access or realms of a digest user, empty fields are left unchanged
+func UpdateUser(w WSMan, user User) (Users, error) {
+	current, err := ListUsers(w)
+	if err != nil {
+		return current, err
+	}
+	existing := current.find(user.Username)
+	if existing == nil {
+		return current, fmt.Errorf("user %s does not exist", user.Username)
+	}
+	updated := *existing
+	updated.Password = user.Password
+	if user.Access != "" {
+		updated.Access = user.Access
+	}
+	if len(user.Realms) > 0 {
+		updated.Realms = user.Realms
+	}
+	change, err := updateUser(w, updated)
+	if err != nil {
+		return current, err
+	}
+	users, err := ListUsers(w)
+	users.Changes = Changes{change}
+	return users, err
+}
+
+// RemoveUser removes a digest user
+func RemoveUser(w WSMan, username string) (Users, error) {
+	current, err := ListUsers(w)
+	if err != nil {
+		return current, err
+	}
+	existing := current.find(username)
+	if existing == nil {
+		return current, fmt.Errorf("user %s does not exist", username)
+	}
+	change, err := removeUser(w, *existing)
+	if err != nil {
+		return current, err
+	}
+	updated, err := ListUsers(w)
+	updated.Changes = Changes{change}
+	return updated, err
+}
+
+// ApplyUsers adds the users of config that are missing and updates those whose access or realms differ.
+// Passwords are only set when a user is added, so applying the same configuration again changes nothing.
+// With prune, users that are not in config are removed.
+func ApplyUsers(w WSMan, config UserConfig, prune bool) (Users, error) {
+	current, err := ListUsers(w)
+	if err != nil {
+		return current, err
+	}
+	changes := Changes{}
+	wanted := map[string]bool{}
+	for _, user := range config.Users {
+		wanted[user.Username] = true
+		existing := current.find(user.Username)
+		var change Change
+		switch {
+		case existing == nil:
+			change, err = addUser(w, user)
+		case existing.Access != user.Access || !sameRealms(existing.Realms, user.Realms):
+			user.handle = existing.handle
+			user.Password = ""
+			change, err = updateUser(w, user)
+		default:
+			continue
+		}
+		if err != nil {
+			current.Changes = changes
+			return current, err
+		}
+		changes = append(changes, change)
+	}
+	if prune {
+		for _, user := range current.Users {
+			if wanted[user.Username] {
+				continue
+			}
+			change, err := removeUser(w, user)
+			if err != nil {
+				current.Changes = changes
+				return current, err
+			}
+			changes = append(changes, change)
+		}
+	}
+	updated, err := ListUsers(w)
+	updated.Changes = changes
+	return updated, err
+}
+
+func addUser(w WSMan, user User) (Change, error) {
+	if err := validateUser(user); err != nil {
+		return Change{}, err
+	}
+	if user.Password == "" {
+		return Change{}, fmt.Errorf("a password is required to add user %s", user.Username)
+	}
+	password, err := digestPassword(w, user)
+	if err != nil {
+		return Change{}, err
+	}
+	input := wsman.AddUserAclEntryExInput{
+		DigestUsername:   user.Username,
+		DigestPassword:   password,
+		AccessPermission: UserAccess[user.Access],
+		Realms:           realmValues(user.Realms),
+	}
+	output := wsman.AddUserAclEntryExOutput{}
+	err = w.Invoke(wsman.AMTAuthorizationServiceURI, "AddUserAclEntryEx", nil, input, &output)
+	if err != nil {
+		return Change{}, err
+	}
+	return Change{Setting: "add " + user.Username, Result: newUserResult(output.ReturnValue)}, nil
+}
+
+func updateUser(w WSMan, user User) (Change, error) {
+	if err := validateUser(user); err != nil {
+		return Change{}, err
+	}
+	input := wsman.UpdateUserAclEntryExInput{
+		Handle:           user.handle,
+		DigestUsername:   user.Username,
+		AccessPermission: UserAccess[user.Access],
+		Realms:           realmValues(user.Realms),
+	}
+	if user.Password != "" {
+		password, err := digestPassword(w, user)
+		if err != nil {
+			return Change{}, err
+		}
+		input.DigestPassword = password
+	}
+	output := wsman.MethodOutput{}
+	err := w.Invoke(wsman.AMTAuthorizationServiceURI, "UpdateUserAclEntryEx", nil, input, &output)
+	if err != nil {
+		return Change{}, err
+	}
+	return Change{Setting: "update " + user.Username, Result: newUserResult(output.ReturnValue)}, nil
+}
+
+func removeUser(w WSMan, user User) (Change, error) {
+	output := wsman.MethodOutput{}
+	err := w.Invoke(wsman.AMTAuthorizationServiceURI, "RemoveUserAclEntry", nil, wsman.RemoveUserAclEntryInput{Handle: user.handle}, &output)
+	if err != nil {
+		return Change{}, err
+	}
+	return Change{Setting: "remove " + user.Username, Result: newUserResult(output.ReturnValue)}, nil
+}
+
+// digestPassword hashes the password with the digest realm of the device, which is how AMT stores it
+func digestPassword(w WSMan, user User) (string, error) {
+	settings := wsman.AMTGeneralSettings{}
+	err := w.Get(wsman.AMTGeneralSettingsURI, nil, &settings)
+	if err != nil {
+		return "", err
+	}
+	hash := md5.Sum([]byte(user.Username + ":" + settings.DigestRealm + ":" + user.Password))
+	return base64.StdEncoding.EncodeToString(hash[:]), nil
+}
+
+func newUserResult(returnValue int) *ActionResult {
+	if message, ok := userStatusMessages[returnValue]; ok {
+		return &ActionResult{ReturnValue: returnValue, Message: message}
+	}
+	return newActionResult(returnValue)
+}
+
+func accessName(permission int) string {
+	for name, value := range UserAccess {
+		if value == permission {
+			return name
+		}
+	}
+	return fmt.Sprint(permission)
+}
+
+// realmNames returns the sorted names of realms, unknown realms are kept as numbers
+func realmNames(realms []int) []string {
+	names := []string{}
+	for _, realm := range realms {
+		name := fmt.Sprint(realm)
+		for n, value := range Realms {
+			if value == realm {
+				name = n
+			}
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseRealm returns the value of a realm name, or of a realm number as realmNames lists realms without a name
+func ParseRealm(name string) (int, error) {
+	if value, ok := Realms[name]; ok {
+		return value, nil
+	}
+	value, err := strconv.Atoi(name)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("unknown realm %s", name)
+	}
+	return value, nil
+}
+
+// realmValues returns the sorted values of realms that passed validateUser
+func realmValues(names []string) []int {
+	values := []int{}
+	for _, name := range names {
+		value, _ := ParseRealm(name)
+		values = append(values, value)
+	}
+	sort.Ints(values)
+	return values
+}
+
+func sameRealms(a []string, b []string) bool {
+	x, y := realmValues(a), realmValues(b)
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (u Users) find(username string) *User {
+	for i := range u.Users {
+		if u.Users[i].Username == username {
+			return &u.Users[i]
+		}
+	}
+	return nil
+}
+
+func (u Users) String() string {
+	var b strings.Builder
+	u.Changes.write(&b)
+	if len(u.Users) == 0 {
+		b.WriteString("No users besides admin\n")
+	}
+	for _, user := range u.Users {
+		fmt.Fprintf(&b, "%-16s %-8s %s\n", user.Username, user.Access, strings.Join(user.Realms, ", "))
+	}
+	return b.String()
+}
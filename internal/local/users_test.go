@@ -0,0 +1,279 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package local
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"rpc/pkg/wsman"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const authorizationNS = `xmlns:g="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_AuthorizationService"`
+
+// usersMock keeps the AMT access control list in memory so the methods of AMT_AuthorizationService see each other's changes
+type usersMock struct {
+	*mockWSMan
+	entries    map[int]wsman.AddUserAclEntryExInput
+	nextHandle int
+	returns    map[string]int
+}
+
+func newUsersMock() *usersMock {
+	m := &usersMock{mockWSMan: newMockWSMan(), entries: map[int]wsman.AddUserAclEntryExInput{}, nextHandle: 1, returns: map[string]int{}}
+	m.instances[wsman.AMTGeneralSettingsURI] = `<g:AMT_GeneralSettings xmlns:g="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_GeneralSettings"><g:DigestRealm>Digest:A3829B3827DE4D33D4449B366831B8E5</g:DigestRealm></g:AMT_GeneralSettings>`
+	return m
+}
+
+func (m *usersMock) add(username string, access int, realms ...int) {
+	m.entries[m.nextHandle] = wsman.AddUserAclEntryExInput{DigestUsername: username, AccessPermission: access, Realms: realms}
+	m.nextHandle++
+}
+
+func (m *usersMock) Invoke(resourceURI string, method string, selectors []wsman.Selector, in interface{}, out interface{}) error {
+	if resourceURI != wsman.AMTAuthorizationServiceURI {
+		return m.mockWSMan.Invoke(resourceURI, method, selectors, in, out)
+	}
+	if method != "EnumerateUserAclEntries" && method != "GetUserAclEntryEx" {
+		m.invocations = append(m.invocations, invocation{ResourceURI: resourceURI, Method: method, Body: marshal(in)})
+	}
+	returnValue := m.returns[method]
+	response := ""
+	switch input := in.(type) {
+	case wsman.EnumerateUserAclEntriesInput:
+		handles := []int{}
+		for handle := range m.entries {
+			handles = append(handles, handle)
+		}
+		sort.Ints(handles)
+		// AMT returns at most 50 handles per call, two are enough to exercise paging here
+		page := handles[min(input.StartIndex-1, len(handles)):min(input.StartIndex+1, len(handles))]
+		response = fmt.Sprintf("<g:TotalCount>%d</g:TotalCount><g:HandlesCount>%d</g:HandlesCount>", len(handles), len(page))
+		for _, handle := range page {
+			response += fmt.Sprintf("<g:Handles>%d</g:Handles>", handle)
+		}
+	case wsman.GetUserAclEntryExInput:
+		entry := m.entries[input.Handle]
+		response = fmt.Sprintf("<g:DigestUsername>%s</g:DigestUsername><g:AccessPermission>%d</g:AccessPermission>", entry.DigestUsername, entry.AccessPermission)
+		for _, realm := range entry.Realms {
+			response += fmt.Sprintf("<g:Realms>%d</g:Realms>", realm)
+		}
+	case wsman.AddUserAclEntryExInput:
+		if returnValue == 0 {
+			m.add(input.DigestUsername, input.AccessPermission, input.Realms...)
+		}
+	case wsman.UpdateUserAclEntryExInput:
+		if returnValue == 0 {
+			m.entries[input.Handle] = wsman.AddUserAclEntryExInput{DigestUsername: input.DigestUsername, AccessPermission: input.AccessPermission, Realms: input.Realms}
+		}
+	case wsman.RemoveUserAclEntryInput:
+		if returnValue == 0 {
+			delete(m.entries, input.Handle)
+		}
+	}
+	response = fmt.Sprintf(`<g:%s_OUTPUT %s>%s<g:ReturnValue>%d</g:ReturnValue></g:%s_OUTPUT>`, method, authorizationNS, response, returnValue, method)
+	return xml.Unmarshal([]byte(response), out)
+}
+
+func marshal(in interface{}) string {
+	body, _ := xml.Marshal(in)
+	return string(body)
+}
+
+func min(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func TestListUsers(t *testing.T) {
+	m := newUsersMock()
+	m.add("noc", 1, 20, 4, 13)
+	m.add("", 2, 3)
+	m.add("helpdesk", 0, 2, 99)
+	users, err := ListUsers(m)
+	assert.NoError(t, err)
+	assert.Equal(t, []User{
+		{Username: "noc", Access: "network", Realms: []string{"event-log-reader", "general-info", "hardware-asset"}, handle: 1},
+		{Username: "helpdesk", Access: "local", Realms: []string{"99", "redirection"}, handle: 3},
+	}, users.Users)
+	assert.False(t, users.Failed())
+	assert.Equal(t, "noc              network  event-log-reader, general-info, hardware-asset\nhelpdesk         local    99, redirection\n", users.String())
+}
+
+func TestAddUser(t *testing.T) {
+	m := newUsersMock()
+	users, err := AddUser(m, User{Username: "noc", Password: "P@ssw0rd", Access: "network", Realms: []string{"hardware-asset", "general-info"}})
+	assert.NoError(t, err)
+	assert.Len(t, users.Users, 1)
+	assert.Equal(t, Changes{{Setting: "add noc", Result: newActionResult(0)}}, users.Changes)
+	assert.Len(t, m.invocations, 1)
+	body := m.invocations[0].Body
+	assert.Contains(t, body, "<DigestPassword>"+digest("noc", "P@ssw0rd")+"</DigestPassword>")
+	assert.Contains(t, body, "<AccessPermission>1</AccessPermission><Realms>4</Realms><Realms>13</Realms>")
+	assert.NotContains(t, body, "P@ssw0rd")
+}
+
+// digest is the DigestPassword AMT expects, computed independently of digestPassword
+func digest(username string, password string) string {
+	hash := md5.Sum([]byte(username + ":Digest:A3829B3827DE4D33D4449B366831B8E5:" + password))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+func TestAddUserErrors(t *testing.T) {
+	m := newUsersMock()
+	m.add("noc", 1, 4)
+	_, err := AddUser(m, User{Username: "noc", Password: "P@ssw0rd", Access: "network", Realms: []string{"hardware-asset"}})
+	assert.EqualError(t, err, "user noc already exists")
+	_, err = AddUser(m, User{Username: "ops", Access: "network", Realms: []string{"hardware-asset"}})
+	assert.EqualError(t, err, "a password is required to add user ops")
+	_, err = AddUser(m, User{Username: "ops", Password: "P@ssw0rd", Access: "network", Realms: []string{"everything"}})
+	assert.EqualError(t, err, "user ops: unknown realm everything")
+	_, err = AddUser(m, User{Username: "admin", Password: "P@ssw0rd", Access: "any", Realms: []string{"redirection"}})
+	assert.Error(t, err)
+	assert.Empty(t, m.invocations)
+}
+
+func TestAddUserRejected(t *testing.T) {
+	m := newUsersMock()
+	m.returns["AddUserAclEntryEx"] = 23
+	users, err := AddUser(m, User{Username: "noc", Password: "P@ssw0rd", Access: "network", Realms: []string{"hardware-asset"}})
+	assert.NoError(t, err)
+	assert.True(t, users.Failed())
+	assert.Contains(t, users.String(), "Changed add noc\t: Maximum Number of Users Reached (23)\n")
+}
+
+func TestUpdateUserKeepsUnsetFields(t *testing.T) {
+	m := newUsersMock()
+	m.add("noc", 1, 4)
+	users, err := UpdateUser(m, User{Username: "noc", Realms: []string{"hardware-asset", "event-log-reader"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "network", users.Users[0].Access)
+	assert.Equal(t, []string{"event-log-reader", "hardware-asset"}, users.Users[0].Realms)
+	assert.NotContains(t, m.invocations[0].Body, "DigestPassword")
+	assert.Contains(t, m.invocations[0].Body, "<Handle>1</Handle><DigestUsername>noc</DigestUsername>")
+
+	_, err = UpdateUser(m, User{Username: "noc", Password: "N3wP@ssw0rd"})
+	assert.NoError(t, err)
+	assert.Contains(t, m.invocations[1].Body, "<DigestPassword>"+digest("noc", "N3wP@ssw0rd")+"</DigestPassword>")
+
+	_, err = UpdateUser(m, User{Username: "ops", Access: "any"})
+	assert.EqualError(t, err, "user ops does not exist")
+}
+
+func TestUpdateUserKeepsUnknownRealms(t *testing.T) {
+	m := newUsersMock()
+	m.add("helpdesk", 0, 2, 14)
+	users, err := UpdateUser(m, User{Username: "helpdesk", Access: "network"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"14", "redirection"}, users.Users[0].Realms)
+	assert.Contains(t, m.invocations[0].Body, "<AccessPermission>1</AccessPermission><Realms>2</Realms><Realms>14</Realms>")
+
+	// the numeric name listed for an unknown realm compares equal to the realm itself
+	m.invocations = nil
+	users, err = ApplyUsers(m, UserConfig{Users: []User{{Username: "helpdesk", Access: "network", Realms: []string{"redirection", "14"}}}}, false)
+	assert.NoError(t, err)
+	assert.Empty(t, users.Changes)
+	assert.Empty(t, m.invocations)
+}
+
+func TestParseRealm(t *testing.T) {
+	value, err := ParseRealm("hardware-asset")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, value)
+	value, err = ParseRealm("14")
+	assert.NoError(t, err)
+	assert.Equal(t, 14, value)
+	_, err = ParseRealm("everything")
+	assert.EqualError(t, err, "unknown realm everything")
+	_, err = ParseRealm("-1")
+	assert.EqualError(t, err, "unknown realm -1")
+}
+
+func TestRemoveUser(t *testing.T) {
+	m := newUsersMock()
+	m.add("noc", 1, 4)
+	m.add("ops", 1, 4)
+	users, err := RemoveUser(m, "noc")
+	assert.NoError(t, err)
+	assert.Equal(t, "<RemoveUserAclEntry_INPUT xmlns=\"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_AuthorizationService\"><Handle>1</Handle></RemoveUserAclEntry_INPUT>", m.invocations[0].Body)
+	assert.Len(t, users.Users, 1)
+	assert.Equal(t, "ops", users.Users[0].Username)
+}
+
+func TestApplyUsersIsIdempotent(t *testing.T) {
+	m := newUsersMock()
+	m.add("noc", 0, 4)
+	m.add("old", 1, 4)
+	m.add("keep", 2, 2)
+	config := UserConfig{Users: []User{
+		{Username: "noc", Access: "network", Realms: []string{"hardware-asset"}},
+		{Username: "monitor", Password: "P@ssw0rd", Access: "network", Realms: []string{"event-log-reader", "general-info"}},
+		{Username: "keep", Access: "any", Realms: []string{"redirection"}},
+	}}
+	users, err := ApplyUsers(m, config, true)
+	assert.NoError(t, err)
+	settings := []string{}
+	for _, change := range users.Changes {
+		settings = append(settings, change.Setting)
+	}
+	assert.Equal(t, []string{"update noc", "add monitor", "remove old"}, settings)
+	assert.Len(t, users.Users, 3)
+
+	m.invocations = nil
+	users, err = ApplyUsers(m, config, true)
+	assert.NoError(t, err)
+	assert.Empty(t, users.Changes)
+	assert.Empty(t, m.invocations)
+}
+
+func TestApplyUsersWithoutPrune(t *testing.T) {
+	m := newUsersMock()
+	m.add("old", 1, 4)
+	users, err := ApplyUsers(m, UserConfig{}, false)
+	assert.NoError(t, err)
+	assert.Empty(t, users.Changes)
+	assert.Len(t, users.Users, 1)
+}
+
+func TestReadUserConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "users")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "users.yaml")
+	yaml := strings.Join([]string{
+		"users:",
+		"  - username: monitor",
+		"    password: P@ssw0rd",
+		"    access: network",
+		"    realms: [hardware-asset, event-log-reader]",
+		"",
+	}, "\n")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(yaml), 0600))
+	config, err := ReadUserConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []User{{Username: "monitor", Password: "P@ssw0rd", Access: "network", Realms: []string{"hardware-asset", "event-log-reader"}}}, config.Users)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte(yaml+strings.Join(strings.Split(yaml, "\n")[1:], "\n")), 0600))
+	_, err = ReadUserConfig(path)
+	assert.EqualError(t, err, "user monitor is listed more than once")
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("users:\n  - username: monitor\n    access: remote\n"), 0600))
+	_, err = ReadUserConfig(path)
+	assert.EqualError(t, err, "user monitor: access must be local, network or any")
+
+	_, err = ReadUserConfig(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
@@ -6,35 +6,77 @@ package rpc
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"rpc/internal/amt"
+	"rpc/internal/local"
+	"rpc/pkg/dnssuffix"
+	"rpc/pkg/heci"
+	"rpc/pkg/mefw"
+	"rpc/pkg/smbios"
 	"rpc/pkg/utils"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
 // Flags holds data received from the command line
 type Flags struct {
-	commandLineArgs       []string
-	URL                   string
-	DNS                   string
-	Hostname              string
-	Proxy                 string
-	Command               string
-	Profile               string
-	SkipCertCheck         bool
-	Verbose               bool
-	JsonOutput            bool
-	SyncClock             bool
-	Password              string
+	commandLineArgs []string
+	URL             string
+	DNS             string
+	Hostname        string
+	Proxy           string
+	Command         string
+	SubCommand      string
+	Profile         string
+	SkipCertCheck   bool
+	DryRun          bool
+	Verbose         bool
+	JsonOutput      bool
+	CSVOutput       bool
+	SyncClock       bool
+	Password        string
+	OutputFile      string
+	MEIDevice       string
+	Inventory       bool
+	Tags            Tags
+	Features        local.FeatureChanges
+	Since           time.Time
+	CompareOS       bool
+	BootDevice      string
+	BootReset       bool
+	User            local.User
+	UserConfig      string
+	PruneUsers      bool
+	Wired           local.WiredConfig
+	Wireless        local.WirelessChanges
+	WiFiProfile     local.WiFiProfileConfig
+	CACertFile      string
+	ExitCode        int
+	// handled is set by commands that complete while parsing, so ParseFlags returning false is not a usage error
+	handled               bool
 	amtInfoCommand        *flag.FlagSet
 	amtActivateCommand    *flag.FlagSet
 	amtDeactivateCommand  *flag.FlagSet
 	amtMaintenanceCommand *flag.FlagSet
+	checkCommand          *flag.FlagSet
+	diagCommand           *flag.FlagSet
+	meInfoCommand         *flag.FlagSet
+	featuresCommand       *flag.FlagSet
+	eventLogCommand       *flag.FlagSet
+	auditLogCommand       *flag.FlagSet
+	inventoryCommand      *flag.FlagSet
+	bootCommand           *flag.FlagSet
+	usersCommand          *flag.FlagSet
+	networkCommand        *flag.FlagSet
+	powerCommand          *flag.FlagSet
 	versionCommand        *flag.FlagSet
 }
 
@@ -43,11 +85,24 @@ func NewFlags(args []string) *Flags {
 	flags.commandLineArgs = args
 	flags.amtInfoCommand = flag.NewFlagSet("amtinfo", flag.ExitOnError)
 	flags.amtInfoCommand.BoolVar(&flags.JsonOutput, "json", false, "json output")
+	flags.setupDeviceFlags(flags.amtInfoCommand)
 
 	flags.amtActivateCommand = flag.NewFlagSet("activate", flag.ExitOnError)
 	flags.amtDeactivateCommand = flag.NewFlagSet("deactivate", flag.ExitOnError)
 	flags.amtMaintenanceCommand = flag.NewFlagSet("maintenance", flag.ExitOnError)
 
+	flags.checkCommand = flag.NewFlagSet("check", flag.ExitOnError)
+	flags.diagCommand = flag.NewFlagSet("diag", flag.ExitOnError)
+	flags.meInfoCommand = flag.NewFlagSet("meinfo", flag.ExitOnError)
+	flags.powerCommand = flag.NewFlagSet("power", flag.ExitOnError)
+	flags.featuresCommand = flag.NewFlagSet("features", flag.ExitOnError)
+	flags.eventLogCommand = flag.NewFlagSet("eventlog", flag.ExitOnError)
+	flags.auditLogCommand = flag.NewFlagSet("auditlog", flag.ExitOnError)
+	flags.inventoryCommand = flag.NewFlagSet("inventory", flag.ExitOnError)
+	flags.bootCommand = flag.NewFlagSet("boot", flag.ExitOnError)
+	flags.usersCommand = flag.NewFlagSet("users", flag.ExitOnError)
+	flags.networkCommand = flag.NewFlagSet("network", flag.ExitOnError)
+
 	flags.versionCommand = flag.NewFlagSet("version", flag.ExitOnError)
 	flags.versionCommand.BoolVar(&flags.JsonOutput, "json", false, "json output")
 
@@ -56,12 +111,23 @@ func NewFlags(args []string) *Flags {
 }
 
 // ParseFlags is used for understanding the command line flags
+// ParseFlags returns false when rpc should exit without running a command, either because a command
+// such as amtinfo was completed while parsing or because the arguments are invalid. ExitCode is the
+// code to exit with in both cases.
 func (f *Flags) ParseFlags() (string, bool) {
+	command, success := f.parseCommand()
+	if !success && !f.handled && f.ExitCode == utils.Success {
+		f.ExitCode = utils.GenericFailure
+	}
+	return command, success
+}
 
+func (f *Flags) parseCommand() (string, bool) {
 	if len(f.commandLineArgs) > 1 {
 		switch f.commandLineArgs[1] {
 		case "amtinfo":
 			f.handleAMTInfo(f.amtInfoCommand)
+			f.handled = true
 			return "amtinfo", false //we want to exit the program
 		case "activate":
 			success := f.handleActivateCommand()
@@ -72,8 +138,43 @@ func (f *Flags) ParseFlags() (string, bool) {
 		case "deactivate":
 			success := f.handleDeactivateCommand()
 			return "deactivate", success
+		case "meinfo":
+			f.handleMEInfoCommand()
+			f.handled = true
+			return "meinfo", false //we want to exit the program
+		case "check":
+			success := f.handleCheckCommand()
+			return "check", success
+		case "diag":
+			success := f.handleDiagCommand()
+			return "diag", success
+		case "power":
+			success := f.handlePowerCommand()
+			return "power", success
+		case "features":
+			success := f.handleFeaturesCommand()
+			return "features", success
+		case "eventlog":
+			success := f.handleLogCommand(f.eventLogCommand)
+			return "eventlog", success
+		case "auditlog":
+			success := f.handleLogCommand(f.auditLogCommand)
+			return "auditlog", success
+		case "inventory":
+			success := f.handleInventoryCommand()
+			return "inventory", success
+		case "boot":
+			success := f.handleBootCommand()
+			return "boot", success
+		case "users":
+			success := f.handleUsersCommand()
+			return "users", success
+		case "network":
+			success := f.handleNetworkCommand()
+			return "network", success
 		case "version":
 			f.handleVersionCommand()
+			f.handled = true
 			return "version", false
 		default:
 			f.printUsage()
@@ -96,6 +197,30 @@ func (f *Flags) printUsage() string {
 	usage = usage + "              Example: ./rpc maintenance -u wss://server/activate\n"
 	usage = usage + "  amtinfo     Displays information about AMT status and configuration\n"
 	usage = usage + "              Example: ./rpc amtinfo\n"
+	usage = usage + "  meinfo      Displays the ME firmware version and capabilities, including on non-AMT SKUs\n"
+	usage = usage + "              Example: ./rpc meinfo\n"
+	usage = usage + "  check       Verifies the prerequisites for activating this device\n"
+	usage = usage + "              Example: ./rpc check -u wss://server/activate\n"
+	usage = usage + "  diag        Collects diagnostic information into a tar.gz bundle for support\n"
+	usage = usage + "              Example: ./rpc diag -o diag.tar.gz\n"
+	usage = usage + "  power       Displays or changes the host power state through AMT. AMT password is required\n"
+	usage = usage + "              Example: ./rpc power reset\n"
+	usage = usage + "  features    Displays or changes KVM, SOL, IDE-R and user consent. AMT password is required\n"
+	usage = usage + "              Example: ./rpc features set -kvm -userconsent none\n"
+	usage = usage + "  eventlog    Displays the AMT event log. AMT password is required\n"
+	usage = usage + "              Example: ./rpc eventlog -since 24h\n"
+	usage = usage + "  auditlog    Displays the AMT security audit log. AMT password is required\n"
+	usage = usage + "              Example: ./rpc auditlog -csv\n"
+	usage = usage + "  inventory   Displays the hardware inventory reported by AMT. AMT password is required\n"
+	usage = usage + "              Example: ./rpc inventory -diff\n"
+	usage = usage + "  boot        Displays the boot options or forces the device used for the next boot. AMT password is required\n"
+	usage = usage + "              Example: ./rpc boot set -next pxe -reset\n"
+	usage = usage + "  users       Lists, adds, removes or updates AMT digest users, or applies a YAML user file. AMT password is required\n"
+	usage = usage + "              Example: ./rpc users add -username noc -access network -realms hardware-asset,event-log-reader\n"
+	usage = usage + "              Example: ./rpc users update -config users.yaml\n"
+	usage = usage + "  network     Displays or configures the AMT wired and wireless network settings. AMT password is required\n"
+	usage = usage + "              Example: ./rpc network wired -static -ip 192.168.1.50 -subnetmask 255.255.255.0 -gateway 192.168.1.1\n"
+	usage = usage + "              Example: ./rpc network addprofile -name office -ssid Office -priority 1 -auth wpa2-psk -passphrase <passphrase>\n"
 	usage = usage + "  version     Displays the current version of RPC and the RPC Protocol version\n"
 	usage = usage + "              Example: ./rpc version\n"
 	usage = usage + "\nRun 'rpc COMMAND' for more information on a command.\n"
@@ -107,9 +232,12 @@ func (f *Flags) setupCommonFlags() {
 	for _, fs := range []*flag.FlagSet{f.amtActivateCommand, f.amtDeactivateCommand, f.amtMaintenanceCommand} {
 		fs.StringVar(&f.URL, "u", "", "websocket address of server to activate against") //required
 		fs.BoolVar(&f.SkipCertCheck, "n", false, "skip websocket server certificate verification")
+		fs.BoolVar(&f.DryRun, "dry-run", false, "display the request that would be sent to the server without connecting to it")
 		fs.StringVar(&f.Proxy, "p", "", "proxy address and port")
 		fs.BoolVar(&f.Verbose, "v", false, "verbose output")
 		fs.BoolVar(&f.JsonOutput, "json", false, "json output")
+		f.setupDeviceFlags(fs)
+		fs.BoolVar(&f.Inventory, "inventory", f.lookupEnvOrBool("INVENTORY", false), "include a device inventory (OS, SMBIOS, network interfaces and AMT adapters) in the request")
 	}
 }
 func (f *Flags) handleMaintenanceCommand() bool {
@@ -121,27 +249,49 @@ func (f *Flags) handleMaintenanceCommand() bool {
 		return false
 	}
 	f.amtMaintenanceCommand.Parse(f.commandLineArgs[2:])
+	heci.Device = f.MEIDevice
 	if f.amtMaintenanceCommand.Parsed() {
-		if f.URL == "" {
+		if f.URL == "" && !f.DryRun {
 			fmt.Println("-u flag is required and cannot be empty")
 			f.amtActivateCommand.Usage()
 			return false
 		}
-		if f.Password == "" {
-			fmt.Println("Please enter AMT Password: ")
-			var password string
-			// Taking input from user
-			_, err := fmt.Scanln(&password)
-			if password == "" || err != nil {
-				return false
-			}
-			f.Password = password
+		if !f.readPassword() {
+			return false
 		}
 	}
 	f.Command = "maintenance --synctime --password " + f.Password
 	return true
 }
 
+// PrintAccessError displays why the AMT host interface could not be opened and returns the exit code to use
+func PrintAccessError(err error) int {
+	accessError := &amt.AccessError{}
+	if errors.As(err, &accessError) {
+		println("Unable to launch application: " + accessError.Cause)
+		println(accessError.Remediation)
+		return accessError.ExitCode
+	}
+	println(utils.AccessErrorMessage)
+	return utils.GenericFailure
+}
+
+// readPassword prompts for the AMT password unless it was given on the command line or in the environment
+func (f *Flags) readPassword() bool {
+	if f.Password != "" {
+		return true
+	}
+	fmt.Println("Please enter AMT Password: ")
+	var password string
+	// Taking input from user
+	_, err := fmt.Scanln(&password)
+	if password == "" || err != nil {
+		return false
+	}
+	f.Password = password
+	return true
+}
+
 func (f *Flags) lookupEnvOrString(key string, defaultVal string) string {
 	if val, ok := os.LookupEnv(key); ok {
 		return val
@@ -165,15 +315,23 @@ func (f *Flags) handleActivateCommand() bool {
 	f.amtActivateCommand.StringVar(&f.Hostname, "h", f.lookupEnvOrString("HOSTNAME", ""), "hostname override")
 	f.amtActivateCommand.StringVar(&f.Profile, "profile", f.lookupEnvOrString("PROFILE", ""), "name of the profile to use")
 	f.amtActivateCommand.StringVar(&f.Password, "password", f.lookupEnvOrString("AMT_PASSWORD", ""), "AMT password")
+	f.Tags = Tags{}
+	f.amtActivateCommand.Var(f.Tags, "tag", "key=value metadata sent to the server, can be repeated (env TAGS=key=value,key=value)")
 
 	if len(f.commandLineArgs) == 2 {
 		f.amtActivateCommand.PrintDefaults()
 		return false
 	}
+	// tags from the environment are applied first so command line tags override them
+	if err := f.Tags.setList(f.lookupEnvOrString("TAGS", "")); err != nil {
+		fmt.Println("invalid TAGS environment variable: " + err.Error())
+		return false
+	}
 	f.amtActivateCommand.Parse(f.commandLineArgs[2:])
+	heci.Device = f.MEIDevice
 
 	if f.amtActivateCommand.Parsed() {
-		if f.URL == "" {
+		if f.URL == "" && !f.DryRun {
 			fmt.Println("-u flag is required and cannot be empty")
 			f.amtActivateCommand.Usage()
 			return false
@@ -196,22 +354,16 @@ func (f *Flags) handleDeactivateCommand() bool {
 		return false
 	}
 	f.amtDeactivateCommand.Parse(f.commandLineArgs[2:])
+	heci.Device = f.MEIDevice
 
 	if f.amtDeactivateCommand.Parsed() {
-		if f.URL == "" {
+		if f.URL == "" && !f.DryRun {
 			fmt.Println("-u flag is required and cannot be empty")
 			f.amtDeactivateCommand.Usage()
 			return false
 		}
-		if f.Password == "" {
-			fmt.Println("Please enter AMT Password: ")
-			var password string
-			// Taking input from user
-			_, err := fmt.Scanln(&password)
-			if password == "" || err != nil {
-				return false
-			}
-			f.Password = password
+		if !f.readPassword() {
+			return false
 		}
 		f.Command = "deactivate --password " + f.Password
 		if *forcePtr {
@@ -220,162 +372,350 @@ func (f *Flags) handleDeactivateCommand() bool {
 	}
 	return true
 }
-func (f *Flags) handleAMTInfo(amtInfoCommand *flag.FlagSet) {
-	amtInfoVerPtr := amtInfoCommand.Bool("ver", false, "BIOS Version")
-	amtInfoBldPtr := amtInfoCommand.Bool("bld", false, "Build Number")
-	amtInfoSkuPtr := amtInfoCommand.Bool("sku", false, "Product SKU")
-	amtInfoUUIDPtr := amtInfoCommand.Bool("uuid", false, "Unique Identifier")
-	amtInfoModePtr := amtInfoCommand.Bool("mode", false, "Current Control Mode")
-	amtInfoDNSPtr := amtInfoCommand.Bool("dns", false, "Domain Name Suffix")
-	amtInfoCertPtr := amtInfoCommand.Bool("cert", false, "Certificate Hashes")
-	amtInfoRasPtr := amtInfoCommand.Bool("ras", false, "Remote Access Status")
-	amtInfoLanPtr := amtInfoCommand.Bool("lan", false, "LAN Settings")
-	amtInfoHostnamePtr := amtInfoCommand.Bool("hostname", false, "OS Hostname")
+func (f *Flags) handleCheckCommand() bool {
+	f.checkCommand.StringVar(&f.URL, "u", "", "websocket address of server to check connectivity to")
+	f.checkCommand.BoolVar(&f.SkipCertCheck, "n", false, "skip websocket server certificate verification")
+	f.checkCommand.StringVar(&f.Proxy, "p", "", "proxy address and port")
+	f.checkCommand.BoolVar(&f.Verbose, "v", false, "verbose output")
+	f.checkCommand.BoolVar(&f.JsonOutput, "json", false, "json output")
+	f.setupDeviceFlags(f.checkCommand)
+
+	f.checkCommand.Parse(f.commandLineArgs[2:])
+	heci.Device = f.MEIDevice
+	f.Command = "check"
+	return true
+}
+func (f *Flags) handleDiagCommand() bool {
+	f.diagCommand.StringVar(&f.OutputFile, "o", "", "path of the diagnostics bundle to create")
+	f.diagCommand.StringVar(&f.URL, "u", "", "websocket address of server to activate against")
+	f.diagCommand.StringVar(&f.Proxy, "p", "", "proxy address and port")
+	f.diagCommand.StringVar(&f.DNS, "d", f.lookupEnvOrString("DNS_SUFFIX", ""), "dns suffix override")
+	f.diagCommand.StringVar(&f.Hostname, "h", f.lookupEnvOrString("HOSTNAME", ""), "hostname override")
+	f.diagCommand.StringVar(&f.Profile, "profile", f.lookupEnvOrString("PROFILE", ""), "name of the profile to use")
+	f.diagCommand.StringVar(&f.Password, "password", f.lookupEnvOrString("AMT_PASSWORD", ""), "AMT password")
+	f.diagCommand.BoolVar(&f.Verbose, "v", false, "verbose output")
+	f.setupDeviceFlags(f.diagCommand)
+
+	f.diagCommand.Parse(f.commandLineArgs[2:])
+	heci.Device = f.MEIDevice
+	f.Command = "diag"
+	return true
+}
 
-	amtInfoCommand.Parse(f.commandLineArgs[2:])
+// setupLocalFlags registers the flags of commands that manage AMT over WS-Management through LMS
+func (f *Flags) setupLocalFlags(fs *flag.FlagSet) {
+	fs.StringVar(&f.Password, "password", f.lookupEnvOrString("AMT_PASSWORD", ""), "AMT password")
+	fs.BoolVar(&f.Verbose, "v", false, "verbose output")
+	fs.BoolVar(&f.JsonOutput, "json", false, "json output")
+	f.setupDeviceFlags(fs)
+}
 
-	defaultFlagCount := 2
-	if f.JsonOutput {
-		defaultFlagCount = defaultFlagCount + 1
-	}
-	if len(f.commandLineArgs) == defaultFlagCount {
-
-		*amtInfoVerPtr = true
-		*amtInfoBldPtr = true
-		*amtInfoSkuPtr = true
-		*amtInfoUUIDPtr = true
-		*amtInfoModePtr = true
-		*amtInfoDNSPtr = true
-		*amtInfoCertPtr = false
-		*amtInfoRasPtr = true
-		*amtInfoLanPtr = true
-		*amtInfoHostnamePtr = true
-	}
-	dataStruct := make(map[string]interface{})
-
-	if amtInfoCommand.Parsed() {
-		amt := amt.NewAMTCommand()
-		if *amtInfoVerPtr {
-			result, _ := amt.GetVersionDataFromME("AMT")
-			dataStruct["amt"] = result
-			if !f.JsonOutput {
-				println("Version			: " + result)
-			}
+func (f *Flags) handlePowerCommand() bool {
+	f.setupLocalFlags(f.powerCommand)
+	actions := "status, on, off, cycle, reset or sleep"
+	if len(f.commandLineArgs) == 2 {
+		fmt.Println("power action is required: " + actions)
+		f.powerCommand.PrintDefaults()
+		return false
+	}
+	f.SubCommand = f.commandLineArgs[2]
+	if _, ok := local.PowerActions[f.SubCommand]; !ok && f.SubCommand != "status" {
+		fmt.Println("unknown power action " + f.SubCommand + ", expected " + actions)
+		return false
+	}
+	f.powerCommand.Parse(f.commandLineArgs[3:])
+	heci.Device = f.MEIDevice
+	if !f.readPassword() {
+		return false
+	}
+	f.Command = "power " + f.SubCommand
+	return true
+}
+func (f *Flags) handleFeaturesCommand() bool {
+	f.setupLocalFlags(f.featuresCommand)
+	kvm := f.featuresCommand.Bool("kvm", false, "enable KVM, use -kvm=false to disable it")
+	sol := f.featuresCommand.Bool("sol", false, "enable serial over LAN, use -sol=false to disable it")
+	ider := f.featuresCommand.Bool("ider", false, "enable IDE redirection, use -ider=false to disable it")
+	f.featuresCommand.StringVar(&f.Features.UserConsent, "userconsent", "", "user consent required for redirection: none, kvm or all")
+	if len(f.commandLineArgs) == 2 || (f.commandLineArgs[2] != "get" && f.commandLineArgs[2] != "set") {
+		fmt.Println("features action is required: get or set")
+		f.featuresCommand.PrintDefaults()
+		return false
+	}
+	f.SubCommand = f.commandLineArgs[2]
+	f.featuresCommand.Parse(f.commandLineArgs[3:])
+	heci.Device = f.MEIDevice
+	// only the features given on the command line are changed
+	f.featuresCommand.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "kvm":
+			f.Features.KVM = kvm
+		case "sol":
+			f.Features.SOL = sol
+		case "ider":
+			f.Features.IDER = ider
 		}
-		if *amtInfoBldPtr {
-			result, _ := amt.GetVersionDataFromME("Build Number")
-			dataStruct["buildNumber"] = result
-
-			if !f.JsonOutput {
-				println("Build Number		: " + result)
-			}
+	})
+	if f.SubCommand == "set" {
+		if f.Features.KVM == nil && f.Features.SOL == nil && f.Features.IDER == nil && f.Features.UserConsent == "" {
+			fmt.Println("at least one of -kvm, -sol, -ider or -userconsent is required")
+			return false
 		}
-		if *amtInfoSkuPtr {
-			result, _ := amt.GetVersionDataFromME("Sku")
-			dataStruct["sku"] = result
-
-			if !f.JsonOutput {
-				println("SKU			: " + result)
-			}
+		if _, ok := local.UserConsentPolicies[f.Features.UserConsent]; !ok && f.Features.UserConsent != "" {
+			fmt.Println("-userconsent must be none, kvm or all")
+			return false
 		}
-		if *amtInfoUUIDPtr {
-			result, _ := amt.GetUUID()
-			dataStruct["uuid"] = result
+	}
+	if !f.readPassword() {
+		return false
+	}
+	f.Command = "features " + f.SubCommand
+	return true
+}
 
-			if !f.JsonOutput {
-				println("UUID			: " + result)
-			}
+func (f *Flags) handleLogCommand(fs *flag.FlagSet) bool {
+	f.setupLocalFlags(fs)
+	fs.BoolVar(&f.CSVOutput, "csv", false, "csv output")
+	since := fs.String("since", "", "only show records logged at or after a time (2021-06-01T08:00:00Z), a date (2021-06-01) or a duration ago (24h)")
+	fs.Parse(f.commandLineArgs[2:])
+	heci.Device = f.MEIDevice
+	if f.CSVOutput && f.JsonOutput {
+		fmt.Println("-csv and -json cannot be used together")
+		return false
+	}
+	if *since != "" {
+		var err error
+		f.Since, err = local.ParseSince(*since, time.Now())
+		if err != nil {
+			fmt.Println(err.Error())
+			return false
 		}
-		if *amtInfoModePtr {
-			result, _ := amt.GetControlMode()
-			dataStruct["controlMode"] = string(utils.InterpretControlMode(result))
+	}
+	if !f.readPassword() {
+		return false
+	}
+	f.Command = fs.Name()
+	return true
+}
 
-			if !f.JsonOutput {
-				println("Control Mode		: " + string(utils.InterpretControlMode(result)))
-			}
-		}
-		if *amtInfoDNSPtr {
-			result, _ := amt.GetDNSSuffix()
-			dataStruct["dnsSuffix"] = result
+// compareOSSupported reports whether the OS hardware inventory can be read, replaced in tests
+var compareOSSupported = runtime.GOOS == "linux"
 
-			if !f.JsonOutput {
-				println("DNS Suffix		: " + string(result))
-			}
-			result, _ = amt.GetOSDNSSuffix()
-			dataStruct["dnsSuffixOS"] = result
+func (f *Flags) handleInventoryCommand() bool {
+	f.setupLocalFlags(f.inventoryCommand)
+	f.inventoryCommand.BoolVar(&f.CompareOS, "diff", false, "compare the AMT inventory with the SMBIOS and disks seen by the OS, Linux only")
+	f.inventoryCommand.Parse(f.commandLineArgs[2:])
+	heci.Device = f.MEIDevice
+	if f.CompareOS && !compareOSSupported {
+		fmt.Println("-diff is only supported on Linux, where the OS inventory is read from sysfs")
+		return false
+	}
+	if !f.readPassword() {
+		return false
+	}
+	f.Command = "inventory"
+	return true
+}
 
-			if !f.JsonOutput {
-				fmt.Println("DNS Suffix (OS)		: " + result)
-			}
+func (f *Flags) handleBootCommand() bool {
+	f.setupLocalFlags(f.bootCommand)
+	f.bootCommand.StringVar(&f.BootDevice, "next", "", "device to boot from once: pxe, hdd, cd or bios-setup")
+	f.bootCommand.BoolVar(&f.BootReset, "reset", false, "reset the host, or power it on when it is off, to boot from the device now")
+	if len(f.commandLineArgs) == 2 || (f.commandLineArgs[2] != "show" && f.commandLineArgs[2] != "set") {
+		fmt.Println("boot action is required: show or set")
+		f.bootCommand.PrintDefaults()
+		return false
+	}
+	f.SubCommand = f.commandLineArgs[2]
+	f.bootCommand.Parse(f.commandLineArgs[3:])
+	heci.Device = f.MEIDevice
+	if f.SubCommand == "set" {
+		if _, ok := local.BootDevices[f.BootDevice]; !ok {
+			fmt.Println("-next must be pxe, hdd, cd or bios-setup")
+			return false
 		}
-		if *amtInfoHostnamePtr {
-			result, _ := os.Hostname()
-			dataStruct["hostnameOS"] = result
-			if !f.JsonOutput {
+	}
+	if !f.readPassword() {
+		return false
+	}
+	f.Command = "boot " + f.SubCommand
+	return true
+}
 
-				println("Hostname (OS)		: " + string(result))
+func (f *Flags) handleUsersCommand() bool {
+	f.setupLocalFlags(f.usersCommand)
+	f.usersCommand.StringVar(&f.User.Username, "username", "", "digest username to add, remove or update")
+	f.usersCommand.StringVar(&f.User.Password, "userpassword", f.lookupEnvOrString("AMT_USER_PASSWORD", ""), "password of the user to add or update")
+	f.usersCommand.StringVar(&f.User.Access, "access", "", "where the user can connect from: local, network or any")
+	realms := f.usersCommand.String("realms", "", "comma separated realms granted to the user, for example hardware-asset,event-log-reader")
+	f.usersCommand.StringVar(&f.UserConfig, "config", "", "YAML file listing the users to add or update")
+	f.usersCommand.BoolVar(&f.PruneUsers, "prune", false, "with -config, remove the users that are not listed")
+	actions := map[string]bool{"list": true, "add": true, "remove": true, "update": true}
+	if len(f.commandLineArgs) == 2 || !actions[f.commandLineArgs[2]] {
+		fmt.Println("users action is required: list, add, remove or update")
+		f.usersCommand.PrintDefaults()
+		return false
+	}
+	f.SubCommand = f.commandLineArgs[2]
+	f.usersCommand.Parse(f.commandLineArgs[3:])
+	heci.Device = f.MEIDevice
+	if *realms != "" {
+		for _, realm := range strings.Split(*realms, ",") {
+			realm = strings.TrimSpace(realm)
+			if _, err := local.ParseRealm(realm); err != nil {
+				fmt.Println(err.Error())
+				return false
 			}
+			f.User.Realms = append(f.User.Realms, realm)
 		}
+	}
+	if _, ok := local.UserAccess[f.User.Access]; !ok && f.User.Access != "" {
+		fmt.Println("-access must be local, network or any")
+		return false
+	}
+	if f.UserConfig != "" && f.SubCommand != "update" {
+		fmt.Println("-config is only used with users update")
+		return false
+	}
+	if f.PruneUsers && f.UserConfig == "" {
+		fmt.Println("-prune requires -config")
+		return false
+	}
+	if f.SubCommand != "list" && f.UserConfig == "" && f.User.Username == "" {
+		fmt.Println("-username is required")
+		return false
+	}
+	if f.SubCommand == "add" && (f.User.Access == "" || len(f.User.Realms) == 0) {
+		fmt.Println("-access and -realms are required to add a user")
+		return false
+	}
+	if f.SubCommand == "update" && f.UserConfig == "" && f.User.Password == "" && f.User.Access == "" && len(f.User.Realms) == 0 {
+		fmt.Println("at least one of -userpassword, -access or -realms is required")
+		return false
+	}
+	if !f.readPassword() {
+		return false
+	}
+	if f.SubCommand == "add" && f.User.Password == "" {
+		fmt.Println("Please enter the password for " + f.User.Username + ": ")
+		_, err := fmt.Scanln(&f.User.Password)
+		if f.User.Password == "" || err != nil {
+			return false
+		}
+	}
+	f.Command = "users " + f.SubCommand
+	return true
+}
 
-		if *amtInfoRasPtr {
-			result, _ := amt.GetRemoteAccessConnectionStatus()
-			dataStruct["ras"] = result
-
-			if !f.JsonOutput {
-				println("RAS Network      	: " + result.NetworkStatus)
-				println("RAS Remote Status	: " + result.RemoteStatus)
-				println("RAS Trigger      	: " + result.RemoteTrigger)
-				println("RAS MPS Hostname 	: " + result.MPSHostname)
-			}
+func (f *Flags) handleNetworkCommand() bool {
+	f.setupLocalFlags(f.networkCommand)
+	dhcp := f.networkCommand.Bool("dhcp", false, "wired: get the IP address from DHCP")
+	static := f.networkCommand.Bool("static", false, "wired: use a static IP address")
+	f.networkCommand.BoolVar(&f.Wired.Shared, "shared", false, "wired: share the static IP address of the OS instead of a dedicated one")
+	f.networkCommand.StringVar(&f.Wired.IPAddress, "ip", "", "wired: dedicated static IP address")
+	f.networkCommand.StringVar(&f.Wired.SubnetMask, "subnetmask", "", "wired: subnet mask of the dedicated static IP address")
+	f.networkCommand.StringVar(&f.Wired.DefaultGateway, "gateway", "", "wired: default gateway of the dedicated static IP address")
+	f.networkCommand.StringVar(&f.Wired.PrimaryDNS, "primarydns", "", "wired: primary DNS server of the dedicated static IP address")
+	f.networkCommand.StringVar(&f.Wired.SecondaryDNS, "secondarydns", "", "wired: secondary DNS server of the dedicated static IP address")
+	enabled := f.networkCommand.Bool("enabled", false, "wireless: let AMT use the wireless port, use -enabled=false to disable it")
+	sync := f.networkCommand.Bool("sync", false, "wireless: synchronize the wireless profiles of the OS, use -sync=false to disable it")
+	f.networkCommand.StringVar(&f.WiFiProfile.Name, "name", "", "name of the wireless profile to add or remove, letters and digits only")
+	f.networkCommand.StringVar(&f.WiFiProfile.SSID, "ssid", "", "SSID of the wireless profile")
+	f.networkCommand.IntVar(&f.WiFiProfile.Priority, "priority", 0, "priority of the wireless profile, unique among the profiles")
+	f.networkCommand.StringVar(&f.WiFiProfile.Authentication, "auth", "", "authentication of the wireless profile: wpa2-psk or wpa2-ieee8021x")
+	f.networkCommand.StringVar(&f.WiFiProfile.PassPhrase, "passphrase", f.lookupEnvOrString("WIFI_PASSPHRASE", ""), "wpa2-psk passphrase")
+	f.networkCommand.StringVar(&f.WiFiProfile.EAPMethod, "eap", "", "wpa2-ieee8021x method: peap-mschapv2 or eap-ttls-mschapv2")
+	f.networkCommand.StringVar(&f.WiFiProfile.Username, "eapuser", "", "wpa2-ieee8021x username")
+	f.networkCommand.StringVar(&f.WiFiProfile.Password, "eappassword", f.lookupEnvOrString("EAP_PASSWORD", ""), "wpa2-ieee8021x password")
+	f.networkCommand.StringVar(&f.CACertFile, "cacert", "", "wpa2-ieee8021x PEM file of the CA certificate of the authentication server")
+	actions := map[string]bool{"show": true, "wired": true, "wireless": true, "addprofile": true, "removeprofile": true}
+	if len(f.commandLineArgs) == 2 || !actions[f.commandLineArgs[2]] {
+		fmt.Println("network action is required: show, wired, wireless, addprofile or removeprofile")
+		f.networkCommand.PrintDefaults()
+		return false
+	}
+	f.SubCommand = f.commandLineArgs[2]
+	f.networkCommand.Parse(f.commandLineArgs[3:])
+	heci.Device = f.MEIDevice
+	// only the wireless options given on the command line are changed
+	f.networkCommand.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "enabled":
+			f.Wireless.Enabled = enabled
+		case "sync":
+			f.Wireless.SyncOS = sync
 		}
-		if *amtInfoLanPtr {
-			wired, _ := amt.GetLANInterfaceSettings(false)
-			dataStruct["wiredAdapter"] = wired
-
-			if !f.JsonOutput {
-				println("---Wired Adapter---")
-				println("DHCP Enabled 		: " + strconv.FormatBool(wired.DHCPEnabled))
-				println("DHCP Mode    		: " + wired.DHCPMode)
-				println("Link Status  		: " + wired.LinkStatus)
-				println("IP Address   		: " + wired.IPAddress)
-				println("MAC Address  		: " + wired.MACAddress)
-			}
+	})
+	switch f.SubCommand {
+	case "wired":
+		if *dhcp == *static {
+			fmt.Println("one of -dhcp or -static is required")
+			return false
+		}
+		if *dhcp && f.Wired.Shared {
+			fmt.Println("-shared is only used with -static")
+			return false
+		}
+		f.Wired.DHCP = *dhcp
+	case "wireless":
+		if f.Wireless.Enabled == nil && f.Wireless.SyncOS == nil {
+			fmt.Println("at least one of -enabled or -sync is required")
+			return false
+		}
+	case "addprofile":
+		if f.WiFiProfile.Name == "" || f.WiFiProfile.SSID == "" || f.WiFiProfile.Priority == 0 {
+			fmt.Println("-name, -ssid and -priority are required")
+			return false
+		}
+		if _, ok := local.WiFiAuthentication[f.WiFiProfile.Authentication]; !ok {
+			fmt.Println("-auth must be wpa2-psk or wpa2-ieee8021x")
+			return false
+		}
+	case "removeprofile":
+		if f.WiFiProfile.Name == "" {
+			fmt.Println("-name is required")
+			return false
+		}
+	}
+	if !f.readPassword() {
+		return false
+	}
+	f.Command = "network " + f.SubCommand
+	return true
+}
 
-			wireless, _ := amt.GetLANInterfaceSettings(true)
-			dataStruct["wirelessAdapter"] = wireless
+func (f *Flags) handleAMTInfo(amtInfoCommand *flag.FlagSet) {
+	fields := amt.InfoFields{}
+	amtInfoCommand.BoolVar(&fields.Version, "ver", false, "BIOS Version")
+	amtInfoCommand.BoolVar(&fields.BuildNumber, "bld", false, "Build Number")
+	amtInfoCommand.BoolVar(&fields.SKU, "sku", false, "Product SKU")
+	amtInfoCommand.BoolVar(&fields.UUID, "uuid", false, "Unique Identifier")
+	amtInfoCommand.BoolVar(&fields.ControlMode, "mode", false, "Current Control Mode")
+	amtInfoCommand.BoolVar(&fields.DNSSuffix, "dns", false, "Domain Name Suffix")
+	amtInfoCommand.BoolVar(&fields.CertificateHashes, "cert", false, "Certificate Hashes")
+	amtInfoCommand.BoolVar(&fields.RAS, "ras", false, "Remote Access Status")
+	amtInfoCommand.BoolVar(&fields.LAN, "lan", false, "LAN Settings")
+	amtInfoCommand.BoolVar(&fields.Hostname, "hostname", false, "OS Hostname")
+	amtInfoCommand.BoolVar(&fields.FirmwareStatus, "fwsts", false, "ME Firmware Status")
+	amtInfoCommand.BoolVar(&fields.CodeVersions, "versions", false, "All Code Versions")
 
-			if !f.JsonOutput {
-				println("---Wireless Adapter---")
-				println("DHCP Enabled 		: " + strconv.FormatBool(wireless.DHCPEnabled))
-				println("DHCP Mode    		: " + wireless.DHCPMode)
-				println("Link Status  		: " + wireless.LinkStatus)
-				println("IP Address   		: " + wireless.IPAddress)
-				println("MAC Address  		: " + wireless.MACAddress)
-			}
-		}
-		if *amtInfoCertPtr {
-			result, _ := amt.GetCertificateHashes()
-			certs := make(map[string]interface{})
-			for _, v := range result {
-				certs[v.Name] = v
-			}
-			dataStruct["certificateHashes"] = certs
-			if !f.JsonOutput {
-				println("Certificate Hashes	:")
-				for _, v := range result {
-					print(v.Name + " (")
-					if v.IsDefault {
-						print("Default,")
-					}
-					if v.IsActive {
-						print("Active)")
-					}
-					println()
-					println("   " + v.Algorithm + ": " + v.Hash)
-				}
-			}
+	amtInfoCommand.Parse(f.commandLineArgs[2:])
+	heci.Device = f.MEIDevice
+
+	// display everything when no specific fields were requested
+	selected := 0
+	amtInfoCommand.Visit(func(fl *flag.Flag) {
+		if fl.Name != "json" && fl.Name != "mei-device" {
+			selected++
 		}
-		if f.JsonOutput {
+	})
+	if selected == 0 {
+		fields = amt.DefaultInfoFields
+	}
+
+	dataStruct, err := amt.NewAMTCommand().GetInfo(fields)
+	if f.JsonOutput {
+		if err == nil || len(dataStruct) > 0 {
 			outBytes, err := json.MarshalIndent(dataStruct, "", "  ")
 			output := string(outBytes)
 			if err != nil {
@@ -383,9 +723,152 @@ func (f *Flags) handleAMTInfo(amtInfoCommand *flag.FlagSet) {
 			}
 			println(output)
 		}
+	} else {
+		printAMTInfo(dataStruct)
+	}
+	if err != nil {
+		f.ExitCode = PrintAccessError(err)
 	}
 }
 
+// printAMTInfo displays the sections of amt.GetInfo that are present in data
+func printAMTInfo(data map[string]interface{}) {
+	if status, ok := data["meStatus"].(mefw.Status); ok {
+		println("ME Status		: " + status.String())
+	}
+	if result, ok := data["amt"].(string); ok {
+		println("Version			: " + result)
+	}
+	if result, ok := data["buildNumber"].(string); ok {
+		println("Build Number		: " + result)
+	}
+	if result, ok := data["sku"].(string); ok {
+		if features, ok := data["skuFeatures"].([]string); ok {
+			result = result + " (" + strings.Join(features, ", ") + ")"
+		}
+		println("SKU			: " + result)
+	}
+	if table, ok := data["codeVersions"].(amt.CodeVersionTable); ok {
+		println("Code Versions		:")
+		println("   BIOS: " + table.BiosVersion)
+		for _, entry := range table.Versions {
+			println("   " + entry.Description + ": " + entry.Version)
+		}
+		if capabilities, ok := data["capabilities"].([]amt.Capability); ok {
+			println("Capabilities		:")
+			for _, capability := range capabilities {
+				println("   " + string(capability))
+			}
+		}
+	}
+	if result, ok := data["uuid"].(string); ok {
+		println("UUID			: " + result)
+		if info, ok := data["smbios"].(smbios.Info); ok {
+			println("UUID (SMBIOS)		: " + info.UUID)
+			println("UUID Check		: " + string(data["uuidCheck"].(smbios.UUIDCheck)))
+		}
+	}
+	if result, ok := data["controlMode"].(string); ok {
+		println("Control Mode		: " + result)
+	}
+	if result, ok := data["dnsSuffix"].(string); ok {
+		println("DNS Suffix		: " + result)
+		suffix := data["dnsSuffixOS"].(string)
+		if suffix != "" {
+			println("DNS Suffix (OS)		: " + suffix + " (" + string(data["dnsSuffixOSSource"].(dnssuffix.Source)) + ")")
+		} else {
+			println("DNS Suffix (OS)		: ")
+		}
+		for _, candidate := range data["dnsSuffixOSCandidates"].([]dnssuffix.Candidate) {
+			println("  Candidate		: " + candidate.Suffix + " (" + string(candidate.Source) + ")")
+		}
+	}
+	if result, ok := data["hostnameOS"].(string); ok {
+		println("Hostname (OS)		: " + result)
+	}
+	if result, ok := data["ras"].(amt.RemoteAccessStatus); ok {
+		println("RAS Network      	: " + result.NetworkStatus)
+		println("RAS Remote Status	: " + result.RemoteStatus)
+		println("RAS Trigger      	: " + result.RemoteTrigger)
+		println("RAS MPS Hostname 	: " + result.MPSHostname)
+	}
+	if wired, ok := data["wiredAdapter"].(amt.InterfaceSettings); ok {
+		println("---Wired Adapter---")
+		printInterfaceSettings(wired)
+	}
+	if wireless, ok := data["wirelessAdapter"].(amt.InterfaceSettings); ok {
+		println("---Wireless Adapter---")
+		printInterfaceSettings(wireless)
+	}
+	if certs, ok := data["certificateHashes"].(map[string]amt.CertHashEntry); ok {
+		names := []string{}
+		for name := range certs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		println("Certificate Hashes	:")
+		for _, name := range names {
+			v := certs[name]
+			print(v.Name + " (")
+			if v.IsDefault {
+				print("Default,")
+			}
+			if v.IsActive {
+				print("Active)")
+			}
+			println()
+			println("   " + v.Algorithm + ": " + v.Hash)
+		}
+	}
+}
+
+func printInterfaceSettings(settings amt.InterfaceSettings) {
+	println("DHCP Enabled 		: " + strconv.FormatBool(settings.DHCPEnabled))
+	println("DHCP Mode    		: " + settings.DHCPMode)
+	println("Link Status  		: " + settings.LinkStatus)
+	println("IP Address   		: " + settings.IPAddress)
+	println("MAC Address  		: " + settings.MACAddress)
+	printIPv6Settings(settings.IPv6)
+}
+
+func (f *Flags) handleMEInfoCommand() {
+	f.meInfoCommand.BoolVar(&f.JsonOutput, "json", false, "json output")
+	f.setupDeviceFlags(f.meInfoCommand)
+
+	f.meInfoCommand.Parse(f.commandLineArgs[2:])
+	heci.Device = f.MEIDevice
+
+	info, err := amt.NewAMTCommand().GetFirmwareInfo()
+	if info.Status != nil && !f.JsonOutput {
+		println("ME Status		: " + info.Status.String())
+		println("FW Status Registers	: " + strings.Join(info.Status.Registers, " "))
+	}
+	accessError := &amt.AccessError{}
+	if errors.As(err, &accessError) {
+		f.ExitCode = PrintAccessError(err)
+		return
+	}
+	if err != nil {
+		println("Unable to read firmware information: " + err.Error())
+		f.ExitCode = utils.GenericFailure
+		return
+	}
+	if f.JsonOutput {
+		outBytes, err := json.MarshalIndent(info, "", "  ")
+		output := string(outBytes)
+		if err != nil {
+			output = err.Error()
+		}
+		println(output)
+		return
+	}
+	println("Firmware Version	: " + info.Version)
+	println("Recovery Version	: " + info.RecoveryVersion)
+	println("FITC Version		: " + info.FITCVersion)
+	println("Capabilities		: " + strings.Join(info.Capabilities, ", "))
+	println("Enabled Features	: " + strings.Join(info.EnabledFeatures, ", "))
+}
+
 func (f *Flags) handleVersionCommand() bool {
 
 	f.versionCommand.Parse(f.commandLineArgs[2:])
@@ -418,3 +901,19 @@ func (f *Flags) handleVersionCommand() bool {
 
 	return true
 }
+
+func printIPv6Settings(settings amt.IPv6Settings) {
+	println("IPv6 Enabled 		: " + strconv.FormatBool(settings.IsEnabled))
+	if !settings.IsEnabled {
+		return
+	}
+	for _, address := range settings.Addresses {
+		println("IPv6 Address 		: " + address.Address + " (" + address.Scope + ", " + address.Origin + ", " + address.State + ")")
+	}
+	if settings.DefaultRouter != "" {
+		println("IPv6 Router  		: " + settings.DefaultRouter)
+	}
+	for _, server := range settings.DNSServers {
+		println("IPv6 DNS     		: " + server)
+	}
+}
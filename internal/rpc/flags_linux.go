@@ -0,0 +1,15 @@
+//go:build linux
+// +build linux
+
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package rpc
+
+import "flag"
+
+// setupDeviceFlags registers the flags that select the MEI device of commands that talk to the firmware
+func (f *Flags) setupDeviceFlags(fs *flag.FlagSet) {
+	fs.StringVar(&f.MEIDevice, "mei-device", f.lookupEnvOrString("MEI_DEVICE", ""), "MEI device node to use instead of discovering it, for example /dev/mei1")
+}
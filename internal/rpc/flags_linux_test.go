@@ -0,0 +1,26 @@
+//go:build linux
+// +build linux
+
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package rpc
+
+import (
+	"rpc/pkg/heci"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFlagsCheckMEIDevice(t *testing.T) {
+	args := []string{"./rpc", "check", "--mei-device", "/dev/mei1"}
+	flags := NewFlags(args)
+	defer func() { heci.Device = "" }()
+	command, result := flags.ParseFlags()
+	assert.True(t, result)
+	assert.Equal(t, "check", command)
+	assert.Equal(t, "/dev/mei1", flags.MEIDevice)
+	assert.Equal(t, "/dev/mei1", heci.Device)
+}
@@ -6,7 +6,11 @@ package rpc
 
 import (
 	"os"
+	"rpc/internal/local"
+	"rpc/pkg/utils"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -31,6 +35,30 @@ func TestPrintUsage(t *testing.T) {
 	usage = usage + "              Example: ./rpc maintenance -u wss://server/activate\n"
 	usage = usage + "  amtinfo     Displays information about AMT status and configuration\n"
 	usage = usage + "              Example: ./rpc amtinfo\n"
+	usage = usage + "  meinfo      Displays the ME firmware version and capabilities, including on non-AMT SKUs\n"
+	usage = usage + "              Example: ./rpc meinfo\n"
+	usage = usage + "  check       Verifies the prerequisites for activating this device\n"
+	usage = usage + "              Example: ./rpc check -u wss://server/activate\n"
+	usage = usage + "  diag        Collects diagnostic information into a tar.gz bundle for support\n"
+	usage = usage + "              Example: ./rpc diag -o diag.tar.gz\n"
+	usage = usage + "  power       Displays or changes the host power state through AMT. AMT password is required\n"
+	usage = usage + "              Example: ./rpc power reset\n"
+	usage = usage + "  features    Displays or changes KVM, SOL, IDE-R and user consent. AMT password is required\n"
+	usage = usage + "              Example: ./rpc features set -kvm -userconsent none\n"
+	usage = usage + "  eventlog    Displays the AMT event log. AMT password is required\n"
+	usage = usage + "              Example: ./rpc eventlog -since 24h\n"
+	usage = usage + "  auditlog    Displays the AMT security audit log. AMT password is required\n"
+	usage = usage + "              Example: ./rpc auditlog -csv\n"
+	usage = usage + "  inventory   Displays the hardware inventory reported by AMT. AMT password is required\n"
+	usage = usage + "              Example: ./rpc inventory -diff\n"
+	usage = usage + "  boot        Displays the boot options or forces the device used for the next boot. AMT password is required\n"
+	usage = usage + "              Example: ./rpc boot set -next pxe -reset\n"
+	usage = usage + "  users       Lists, adds, removes or updates AMT digest users, or applies a YAML user file. AMT password is required\n"
+	usage = usage + "              Example: ./rpc users add -username noc -access network -realms hardware-asset,event-log-reader\n"
+	usage = usage + "              Example: ./rpc users update -config users.yaml\n"
+	usage = usage + "  network     Displays or configures the AMT wired and wireless network settings. AMT password is required\n"
+	usage = usage + "              Example: ./rpc network wired -static -ip 192.168.1.50 -subnetmask 255.255.255.0 -gateway 192.168.1.1\n"
+	usage = usage + "              Example: ./rpc network addprofile -name office -ssid Office -priority 1 -auth wpa2-psk -passphrase <passphrase>\n"
 	usage = usage + "  version     Displays the current version of RPC and the RPC Protocol version\n"
 	usage = usage + "              Example: ./rpc version\n"
 	usage = usage + "\nRun 'rpc COMMAND' for more information on a command.\n"
@@ -203,6 +231,24 @@ func TestParseFlagsActivate(t *testing.T) {
 	assert.False(t, result)
 	assert.Equal(t, "activate", command)
 }
+func TestParseFlagsCheck(t *testing.T) {
+	args := []string{"./rpc", "check", "-u", "wss://localhost", "-json"}
+	flags := NewFlags(args)
+	command, result := flags.ParseFlags()
+	assert.True(t, result)
+	assert.Equal(t, "check", command)
+	assert.Equal(t, "wss://localhost", flags.URL)
+	assert.Equal(t, true, flags.JsonOutput)
+}
+func TestParseFlagsDiag(t *testing.T) {
+	args := []string{"./rpc", "diag", "-o", "bundle.tar.gz", "-password", "password"}
+	flags := NewFlags(args)
+	command, result := flags.ParseFlags()
+	assert.True(t, result)
+	assert.Equal(t, "diag", command)
+	assert.Equal(t, "bundle.tar.gz", flags.OutputFile)
+	assert.Equal(t, "password", flags.Password)
+}
 func TestParseFlagsVersion(t *testing.T) {
 	args := []string{"./rpc", "version"}
 	flags := NewFlags(args)
@@ -210,6 +256,7 @@ func TestParseFlagsVersion(t *testing.T) {
 	assert.False(t, result)
 	assert.Equal(t, "version", command)
 	assert.Equal(t, false, flags.JsonOutput)
+	assert.Equal(t, utils.Success, flags.ExitCode)
 }
 
 func TestParseFlagsVersionJSON(t *testing.T) {
@@ -227,6 +274,7 @@ func TestParseFlagsNone(t *testing.T) {
 	command, result := flags.ParseFlags()
 	assert.False(t, result)
 	assert.Equal(t, "", command)
+	assert.Equal(t, utils.GenericFailure, flags.ExitCode)
 }
 
 func TestParseFlagsEmptyCommand(t *testing.T) {
@@ -272,3 +320,289 @@ func TestLookupEnvOrBool_EnvError(t *testing.T) {
 	result := flags.lookupEnvOrBool("SKIP_CERT_CHECK", false)
 	assert.Equal(t, false, result)
 }
+
+func TestHandleActivateCommandDryRunNoURL(t *testing.T) {
+	args := []string{"./rpc", "activate", "-profile", "profileName", "--dry-run"}
+	flags := NewFlags(args)
+	success := flags.handleActivateCommand()
+	assert.True(t, success)
+	assert.True(t, flags.DryRun)
+	assert.Equal(t, "activate --profile profileName", flags.Command)
+}
+
+func TestHandleActivateCommandInventory(t *testing.T) {
+	args := []string{"./rpc", "activate", "-u", "wss://localhost", "-profile", "profileName", "--inventory"}
+	flags := NewFlags(args)
+	success := flags.handleActivateCommand()
+	assert.True(t, success)
+	assert.True(t, flags.Inventory)
+}
+
+func TestHandleActivateCommandTags(t *testing.T) {
+	args := []string{"./rpc", "activate", "-u", "wss://localhost", "-profile", "profileName", "--tag", "site=berlin", "--tag", "ring=1", "--tag", "site=munich"}
+	flags := NewFlags(args)
+	success := flags.handleActivateCommand()
+	assert.True(t, success)
+	assert.Equal(t, Tags{"site": "munich", "ring": "1"}, flags.Tags)
+	assert.Equal(t, "ring=1,site=munich", flags.Tags.String())
+}
+
+func TestHandleActivateCommandTagsEnv(t *testing.T) {
+	os.Setenv("TAGS", "site=berlin, costCenter=42")
+	defer os.Unsetenv("TAGS")
+	args := []string{"./rpc", "activate", "-u", "wss://localhost", "-profile", "profileName", "--tag", "site=munich"}
+	flags := NewFlags(args)
+	success := flags.handleActivateCommand()
+	assert.True(t, success)
+	assert.Equal(t, Tags{"site": "munich", "costCenter": "42"}, flags.Tags)
+}
+
+func TestHandleActivateCommandTagsEnvInvalid(t *testing.T) {
+	os.Setenv("TAGS", "site")
+	defer os.Unsetenv("TAGS")
+	args := []string{"./rpc", "activate", "-u", "wss://localhost", "-profile", "profileName"}
+	flags := NewFlags(args)
+	success := flags.handleActivateCommand()
+	assert.False(t, success)
+}
+
+func TestTagsSet(t *testing.T) {
+	tags := Tags{}
+	assert.NoError(t, tags.Set("cost-center=a=b"))
+	assert.Equal(t, "a=b", tags["cost-center"])
+	assert.NoError(t, tags.Set("empty="))
+	assert.Error(t, tags.Set("novalue"))
+	assert.Error(t, tags.Set("=value"))
+	assert.Error(t, tags.Set("bad key=value"))
+	assert.Error(t, tags.Set("key="+strings.Repeat("a", 257)))
+}
+
+func TestParseFlagsPower(t *testing.T) {
+	args := []string{"./rpc", "power", "reset", "-password", "P@ssw0rd", "-json"}
+	flags := NewFlags(args)
+	result, success := flags.ParseFlags()
+	assert.True(t, success)
+	assert.Equal(t, "power", result)
+	assert.Equal(t, "reset", flags.SubCommand)
+	assert.Equal(t, "P@ssw0rd", flags.Password)
+	assert.Equal(t, "power reset", flags.Command)
+	assert.True(t, flags.JsonOutput)
+}
+
+func TestHandlePowerCommandNoAction(t *testing.T) {
+	args := []string{"./rpc", "power"}
+	flags := NewFlags(args)
+	assert.False(t, flags.handlePowerCommand())
+}
+
+func TestHandlePowerCommandUnknownAction(t *testing.T) {
+	args := []string{"./rpc", "power", "hibernate", "-password", "P@ssw0rd"}
+	flags := NewFlags(args)
+	assert.False(t, flags.handlePowerCommand())
+}
+
+func TestParseFlagsFeaturesSet(t *testing.T) {
+	args := []string{"./rpc", "features", "set", "-kvm", "-sol=false", "-userconsent", "none", "-password", "P@ssw0rd"}
+	flags := NewFlags(args)
+	result, success := flags.ParseFlags()
+	assert.True(t, success)
+	assert.Equal(t, "features", result)
+	assert.Equal(t, "set", flags.SubCommand)
+	assert.True(t, *flags.Features.KVM)
+	assert.False(t, *flags.Features.SOL)
+	assert.Nil(t, flags.Features.IDER)
+	assert.Equal(t, "none", flags.Features.UserConsent)
+}
+
+func TestHandleFeaturesCommandSetNothing(t *testing.T) {
+	args := []string{"./rpc", "features", "set", "-password", "P@ssw0rd"}
+	flags := NewFlags(args)
+	assert.False(t, flags.handleFeaturesCommand())
+}
+
+func TestHandleFeaturesCommandInvalidUserConsent(t *testing.T) {
+	args := []string{"./rpc", "features", "set", "-userconsent", "sometimes", "-password", "P@ssw0rd"}
+	flags := NewFlags(args)
+	assert.False(t, flags.handleFeaturesCommand())
+}
+
+func TestHandleFeaturesCommandNoAction(t *testing.T) {
+	args := []string{"./rpc", "features", "toggle"}
+	flags := NewFlags(args)
+	assert.False(t, flags.handleFeaturesCommand())
+}
+
+func TestParseFlagsEventLog(t *testing.T) {
+	args := []string{"./rpc", "eventlog", "-since", "2021-06-01T08:00:00Z", "-password", "P@ssw0rd"}
+	flags := NewFlags(args)
+	result, success := flags.ParseFlags()
+	assert.True(t, success)
+	assert.Equal(t, "eventlog", result)
+	assert.Equal(t, "eventlog", flags.Command)
+	assert.Equal(t, time.Date(2021, 6, 1, 8, 0, 0, 0, time.UTC), flags.Since)
+}
+
+func TestParseFlagsAuditLogCSV(t *testing.T) {
+	args := []string{"./rpc", "auditlog", "-csv", "-password", "P@ssw0rd"}
+	flags := NewFlags(args)
+	result, success := flags.ParseFlags()
+	assert.True(t, success)
+	assert.Equal(t, "auditlog", result)
+	assert.True(t, flags.CSVOutput)
+	assert.True(t, flags.Since.IsZero())
+}
+
+func TestHandleLogCommandInvalidSince(t *testing.T) {
+	args := []string{"./rpc", "auditlog", "-since", "yesterday", "-password", "P@ssw0rd"}
+	flags := NewFlags(args)
+	assert.False(t, flags.handleLogCommand(flags.auditLogCommand))
+}
+
+func TestHandleLogCommandCSVAndJSON(t *testing.T) {
+	args := []string{"./rpc", "eventlog", "-csv", "-json", "-password", "P@ssw0rd"}
+	flags := NewFlags(args)
+	assert.False(t, flags.handleLogCommand(flags.eventLogCommand))
+}
+
+func TestParseFlagsInventory(t *testing.T) {
+	args := []string{"./rpc", "inventory", "-diff", "-json", "-password", "P@ssw0rd"}
+	flags := NewFlags(args)
+	result, success := flags.ParseFlags()
+	assert.True(t, success)
+	assert.Equal(t, "inventory", result)
+	assert.True(t, flags.CompareOS)
+	assert.True(t, flags.JsonOutput)
+	assert.False(t, flags.Inventory)
+}
+
+func TestParseFlagsInventoryDiffUnsupported(t *testing.T) {
+	defer func(supported bool) { compareOSSupported = supported }(compareOSSupported)
+	compareOSSupported = false
+	args := []string{"./rpc", "inventory", "-diff", "-password", "P@ssw0rd"}
+	flags := NewFlags(args)
+	_, success := flags.ParseFlags()
+	assert.False(t, success)
+	assert.Equal(t, utils.GenericFailure, flags.ExitCode)
+
+	args = []string{"./rpc", "inventory", "-password", "P@ssw0rd"}
+	flags = NewFlags(args)
+	_, success = flags.ParseFlags()
+	assert.True(t, success)
+}
+
+func TestParseFlagsBootSet(t *testing.T) {
+	args := []string{"./rpc", "boot", "set", "--next", "pxe", "-reset", "-password", "P@ssw0rd"}
+	flags := NewFlags(args)
+	result, success := flags.ParseFlags()
+	assert.True(t, success)
+	assert.Equal(t, "boot", result)
+	assert.Equal(t, "set", flags.SubCommand)
+	assert.Equal(t, "pxe", flags.BootDevice)
+	assert.True(t, flags.BootReset)
+}
+
+func TestParseFlagsBootShow(t *testing.T) {
+	args := []string{"./rpc", "boot", "show", "-password", "P@ssw0rd"}
+	flags := NewFlags(args)
+	result, success := flags.ParseFlags()
+	assert.True(t, success)
+	assert.Equal(t, "boot", result)
+	assert.Equal(t, "boot show", flags.Command)
+}
+
+func TestHandleBootCommandInvalidDevice(t *testing.T) {
+	args := []string{"./rpc", "boot", "set", "-next", "floppy", "-password", "P@ssw0rd"}
+	flags := NewFlags(args)
+	assert.False(t, flags.handleBootCommand())
+}
+
+func TestHandleBootCommandNoAction(t *testing.T) {
+	args := []string{"./rpc", "boot"}
+	flags := NewFlags(args)
+	assert.False(t, flags.handleBootCommand())
+}
+
+func TestParseFlagsUsersAdd(t *testing.T) {
+	args := []string{"./rpc", "users", "add", "-username", "noc", "-userpassword", "N0cP@ssw0rd", "-access", "network", "-realms", "hardware-asset, event-log-reader", "-password", "P@ssw0rd"}
+	flags := NewFlags(args)
+	result, success := flags.ParseFlags()
+	assert.True(t, success)
+	assert.Equal(t, "users", result)
+	assert.Equal(t, "add", flags.SubCommand)
+	assert.Equal(t, local.User{Username: "noc", Password: "N0cP@ssw0rd", Access: "network", Realms: []string{"hardware-asset", "event-log-reader"}}, flags.User)
+}
+
+func TestParseFlagsUsersUpdateConfig(t *testing.T) {
+	args := []string{"./rpc", "users", "update", "-config", "users.yaml", "-prune", "-password", "P@ssw0rd"}
+	flags := NewFlags(args)
+	result, success := flags.ParseFlags()
+	assert.True(t, success)
+	assert.Equal(t, "users", result)
+	assert.Equal(t, "users.yaml", flags.UserConfig)
+	assert.True(t, flags.PruneUsers)
+}
+
+func TestHandleUsersCommandInvalid(t *testing.T) {
+	invalid := [][]string{
+		{"./rpc", "users"},
+		{"./rpc", "users", "rename"},
+		{"./rpc", "users", "remove", "-password", "P@ssw0rd"},
+		{"./rpc", "users", "add", "-username", "noc", "-access", "network", "-password", "P@ssw0rd"},
+		{"./rpc", "users", "add", "-username", "noc", "-access", "remote", "-realms", "general-info", "-password", "P@ssw0rd"},
+		{"./rpc", "users", "update", "-username", "noc", "-realms", "everything", "-password", "P@ssw0rd"},
+		{"./rpc", "users", "list", "-config", "users.yaml", "-password", "P@ssw0rd"},
+		{"./rpc", "users", "update", "-username", "noc", "-prune", "-password", "P@ssw0rd"},
+		{"./rpc", "users", "update", "-username", "noc", "-password", "P@ssw0rd"},
+	}
+	for _, args := range invalid {
+		flags := NewFlags(args)
+		assert.False(t, flags.handleUsersCommand(), strings.Join(args, " "))
+	}
+}
+
+func TestParseFlagsNetworkWired(t *testing.T) {
+	args := []string{"./rpc", "network", "wired", "-static", "-ip", "192.168.1.50", "-subnetmask", "255.255.255.0", "-gateway", "192.168.1.1", "-primarydns", "192.168.1.2", "-password", "P@ssw0rd"}
+	flags := NewFlags(args)
+	result, success := flags.ParseFlags()
+	assert.True(t, success)
+	assert.Equal(t, "network", result)
+	assert.Equal(t, "wired", flags.SubCommand)
+	assert.Equal(t, local.WiredConfig{IPAddress: "192.168.1.50", SubnetMask: "255.255.255.0", DefaultGateway: "192.168.1.1", PrimaryDNS: "192.168.1.2"}, flags.Wired)
+}
+
+func TestParseFlagsNetworkWireless(t *testing.T) {
+	args := []string{"./rpc", "network", "wireless", "-sync=false", "-password", "P@ssw0rd"}
+	flags := NewFlags(args)
+	_, success := flags.ParseFlags()
+	assert.True(t, success)
+	assert.Nil(t, flags.Wireless.Enabled)
+	assert.False(t, *flags.Wireless.SyncOS)
+}
+
+func TestParseFlagsNetworkAddProfile(t *testing.T) {
+	args := []string{"./rpc", "network", "addprofile", "-name", "corp", "-ssid", "Corp", "-priority", "2", "-auth", "wpa2-ieee8021x", "-eap", "peap-mschapv2", "-eapuser", "device1", "-eappassword", "Secret1!", "-cacert", "ca.pem", "-password", "P@ssw0rd"}
+	flags := NewFlags(args)
+	_, success := flags.ParseFlags()
+	assert.True(t, success)
+	assert.Equal(t, "network addprofile", flags.Command)
+	assert.Equal(t, local.WiFiProfileConfig{Name: "corp", SSID: "Corp", Priority: 2, Authentication: "wpa2-ieee8021x", EAPMethod: "peap-mschapv2", Username: "device1", Password: "Secret1!"}, flags.WiFiProfile)
+	assert.Equal(t, "ca.pem", flags.CACertFile)
+}
+
+func TestHandleNetworkCommandInvalid(t *testing.T) {
+	invalid := [][]string{
+		{"./rpc", "network"},
+		{"./rpc", "network", "vpn"},
+		{"./rpc", "network", "wired", "-password", "P@ssw0rd"},
+		{"./rpc", "network", "wired", "-dhcp", "-static", "-password", "P@ssw0rd"},
+		{"./rpc", "network", "wired", "-dhcp", "-shared", "-password", "P@ssw0rd"},
+		{"./rpc", "network", "wireless", "-password", "P@ssw0rd"},
+		{"./rpc", "network", "addprofile", "-name", "office", "-ssid", "Office", "-password", "P@ssw0rd"},
+		{"./rpc", "network", "addprofile", "-name", "office", "-ssid", "Office", "-priority", "1", "-auth", "wep", "-password", "P@ssw0rd"},
+		{"./rpc", "network", "removeprofile", "-password", "P@ssw0rd"},
+	}
+	for _, args := range invalid {
+		flags := NewFlags(args)
+		assert.False(t, flags.handleNetworkCommand(), strings.Join(args, " "))
+	}
+}
@@ -0,0 +1,14 @@
+//go:build windows
+// +build windows
+
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package rpc
+
+import "flag"
+
+// setupDeviceFlags has nothing to register on Windows, the HECI driver is located through SetupAPI so there is no
+// device node to select
+func (f *Flags) setupDeviceFlags(fs *flag.FlagSet) {}
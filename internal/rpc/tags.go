@@ -0,0 +1,62 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package rpc
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	maxTagKeyLength   = 64
+	maxTagValueLength = 256
+)
+
+var tagKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Tags are key=value pairs sent to the server at activation, for grouping devices by site, cost center or ring
+type Tags map[string]string
+
+// String returns the tags as a sorted, comma separated list
+func (t Tags) String() string {
+	pairs := []string{}
+	for key, value := range t {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set adds a single key=value tag, replacing an earlier value for the same key
+func (t Tags) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 {
+		return errors.New("tag " + value + " must be in the form key=value")
+	}
+	key := strings.TrimSpace(parts[0])
+	if !tagKeyPattern.MatchString(key) || len(key) > maxTagKeyLength {
+		return errors.New("tag key " + key + " must start with a letter or digit and only contain letters, digits, '.', '_' or '-'")
+	}
+	if len(parts[1]) > maxTagValueLength {
+		return errors.New("value of tag " + key + " is longer than 256 characters")
+	}
+	t[key] = parts[1]
+	return nil
+}
+
+// setList adds comma separated key=value tags, as used by the TAGS environment variable
+func (t Tags) setList(list string) error {
+	for _, value := range strings.Split(list, ",") {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		if err := t.Set(strings.TrimSpace(value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package rps
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"rpc/internal/check"
+	"rpc/internal/rpc"
+	"strings"
+)
+
+// RedactedValue replaces secrets in dry run output
+const RedactedValue = "********"
+
+// DryRunMessage is the redacted, human readable form of an RPSMessage
+type DryRunMessage struct {
+	Method          string         `json:"method"`
+	APIKey          string         `json:"apiKey"`
+	AppVersion      string         `json:"appVersion"`
+	ProtocolVersion string         `json:"protocolVersion"`
+	Status          string         `json:"status"`
+	Message         string         `json:"message"`
+	Payload         MessagePayload `json:"payload"`
+}
+
+// DryRunReport is displayed instead of contacting RPS when --dry-run is specified
+type DryRunReport struct {
+	URL       string         `json:"url"`
+	Request   DryRunMessage  `json:"request"`
+	Preflight []check.Result `json:"preflight"`
+}
+
+// CreateDryRunReport decodes and redacts the message that would be sent to RPS and runs preflight checks against it
+func (p Payload) CreateDryRunReport(flags rpc.Flags, message RPSMessage) (DryRunReport, error) {
+	report := DryRunReport{
+		URL: flags.URL,
+		Request: DryRunMessage{
+			Method:          redactMethod(message.Method),
+			APIKey:          message.APIKey,
+			AppVersion:      message.AppVersion,
+			ProtocolVersion: message.ProtocolVersion,
+			Status:          message.Status,
+			Message:         message.Message,
+		},
+	}
+	data, err := base64.StdEncoding.DecodeString(message.Payload)
+	if err != nil {
+		return report, err
+	}
+	err = json.Unmarshal(data, &report.Request.Payload)
+	if err != nil {
+		return report, err
+	}
+	report.Preflight = p.preflight(flags, report.Request.Payload)
+	if report.Request.Payload.Password != "" {
+		report.Request.Payload.Password = RedactedValue
+	}
+	return report, nil
+}
+
+// Failed reports whether any preflight check failed
+func (r DryRunReport) Failed() bool {
+	for _, result := range r.Preflight {
+		if result.Status == check.Fail {
+			return true
+		}
+	}
+	return false
+}
+
+// redactMethod hides the value following --password in the command sent to RPS
+func redactMethod(method string) string {
+	fields := strings.Fields(method)
+	for i := 0; i < len(fields)-1; i++ {
+		if fields[i] == "--password" {
+			fields[i+1] = RedactedValue
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// preflight runs the prerequisite checks that apply to the command against the payload that would be sent to RPS
+func (p Payload) preflight(flags rpc.Flags, payload MessagePayload) []check.Result {
+	command := ""
+	if fields := strings.Fields(flags.Command); len(fields) > 0 {
+		command = fields[0]
+	}
+	results := []check.Result{
+		check.CheckServerURL(flags.URL),
+		check.CheckVersion(payload.Version, command),
+	}
+
+	uuidResult := check.Result{Name: "uuid", Status: check.Pass, Message: payload.UUID}
+	if payload.UUID == "" {
+		uuidResult.Status = check.Fail
+		uuidResult.Message = "unable to read device UUID"
+	}
+	results = append(results, uuidResult, check.CheckControlMode(payload.CurrentMode, command))
+
+	if flags.DNS != "" {
+		results = append(results, check.Result{Name: "dns suffix", Status: check.Pass, Message: flags.DNS + " (-d)"})
+	} else {
+		amtSuffix, _ := p.AMT.GetDNSSuffix()
+		detection, _ := p.AMT.DetectOSDNSSuffix()
+		results = append(results, check.CheckDNSSuffix(amtSuffix, detection))
+	}
+
+	hashResult := check.Result{Name: "certificate hashes", Status: check.Pass, Message: "trusted root certificate hashes found"}
+	if len(payload.CertificateHashes) == 0 {
+		hashResult.Status = check.Warn
+		hashResult.Message = "no trusted root certificate hashes found, admin control mode activation will fail"
+	}
+	results = append(results, hashResult)
+
+	if payload.CurrentMode != 0 {
+		passwordResult := check.Result{Name: "password", Status: check.Pass, Message: "AMT password provided"}
+		if payload.Password == "" {
+			passwordResult.Status = check.Fail
+			passwordResult.Message = "AMT password is required for activated devices"
+		}
+		results = append(results, passwordResult)
+	}
+
+	return results
+}
@@ -0,0 +1,94 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package rps
+
+import (
+	"rpc/internal/check"
+	"rpc/internal/rpc"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateDryRunReport(t *testing.T) {
+	mebxDNSSuffix = "mebxdns"
+	flags := rpc.Flags{
+		Command: "activate --profile profileName",
+		URL:     "wss://localhost",
+	}
+	message, err := p.CreateMessageRequest(flags)
+	assert.NoError(t, err)
+	result, err := p.CreateDryRunReport(flags, message)
+	assert.NoError(t, err)
+	assert.Equal(t, "activate --profile profileName", result.Request.Method)
+	assert.Equal(t, "123-456-789", result.Request.Payload.UUID)
+	assert.Equal(t, "mebxdns", result.Request.Payload.FQDN)
+	assert.Equal(t, RedactedValue, result.Request.Payload.Password)
+	assert.False(t, result.Failed())
+}
+
+func TestCreateDryRunReportRedactsMethod(t *testing.T) {
+	controlMode = 1
+	defer func() {
+		controlMode = 0
+	}()
+	flags := rpc.Flags{
+		Command:  "deactivate --password password -f",
+		URL:      "wss://localhost",
+		Password: "password",
+	}
+	message, err := p.CreateMessageRequest(flags)
+	assert.NoError(t, err)
+	result, err := p.CreateDryRunReport(flags, message)
+	assert.NoError(t, err)
+	assert.Equal(t, "deactivate --password "+RedactedValue+" -f", result.Request.Method)
+	assert.Equal(t, RedactedValue, result.Request.Payload.Password)
+	assert.False(t, result.Failed())
+}
+
+func TestCreateDryRunReportInvalidPayload(t *testing.T) {
+	_, err := p.CreateDryRunReport(rpc.Flags{}, RPSMessage{Payload: "not base64"})
+	assert.Error(t, err)
+}
+
+func TestPreflightDeactivateNotActivated(t *testing.T) {
+	flags := rpc.Flags{
+		Command: "deactivate --password password",
+		URL:     "wss://localhost",
+	}
+	results := p.preflight(flags, MessagePayload{Version: "15.0.0", UUID: "123"})
+	report := DryRunReport{Preflight: results}
+	assert.True(t, report.Failed())
+}
+
+func TestPreflightUnsupportedVersion(t *testing.T) {
+	flags := rpc.Flags{
+		Command: "activate --profile profile1",
+		URL:     "wss://localhost",
+	}
+	results := p.preflight(flags, MessagePayload{Version: "11.0.25", UUID: "123"})
+	assert.Equal(t, check.Fail, results[1].Status)
+	assert.Contains(t, results[1].Message, "requires AMT 11.8 or later")
+}
+
+func TestPreflightServerURL(t *testing.T) {
+	results := p.preflight(rpc.Flags{URL: "https://localhost"}, MessagePayload{})
+	assert.Equal(t, check.Fail, results[0].Status)
+	results = p.preflight(rpc.Flags{URL: "ws://localhost"}, MessagePayload{})
+	assert.Equal(t, check.Warn, results[0].Status)
+	results = p.preflight(rpc.Flags{}, MessagePayload{})
+	assert.Equal(t, check.Warn, results[0].Status)
+}
+
+func TestPreflightDNSSuffix(t *testing.T) {
+	mebxDNSSuffix = ""
+	results := p.preflight(rpc.Flags{}, MessagePayload{})
+	assert.Equal(t, check.Result{Name: "dns suffix", Status: check.Pass, Message: "osdns (OS, ptr)"}, results[4])
+	mebxDNSSuffix = "mebxdns"
+	results = p.preflight(rpc.Flags{}, MessagePayload{})
+	assert.Equal(t, check.Warn, results[4].Status)
+	results = p.preflight(rpc.Flags{DNS: "vprodemo.com"}, MessagePayload{})
+	assert.Equal(t, check.Result{Name: "dns suffix", Status: check.Pass, Message: "vprodemo.com (-d)"}, results[4])
+}
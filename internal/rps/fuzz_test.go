@@ -0,0 +1,24 @@
+//go:build go1.18
+// +build go1.18
+
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package rps
+
+import (
+	"testing"
+)
+
+func FuzzProcessMessage(f *testing.F) {
+	f.Add([]byte(`{"method": "heartbeat_request"}`))
+	f.Add([]byte(`{"method": "success", "message": "{\"status\":\"ok\"}"}`))
+	f.Add([]byte(`{"method": "error", "message": "failed"}`))
+	f.Add([]byte(`{"method": "", "payload": "SGVsbG8="}`))
+	f.Add([]byte(`{"payload": "not base64"}`))
+	f.Fuzz(func(t *testing.T, message []byte) {
+		server := AMTActivationServer{}
+		server.ProcessMessage(message)
+	})
+}
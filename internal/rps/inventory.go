@@ -0,0 +1,47 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package rps
+
+import (
+	"rpc/internal/amt"
+	"rpc/pkg/osinfo"
+	"rpc/pkg/smbios"
+)
+
+// InventoryVersion is incremented when fields are removed or change meaning, added fields keep the version
+const InventoryVersion = 1
+
+// Inventory describes the device so RPS can populate its records without a separate agent
+type Inventory struct {
+	Version           int                       `json:"version"`
+	OS                osinfo.Info               `json:"os"`
+	SMBIOS            *smbios.Info              `json:"smbios,omitempty"`
+	NetworkInterfaces []osinfo.NetworkInterface `json:"networkInterfaces"`
+	WiredAdapter      *amt.InterfaceSettings    `json:"wiredAdapter,omitempty"`
+	WirelessAdapter   *amt.InterfaceSettings    `json:"wirelessAdapter,omitempty"`
+}
+
+// readOSInfo and listNetworkInterfaces are replaced in tests
+var readOSInfo = osinfo.Read
+var listNetworkInterfaces = osinfo.NetworkInterfaces
+
+// createInventory gathers the inventory, leaving out anything that cannot be read
+func (p Payload) createInventory() Inventory {
+	inventory := Inventory{
+		Version:           InventoryVersion,
+		OS:                readOSInfo(),
+		NetworkInterfaces: listNetworkInterfaces(),
+	}
+	if info, err := readSMBIOS(); err == nil {
+		inventory.SMBIOS = &info
+	}
+	if wired, err := p.AMT.GetLANInterfaceSettings(false); err == nil {
+		inventory.WiredAdapter = &wired
+	}
+	if wireless, err := p.AMT.GetLANInterfaceSettings(true); err == nil {
+		inventory.WirelessAdapter = &wireless
+	}
+	return inventory
+}
@@ -0,0 +1,77 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package rps
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"rpc/internal/rpc"
+	"rpc/pkg/osinfo"
+	"rpc/pkg/smbios"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withTestInventory(t *testing.T) {
+	readOSInfo = func() osinfo.Info {
+		return osinfo.Info{Name: "Ubuntu", Version: "20.04", Kernel: "5.11.0-27-generic", Architecture: "amd64"}
+	}
+	listNetworkInterfaces = func() []osinfo.NetworkInterface {
+		return []osinfo.NetworkInterface{{Name: "eth0", MACAddress: "07:07:07:07:07:07"}}
+	}
+	t.Cleanup(func() {
+		readOSInfo = osinfo.Read
+		listNetworkInterfaces = osinfo.NetworkInterfaces
+	})
+}
+
+func TestCreateInventory(t *testing.T) {
+	withTestInventory(t)
+	readSMBIOS = func() (smbios.Info, error) {
+		return smbios.Info{Serial: "PF2ABCDE", Manufacturer: "LENOVO", Model: "20XW0055US"}, nil
+	}
+	defer func() {
+		readSMBIOS = func() (smbios.Info, error) { return smbios.Info{}, errors.New("no SMBIOS information found") }
+	}()
+	inventory := p.createInventory()
+	assert.Equal(t, InventoryVersion, inventory.Version)
+	assert.Equal(t, "Ubuntu", inventory.OS.Name)
+	assert.Equal(t, "5.11.0-27-generic", inventory.OS.Kernel)
+	assert.Equal(t, "LENOVO", inventory.SMBIOS.Manufacturer)
+	assert.Equal(t, "07:07:07:07:07:07", inventory.NetworkInterfaces[0].MACAddress)
+	assert.NotNil(t, inventory.WiredAdapter)
+	assert.NotNil(t, inventory.WirelessAdapter)
+}
+
+func TestCreateInventoryWithoutSMBIOS(t *testing.T) {
+	withTestInventory(t)
+	inventory := p.createInventory()
+	assert.Nil(t, inventory.SMBIOS)
+}
+
+func decodePayload(t *testing.T, message RPSMessage) map[string]interface{} {
+	data, err := base64.StdEncoding.DecodeString(message.Payload)
+	assert.NoError(t, err)
+	payload := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(data, &payload))
+	return payload
+}
+
+func TestCreateMessageRequestInventory(t *testing.T) {
+	withTestInventory(t)
+	result, err := p.CreateMessageRequest(rpc.Flags{Command: "method", Inventory: true})
+	assert.NoError(t, err)
+	inventory := decodePayload(t, result)["inventory"].(map[string]interface{})
+	assert.Equal(t, float64(InventoryVersion), inventory["version"])
+	assert.Equal(t, "Ubuntu", inventory["os"].(map[string]interface{})["name"])
+}
+
+func TestCreateMessageRequestWithoutInventory(t *testing.T) {
+	result, err := p.CreateMessageRequest(rpc.Flags{Command: "method"})
+	assert.NoError(t, err)
+	assert.NotContains(t, decodePayload(t, result), "inventory")
+}
@@ -11,7 +11,10 @@ import (
 	"os"
 	"rpc/internal/amt"
 	"rpc/internal/rpc"
+	"rpc/pkg/smbios"
 	"rpc/pkg/utils"
+
+	log "github.com/sirupsen/logrus"
 )
 
 type Payload struct {
@@ -39,19 +42,25 @@ type StatusMessage struct {
 
 // MessagePayload struct is used for the initial request to RPS to activate a device
 type MessagePayload struct {
-	Version           string   `json:"ver"`
-	Build             string   `json:"build"`
-	SKU               string   `json:"sku"`
-	UUID              string   `json:"uuid"`
-	Username          string   `json:"username"`
-	Password          string   `json:"password"`
-	CurrentMode       int      `json:"currentMode"`
-	Hostname          string   `json:"hostname"`
-	FQDN              string   `json:"fqdn"`
-	Client            string   `json:"client"`
-	CertificateHashes []string `json:"certHashes"`
+	Version           string            `json:"ver"`
+	Build             string            `json:"build"`
+	SKU               string            `json:"sku"`
+	UUID              string            `json:"uuid"`
+	Username          string            `json:"username"`
+	Password          string            `json:"password"`
+	CurrentMode       int               `json:"currentMode"`
+	Hostname          string            `json:"hostname"`
+	FQDN              string            `json:"fqdn"`
+	Client            string            `json:"client"`
+	CertificateHashes []string          `json:"certHashes"`
+	SMBIOS            *smbios.Info      `json:"smbios,omitempty"`
+	Inventory         *Inventory        `json:"inventory,omitempty"`
+	Tags              map[string]string `json:"tags,omitempty"`
 }
 
+// readSMBIOS is replaced in tests
+var readSMBIOS = smbios.Read
+
 // createPayload gathers data from ME to assemble required information for sending to the server
 func (p Payload) createPayload(dnsSuffix string, hostname string) (MessagePayload, error) {
 	payload := MessagePayload{}
@@ -72,6 +81,12 @@ func (p Payload) createPayload(dnsSuffix string, hostname string) (MessagePayloa
 	if err != nil {
 		return payload, err
 	}
+	if info, err := readSMBIOS(); err == nil {
+		payload.SMBIOS = &info
+		checkUUID(payload.UUID, info.UUID)
+	} else {
+		log.Debug("unable to read SMBIOS information: ", err)
+	}
 	payload.CurrentMode, err = p.AMT.GetControlMode()
 	if err != nil {
 		return payload, err
@@ -88,7 +103,11 @@ func (p Payload) createPayload(dnsSuffix string, hostname string) (MessagePayloa
 	} else {
 		payload.FQDN, err = p.AMT.GetDNSSuffix()
 		if payload.FQDN == "" {
-			payload.FQDN, _ = p.AMT.GetOSDNSSuffix()
+			detection, _ := p.AMT.DetectOSDNSSuffix()
+			payload.FQDN = detection.Suffix
+			if detection.Suffix != "" {
+				log.Info("using OS DNS suffix ", detection.Suffix, " from ", detection.Source)
+			}
 		}
 		if err != nil {
 			return payload, err
@@ -114,6 +133,18 @@ func (p Payload) createPayload(dnsSuffix string, hostname string) (MessagePayloa
 
 }
 
+// checkUUID warns when the AMT UUID does not match the SMBIOS UUID, since RPS keys devices on it
+func checkUUID(amtUUID string, smbiosUUID string) smbios.UUIDCheck {
+	check := smbios.CompareUUID(amtUUID, smbiosUUID)
+	switch check {
+	case smbios.UUIDByteSwapped:
+		log.Warn("AMT UUID ", amtUUID, " matches SMBIOS UUID ", smbiosUUID, " only with the byte order swapped")
+	case smbios.UUIDMismatch:
+		log.Warn("AMT UUID ", amtUUID, " does not match SMBIOS UUID ", smbiosUUID)
+	}
+	return check
+}
+
 // CreateMessageRequest is used for assembling the message to request activation of a device
 func (p Payload) CreateMessageRequest(flags rpc.Flags) (RPSMessage, error) {
 	message := RPSMessage{
@@ -128,6 +159,13 @@ func (p Payload) CreateMessageRequest(flags rpc.Flags) (RPSMessage, error) {
 	if err != nil {
 		return message, err
 	}
+	if len(flags.Tags) > 0 {
+		payload.Tags = flags.Tags
+	}
+	if flags.Inventory {
+		inventory := p.createInventory()
+		payload.Inventory = &inventory
+	}
 	// Update with AMT password for activated devices
 	if payload.CurrentMode != 0 {
 		if flags.Password == "" {
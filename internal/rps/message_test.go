@@ -7,9 +7,12 @@ package rps
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"os"
 	"rpc/internal/amt"
 	"rpc/internal/rpc"
+	"rpc/pkg/dnssuffix"
+	"rpc/pkg/smbios"
 	"rpc/pkg/utils"
 	"testing"
 
@@ -32,6 +35,9 @@ func (c MockAMT) GetControlMode() (int, error)                    { return contr
 func (c MockAMT) GetControlModeV2() (int, error)                  { return controlMode, nil }
 func (c MockAMT) GetOSDNSSuffix() (string, error)                 { return "osdns", nil }
 func (c MockAMT) GetDNSSuffix() (string, error)                   { return mebxDNSSuffix, nil }
+func (c MockAMT) DetectOSDNSSuffix() (dnssuffix.Result, error) {
+	return dnssuffix.Result{Suffix: "osdns", Source: dnssuffix.SourcePTR, Candidates: []dnssuffix.Candidate{{Suffix: "osdns", Source: dnssuffix.SourcePTR}}}, nil
+}
 func (c MockAMT) GetCertificateHashes() ([]amt.CertHashEntry, error) {
 	return []amt.CertHashEntry{}, nil
 }
@@ -52,6 +58,7 @@ func (c MockAMT) InitiateLMS() {}
 func init() {
 	p = Payload{}
 	p.AMT = MockAMT{}
+	readSMBIOS = func() (smbios.Info, error) { return smbios.Info{}, errors.New("no SMBIOS information found") }
 
 }
 func TestCreatePayload(t *testing.T) {
@@ -76,6 +83,27 @@ func TestCreatePayloadWithOSDNSSuffix(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "osdns", result.FQDN)
 }
+func TestCreatePayloadWithSMBIOS(t *testing.T) {
+	readSMBIOS = func() (smbios.Info, error) {
+		return smbios.Info{UUID: "123-456-789", Serial: "PF2ABCDE", Manufacturer: "LENOVO", Model: "20XW0055US"}, nil
+	}
+	defer func() {
+		readSMBIOS = func() (smbios.Info, error) { return smbios.Info{}, errors.New("no SMBIOS information found") }
+	}()
+	result, err := p.createPayload("", "")
+	assert.NoError(t, err)
+	assert.Equal(t, &smbios.Info{UUID: "123-456-789", Serial: "PF2ABCDE", Manufacturer: "LENOVO", Model: "20XW0055US"}, result.SMBIOS)
+}
+func TestCreatePayloadWithoutSMBIOS(t *testing.T) {
+	result, err := p.createPayload("", "")
+	assert.NoError(t, err)
+	assert.Nil(t, result.SMBIOS)
+}
+func TestCheckUUID(t *testing.T) {
+	assert.Equal(t, smbios.UUIDMatch, checkUUID("1c113fd2-3325-4594-a272-54b2038beb07", "1c113fd2-3325-4594-a272-54b2038beb07"))
+	assert.Equal(t, smbios.UUIDByteSwapped, checkUUID("1c113fd2-3325-4594-a272-54b2038beb07", "d23f111c-2533-9445-a272-54b2038beb07"))
+	assert.Equal(t, smbios.UUIDMismatch, checkUUID("1c113fd2-3325-4594-a272-54b2038beb07", "00000000-0000-0000-0000-000000000000"))
+}
 func TestCreatePayloadWithDNSSuffix(t *testing.T) {
 
 	result, err := p.createPayload("vprodemo.com", "")
@@ -173,3 +201,13 @@ func TestCreateActivationResponse(t *testing.T) {
 	assert.Equal(t, utils.ProjectVersion, result.AppVersion)
 
 }
+func TestCreateMessageRequestTags(t *testing.T) {
+	flags := rpc.Flags{
+		Command: "method",
+		Tags:    rpc.Tags{"site": "berlin"},
+	}
+	result, err := p.CreateMessageRequest(flags)
+	assert.NoError(t, err)
+	payload := decodePayload(t, result)
+	assert.Equal(t, map[string]interface{}{"site": "berlin"}, payload["tags"])
+}
@@ -8,6 +8,7 @@ import (
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 
 	"github.com/gorilla/websocket"
 	log "github.com/sirupsen/logrus"
@@ -15,8 +16,9 @@ import (
 
 // AMTActivationServer struct represents the connection to RPS
 type AMTActivationServer struct {
-	URL  string
-	Conn *websocket.Conn
+	URL        string
+	Conn       *websocket.Conn
+	Transcript *Transcript
 }
 
 // Connect is used to connect to the RPS Server
@@ -51,6 +53,10 @@ func (amt *AMTActivationServer) Close() error {
 func (amt *AMTActivationServer) Send(data []byte) error {
 	log.Debug("sending message to RPS")
 	// log.Trace(string(data))
+	if amt.Conn == nil {
+		return errors.New("not connected to RPS")
+	}
+	amt.Transcript.Record("->", data)
 	err := amt.Conn.WriteMessage(websocket.TextMessage, data)
 	if err != nil {
 		return err
@@ -72,6 +78,7 @@ func (amt *AMTActivationServer) Listen() chan []byte {
 				log.Error("error:", err)
 				return
 			}
+			amt.Transcript.Record("<-", message)
 			dataChannel <- message
 		}
 	}(dataChannel)
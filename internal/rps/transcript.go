@@ -0,0 +1,98 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package rps
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"rpc/pkg/utils"
+	"sync"
+	"time"
+)
+
+// Transcript records a summary of every message exchanged with RPS.
+// Payloads may carry AMT credentials, so only their first line and size are kept.
+type Transcript struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+// TranscriptPath returns the location of the last session transcript, in the cache directory of the user running rpc.
+// Unlike the shared temp directory, other users cannot place a file or symlink there.
+func TranscriptPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "rpc", utils.TranscriptFileName), nil
+}
+
+// CreateTranscriptFile creates or truncates the transcript file, refusing to follow a symlink or replace anything but a regular file
+func CreateTranscriptFile() (*os.File, error) {
+	path, err := TranscriptPath()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(path)
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	info, err = os.Lstat(path)
+	if err == nil && !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", path)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+}
+
+// NewTranscript creates a transcript that writes to w
+func NewTranscript(w io.Writer) *Transcript {
+	return &Transcript{writer: w}
+}
+
+// Record writes a redacted summary of message to the transcript
+func (t *Transcript) Record(direction string, message []byte) {
+	if t == nil || t.writer == nil {
+		return
+	}
+	entry := RPSMessage{}
+	summary := ""
+	if err := json.Unmarshal(message, &entry); err != nil {
+		summary = fmt.Sprintf("unparsable message (%d bytes)", len(message))
+	} else {
+		summary = fmt.Sprintf("method=%q status=%q", redactMethod(entry.Method), entry.Status)
+		if entry.Method == "success" || entry.Method == "error" {
+			summary = summary + fmt.Sprintf(" message=%q", entry.Message)
+		}
+		payload, err := base64.StdEncoding.DecodeString(entry.Payload)
+		if err == nil && len(payload) > 0 {
+			// HTTP messages relayed to and from LMS start with a request or status line, anything else is hidden
+			firstLine, err := bufio.NewReader(bytes.NewReader(payload)).ReadString('\n')
+			if err != nil {
+				firstLine = "<redacted>"
+			}
+			summary = summary + fmt.Sprintf(" payload=%q (%d bytes)", bytes.TrimSpace([]byte(firstLine)), len(payload))
+		}
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	fmt.Fprintf(t.writer, "%s %s %s\n", time.Now().Format(time.RFC3339), direction, summary)
+}
@@ -0,0 +1,116 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package rps
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranscriptRecordRedactsPayload(t *testing.T) {
+	var buf bytes.Buffer
+	transcript := NewTranscript(&buf)
+	message, _ := json.Marshal(RPSMessage{
+		Method:  "deactivate --password secret",
+		Status:  "ok",
+		Payload: base64.StdEncoding.EncodeToString([]byte(`{"password":"secret"}`)),
+	})
+	transcript.Record("->", message)
+	assert.NotContains(t, buf.String(), "secret")
+	assert.Contains(t, buf.String(), "-> method=\"deactivate --password ********\"")
+	assert.Contains(t, buf.String(), "payload=\"<redacted>\"")
+}
+
+func TestTranscriptRecordHTTPPayload(t *testing.T) {
+	var buf bytes.Buffer
+	transcript := NewTranscript(&buf)
+	message, _ := json.Marshal(RPSMessage{
+		Method:  "wsman",
+		Payload: base64.StdEncoding.EncodeToString([]byte("POST /wsman HTTP/1.1\r\nAuthorization: Digest\r\n\r\n<h:AdminPassword>secret</h:AdminPassword>")),
+	})
+	transcript.Record("<-", message)
+	assert.Contains(t, buf.String(), "payload=\"POST /wsman HTTP/1.1\"")
+	assert.NotContains(t, buf.String(), "secret")
+}
+
+func TestTranscriptRecordUnparsable(t *testing.T) {
+	var buf bytes.Buffer
+	transcript := NewTranscript(&buf)
+	transcript.Record("<-", []byte("test"))
+	assert.Contains(t, buf.String(), "unparsable message (4 bytes)")
+}
+
+func TestTranscriptRecordNil(t *testing.T) {
+	var transcript *Transcript
+	transcript.Record("->", []byte("test"))
+}
+
+// useCacheDir points the user cache directory at dir on every platform
+func useCacheDir(t *testing.T, dir string) {
+	for _, key := range []string{"XDG_CACHE_HOME", "LocalAppData", "HOME"} {
+		old, ok := os.LookupEnv(key)
+		os.Setenv(key, dir)
+		t.Cleanup(func() {
+			if ok {
+				os.Setenv(key, old)
+			} else {
+				os.Unsetenv(key)
+			}
+		})
+	}
+}
+
+func TestCreateTranscriptFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transcript")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	useCacheDir(t, dir)
+	path, err := TranscriptPath()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(path, dir))
+	file, err := CreateTranscriptFile()
+	assert.NoError(t, err)
+	file.WriteString("-> method=\"activate\"\n")
+	file.Close()
+	info, err := os.Stat(filepath.Dir(path))
+	assert.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+	}
+	// a later session replaces the previous transcript
+	file, err = CreateTranscriptFile()
+	assert.NoError(t, err)
+	file.Close()
+	data, _ := ioutil.ReadFile(path)
+	assert.Empty(t, data)
+}
+
+func TestCreateTranscriptFileRefusesSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks requires privileges on Windows")
+	}
+	dir, err := ioutil.TempDir("", "transcript")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	useCacheDir(t, dir)
+	target := filepath.Join(dir, "target")
+	assert.NoError(t, ioutil.WriteFile(target, []byte("keep"), 0600))
+	path, _ := TranscriptPath()
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	assert.NoError(t, os.Symlink(target, path))
+	_, err = CreateTranscriptFile()
+	assert.EqualError(t, err, path+" is not a regular file")
+	data, _ := ioutil.ReadFile(target)
+	assert.Equal(t, "keep", string(data))
+}
@@ -0,0 +1,268 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package dnssuffix
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Source identifies where a DNS suffix candidate was found
+type Source string
+
+// Sources in the order they are preferred. DHCP option 15 comes first because it is the
+// domain AMT itself compares against the provisioning certificate.
+const (
+	SourceDHCP       Source = "dhcp"
+	SourceResolved   Source = "systemd-resolved"
+	SourcePTR        Source = "ptr"
+	SourceResolvConf Source = "resolv.conf"
+)
+
+// Default locations of the resolver configuration and DHCP leases
+var (
+	ResolvConfPath     = "/etc/resolv.conf"
+	ResolvedLinkPath   = "/run/systemd/resolve/netif"
+	NetworkdLeasePath  = "/run/systemd/netif/leases"
+	DHClientLeaseGlobs = []string{"/var/lib/dhcp/dhclient*.leases", "/var/lib/dhclient/*.lease*", "/var/lib/NetworkManager/*.lease"}
+)
+
+var sourceOrder = []Source{SourceDHCP, SourceResolved, SourcePTR, SourceResolvConf}
+
+// Candidate is a DNS suffix and the source that reported it
+type Candidate struct {
+	Suffix string `json:"suffix"`
+	Source Source `json:"source"`
+}
+
+// Result is the chosen suffix, where it came from and every candidate considered
+type Result struct {
+	Suffix     string      `json:"suffix"`
+	Source     Source      `json:"source,omitempty"`
+	Candidates []Candidate `json:"candidates"`
+}
+
+// Interface is the OS network interface whose per-link sources are consulted
+type Interface struct {
+	Name  string
+	Index int
+	Addrs []net.IP
+}
+
+// Detector gathers DNS suffix candidates from the resolver configuration, DHCP leases and reverse lookups
+type Detector struct {
+	Interface  Interface
+	lookupAddr func(addr string) ([]string, error)
+	readFile   func(name string) ([]byte, error)
+	glob       func(pattern string) ([]string, error)
+}
+
+// NewDetector creates a detector for iface, which may be nil to only use system wide sources
+func NewDetector(iface *net.Interface) Detector {
+	detector := Detector{
+		lookupAddr: net.LookupAddr,
+		readFile:   ioutil.ReadFile,
+		glob:       filepath.Glob,
+	}
+	if iface != nil {
+		detector.Interface = Interface{Name: iface.Name, Index: iface.Index}
+		addrs, _ := iface.Addrs()
+		for _, a := range addrs {
+			if networkIp, ok := a.(*net.IPNet); ok {
+				detector.Interface.Addrs = append(detector.Interface.Addrs, networkIp.IP)
+			}
+		}
+	}
+	return detector
+}
+
+// Detect returns the first candidate matching preferred, the AMT PKI DNS suffix, or else the highest priority candidate
+func (d Detector) Detect(preferred string) Result {
+	result := Result{Candidates: d.Candidates()}
+	preferred = normalize(preferred)
+	if preferred != "" {
+		for _, candidate := range result.Candidates {
+			if Matches(candidate.Suffix, preferred) {
+				result.Suffix = candidate.Suffix
+				result.Source = candidate.Source
+				return result
+			}
+		}
+	}
+	if len(result.Candidates) > 0 {
+		result.Suffix = result.Candidates[0].Suffix
+		result.Source = result.Candidates[0].Source
+	}
+	return result
+}
+
+// Candidates returns every suffix found, ordered by source priority without duplicates
+func (d Detector) Candidates() []Candidate {
+	candidates := []Candidate{}
+	seen := map[Candidate]bool{}
+	for _, source := range sourceOrder {
+		for _, suffix := range d.lookup(source) {
+			candidate := Candidate{Suffix: normalize(suffix), Source: source}
+			if candidate.Suffix == "" || seen[candidate] {
+				continue
+			}
+			seen[candidate] = true
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// Matches reports whether suffix is domain or one of its subdomains
+func Matches(suffix string, domain string) bool {
+	suffix = normalize(suffix)
+	domain = normalize(domain)
+	return suffix == domain || strings.HasSuffix(suffix, "."+domain)
+}
+
+func (d Detector) lookup(source Source) []string {
+	switch source {
+	case SourceDHCP:
+		return d.dhcpDomains()
+	case SourceResolved:
+		return d.resolvedDomains()
+	case SourcePTR:
+		return d.ptrDomains()
+	case SourceResolvConf:
+		data, err := d.readFile(ResolvConfPath)
+		if err != nil {
+			return nil
+		}
+		return parseResolvConf(data)
+	}
+	return nil
+}
+
+func (d Detector) dhcpDomains() []string {
+	domains := []string{}
+	if d.Interface.Index > 0 {
+		if data, err := d.readFile(filepath.Join(NetworkdLeasePath, strconv.Itoa(d.Interface.Index))); err == nil {
+			domains = append(domains, parseKeyValue(data, "DOMAINNAME")...)
+		}
+	}
+	if d.Interface.Name == "" {
+		return domains
+	}
+	for _, pattern := range DHClientLeaseGlobs {
+		files, _ := d.glob(pattern)
+		for _, file := range files {
+			if data, err := d.readFile(file); err == nil {
+				if domain := parseDHClientLeases(data, d.Interface.Name); domain != "" {
+					domains = append(domains, domain)
+				}
+			}
+		}
+	}
+	return domains
+}
+
+func (d Detector) resolvedDomains() []string {
+	if d.Interface.Index <= 0 {
+		return nil
+	}
+	data, err := d.readFile(filepath.Join(ResolvedLinkPath, strconv.Itoa(d.Interface.Index)))
+	if err != nil {
+		return nil
+	}
+	domains := []string{}
+	for _, domain := range parseKeyValue(data, "DOMAINS") {
+		// routing-only domains are not search domains
+		if !strings.HasPrefix(domain, "~") {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// ptrDomains reverse resolves the interface addresses, IPv4 first and then global IPv6,
+// and drops the host label from each name
+func (d Detector) ptrDomains() []string {
+	domains := []string{}
+	for _, family := range []bool{true, false} {
+		for _, ip := range d.Interface.Addrs {
+			if ip.IsLoopback() || ip.IsLinkLocalUnicast() || (ip.To4() != nil) != family {
+				continue
+			}
+			names, err := d.lookupAddr(ip.String())
+			if err != nil {
+				continue
+			}
+			for _, name := range names {
+				name = strings.TrimSuffix(name, ".")
+				if index := strings.Index(name, "."); index >= 0 {
+					domains = append(domains, name[index+1:])
+				}
+			}
+		}
+	}
+	return domains
+}
+
+// parseResolvConf returns the search list, where the last search or domain line wins as it does for the resolver
+func parseResolvConf(data []byte) []string {
+	domains := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		if fields[0] == "search" || fields[0] == "domain" {
+			domains = fields[1:]
+		}
+	}
+	return domains
+}
+
+// parseKeyValue returns the space separated values of key in a systemd state file
+func parseKeyValue(data []byte, key string) []string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, key+"=") {
+			return strings.Fields(strings.TrimPrefix(line, key+"="))
+		}
+	}
+	return nil
+}
+
+// parseDHClientLeases returns the domain-name option of the last lease for iface
+func parseDHClientLeases(data []byte, iface string) string {
+	domain := ""
+	leaseInterface := ""
+	leaseDomain := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSuffix(strings.TrimSpace(scanner.Text()), ";")
+		switch {
+		case strings.HasPrefix(line, "lease"):
+			leaseInterface = ""
+			leaseDomain = ""
+		case strings.HasPrefix(line, "interface "):
+			leaseInterface = strings.Trim(strings.TrimPrefix(line, "interface "), "\"")
+		case strings.HasPrefix(line, "option domain-name "):
+			leaseDomain = strings.Trim(strings.TrimPrefix(line, "option domain-name "), "\"")
+		case line == "}":
+			if leaseInterface == iface && leaseDomain != "" {
+				domain = leaseDomain
+			}
+		}
+	}
+	return domain
+}
+
+func normalize(suffix string) string {
+	return strings.ToLower(strings.Trim(strings.TrimSpace(suffix), "."))
+}
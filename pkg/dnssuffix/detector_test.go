@@ -0,0 +1,133 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package dnssuffix
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const dhclientLeases = `lease {
+  interface "eth0";
+  fixed-address 192.168.1.7;
+  option domain-name "old.example.com";
+}
+lease {
+  interface "wlan0";
+  option domain-name "wireless.example.com";
+}
+lease {
+  interface "eth0";
+  fixed-address 192.168.1.7;
+  option domain-name "corp.example.com";
+  option domain-name-servers 192.168.1.1;
+}
+`
+
+func newTestDetector(files map[string]string, names map[string][]string) Detector {
+	return Detector{
+		Interface: Interface{Name: "eth0", Index: 2, Addrs: []net.IP{net.ParseIP("fe80::7"), net.ParseIP("2001:db8::7"), net.ParseIP("192.168.1.7")}},
+		lookupAddr: func(addr string) ([]string, error) {
+			if result, ok := names[addr]; ok {
+				return result, nil
+			}
+			return nil, errors.New("no such host")
+		},
+		readFile: func(name string) ([]byte, error) {
+			if data, ok := files[name]; ok {
+				return []byte(data), nil
+			}
+			return nil, os.ErrNotExist
+		},
+		glob: func(pattern string) ([]string, error) {
+			matches := []string{}
+			for name := range files {
+				if ok, _ := filepath.Match(pattern, name); ok {
+					matches = append(matches, name)
+				}
+			}
+			return matches, nil
+		},
+	}
+}
+
+func TestCandidatesAllSources(t *testing.T) {
+	detector := newTestDetector(map[string]string{
+		"/var/lib/dhcp/dhclient.eth0.leases": dhclientLeases,
+		"/run/systemd/netif/leases/2":        "ADDRESS=192.168.1.7\nDOMAINNAME=lease.example.com\n",
+		"/run/systemd/resolve/netif/2":       "SERVERS=192.168.1.1\nDOMAINS=link.example.com ~routing.example.com\n",
+		"/etc/resolv.conf":                   "# generated\nnameserver 127.0.0.53\nsearch Search.Example.com. other.example.com\n",
+	}, map[string][]string{
+		"192.168.1.7": {"myhost.ptr4.example.com."},
+		"2001:db8::7": {"myhost.ptr6.example.com."},
+	})
+	assert.Equal(t, []Candidate{
+		{Suffix: "lease.example.com", Source: SourceDHCP},
+		{Suffix: "corp.example.com", Source: SourceDHCP},
+		{Suffix: "link.example.com", Source: SourceResolved},
+		{Suffix: "ptr4.example.com", Source: SourcePTR},
+		{Suffix: "ptr6.example.com", Source: SourcePTR},
+		{Suffix: "search.example.com", Source: SourceResolvConf},
+		{Suffix: "other.example.com", Source: SourceResolvConf},
+	}, detector.Candidates())
+}
+
+func TestDetectPrefersHighestPriority(t *testing.T) {
+	detector := newTestDetector(map[string]string{
+		"/etc/resolv.conf": "search example.com\n",
+	}, map[string][]string{"192.168.1.7": {"myhost.ptr.example.com."}})
+	result := detector.Detect("")
+	assert.Equal(t, "ptr.example.com", result.Suffix)
+	assert.Equal(t, SourcePTR, result.Source)
+	assert.Len(t, result.Candidates, 2)
+}
+
+func TestDetectPrefersMatchingSuffix(t *testing.T) {
+	detector := newTestDetector(map[string]string{
+		"/etc/resolv.conf": "search vprodemo.com\n",
+	}, map[string][]string{"192.168.1.7": {"myhost.ptr.example.com."}})
+	result := detector.Detect("vprodemo.com")
+	assert.Equal(t, "vprodemo.com", result.Suffix)
+	assert.Equal(t, SourceResolvConf, result.Source)
+}
+
+func TestDetectNoCandidates(t *testing.T) {
+	detector := newTestDetector(nil, nil)
+	result := detector.Detect("vprodemo.com")
+	assert.Equal(t, "", result.Suffix)
+	assert.Equal(t, Source(""), result.Source)
+	assert.Empty(t, result.Candidates)
+}
+
+func TestPTRKeepsHostnameLetters(t *testing.T) {
+	// the host label is dropped as a whole, letters it shares with the domain are kept
+	detector := newTestDetector(nil, map[string][]string{"192.168.1.7": {"mo.mydomain.com."}})
+	assert.Equal(t, []Candidate{{Suffix: "mydomain.com", Source: SourcePTR}}, detector.Candidates())
+}
+
+func TestCandidatesWithoutInterface(t *testing.T) {
+	detector := newTestDetector(map[string]string{
+		"/var/lib/dhcp/dhclient.eth0.leases": dhclientLeases,
+		"/etc/resolv.conf":                   "domain example.com\n",
+	}, nil)
+	detector.Interface = Interface{}
+	assert.Equal(t, []Candidate{{Suffix: "example.com", Source: SourceResolvConf}}, detector.Candidates())
+}
+
+func TestParseResolvConfLastLineWins(t *testing.T) {
+	assert.Equal(t, []string{"b.example.com"}, parseResolvConf([]byte("search a.example.com\ndomain b.example.com\n")))
+	assert.Empty(t, parseResolvConf([]byte("nameserver 1.1.1.1\n")))
+}
+
+func TestMatches(t *testing.T) {
+	assert.True(t, Matches("corp.example.com", "example.com"))
+	assert.True(t, Matches("Example.com.", "example.com"))
+	assert.False(t, Matches("badexample.com", "example.com"))
+}
@@ -0,0 +1,91 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package heci
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// Client identifies a firmware client reachable over HECI. GUID is stored in the little endian layout used by the MEI drivers.
+type Client struct {
+	Name string
+	GUID [16]byte
+}
+
+// ClientProperties are returned by the firmware when a connection to a client is established
+type ClientProperties struct {
+	MaxMessageLength uint32
+	ProtocolVersion  uint8
+}
+
+var (
+	// AMTHI is the AMT host interface used for PTHI commands
+	AMTHI = NewClient("AMTHI", "12F80028-B4B7-4B2D-ACA8-46E0FF65814C")
+	// MKHI is the ME kernel host interface
+	MKHI = NewClient("MKHI", "8E6A6715-9ABC-4043-88EF-9E39C6F63E0F")
+	// MCHI is the ME client host interface used by newer firmware in place of MKHI
+	MCHI = NewClient("MCHI", "DD17041C-09EA-4B17-A271-5B989867EC65")
+	// LME is the local manageability engine used by LMS
+	LME = NewClient("LME", "6733A4DB-0476-4E7B-B3AF-BCFC29BEE7A7")
+	// Watchdog is the AMT watchdog client
+	Watchdog = NewClient("Watchdog", "05B79A6F-4628-4D7F-899D-A91514CB32AB")
+	// HDCP is the HDCP content protection client
+	HDCP = NewClient("HDCP", "B638AB7E-94E2-4EA2-A552-D1C54B627F04")
+)
+
+// Clients is the registry of known firmware clients
+var Clients = []Client{AMTHI, MKHI, MCHI, LME, Watchdog, HDCP}
+
+// NewClient creates a Client from a GUID string and panics if it is malformed. It is intended for registry entries.
+func NewClient(name string, guid string) Client {
+	parsed, err := ParseGUID(guid)
+	if err != nil {
+		panic(err)
+	}
+	return Client{Name: name, GUID: parsed}
+}
+
+// LookupClient finds a known client by name, ignoring case
+func LookupClient(name string) (Client, bool) {
+	for _, client := range Clients {
+		if strings.EqualFold(client.Name, name) {
+			return client, true
+		}
+	}
+	return Client{}, false
+}
+
+// ParseGUID converts a GUID such as 12F80028-B4B7-4B2D-ACA8-46E0FF65814C to its little endian byte layout
+func ParseGUID(guid string) ([16]byte, error) {
+	result := [16]byte{}
+	guid = strings.Trim(guid, "{}")
+	parts := strings.Split(guid, "-")
+	if len(parts) != 5 || len(parts[0]) != 8 || len(parts[1]) != 4 || len(parts[2]) != 4 || len(parts[3]) != 4 || len(parts[4]) != 12 {
+		return result, errors.New("invalid GUID " + guid)
+	}
+	raw, err := hex.DecodeString(strings.Join(parts, ""))
+	if err != nil {
+		return result, errors.New("invalid GUID " + guid)
+	}
+	binary.LittleEndian.PutUint32(result[0:4], binary.BigEndian.Uint32(raw[0:4]))
+	binary.LittleEndian.PutUint16(result[4:6], binary.BigEndian.Uint16(raw[4:6]))
+	binary.LittleEndian.PutUint16(result[6:8], binary.BigEndian.Uint16(raw[6:8]))
+	copy(result[8:], raw[8:])
+	return result, nil
+}
+
+// String formats the client GUID in its canonical form
+func (c Client) String() string {
+	raw := make([]byte, 16)
+	binary.BigEndian.PutUint32(raw[0:4], binary.LittleEndian.Uint32(c.GUID[0:4]))
+	binary.BigEndian.PutUint16(raw[4:6], binary.LittleEndian.Uint16(c.GUID[4:6]))
+	binary.BigEndian.PutUint16(raw[6:8], binary.LittleEndian.Uint16(c.GUID[6:8]))
+	copy(raw[8:], c.GUID[8:])
+	text := strings.ToUpper(hex.EncodeToString(raw))
+	return text[0:8] + "-" + text[8:12] + "-" + text[12:16] + "-" + text[16:20] + "-" + text[20:]
+}
@@ -0,0 +1,43 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package heci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGUID(t *testing.T) {
+	result, err := ParseGUID("12F80028-B4B7-4B2D-ACA8-46E0FF65814C")
+	assert.NoError(t, err)
+	expected := [16]byte{0x28, 0x00, 0xf8, 0x12, 0xb7, 0xb4, 0x2d, 0x4b, 0xac, 0xa8, 0x46, 0xe0, 0xff, 0x65, 0x81, 0x4c}
+	assert.Equal(t, expected, result)
+}
+
+func TestParseGUIDBraces(t *testing.T) {
+	result, err := ParseGUID("{12F80028-B4B7-4B2D-ACA8-46E0FF65814C}")
+	assert.NoError(t, err)
+	assert.Equal(t, AMTHI.GUID, result)
+}
+
+func TestParseGUIDInvalid(t *testing.T) {
+	_, err := ParseGUID("12F80028-B4B7-4B2D-ACA8")
+	assert.Error(t, err)
+	_, err = ParseGUID("ZZF80028-B4B7-4B2D-ACA8-46E0FF65814C")
+	assert.Error(t, err)
+}
+
+func TestClientString(t *testing.T) {
+	assert.Equal(t, "8E6A6715-9ABC-4043-88EF-9E39C6F63E0F", MKHI.String())
+}
+
+func TestLookupClient(t *testing.T) {
+	client, ok := LookupClient("lme")
+	assert.True(t, ok)
+	assert.Equal(t, LME, client)
+	_, ok = LookupClient("unknown")
+	assert.False(t, ok)
+}
@@ -0,0 +1,36 @@
+//go:build linux
+// +build linux
+
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package heci
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindDevices(t *testing.T) {
+	sysfs := t.TempDir()
+	for _, name := range []string{"mei10", "mei2", "mei0"} {
+		assert.NoError(t, os.Mkdir(filepath.Join(sysfs, name), 0755))
+	}
+	result := findDevices(sysfs)
+	assert.Equal(t, []string{"/dev/mei0", "/dev/mei2", "/dev/mei10"}, result)
+}
+
+func TestFindDevicesEmpty(t *testing.T) {
+	result := findDevices(t.TempDir())
+	assert.Equal(t, []string{DefaultDevice}, result)
+}
+
+func TestCandidatesOverride(t *testing.T) {
+	Device = "/dev/mei1"
+	defer func() { Device = "" }()
+	assert.Equal(t, []string{"/dev/mei1"}, Candidates())
+}
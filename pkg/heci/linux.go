@@ -13,6 +13,9 @@ import (
 	"encoding/binary"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"syscall"
 	"unsafe"
 
@@ -21,16 +24,17 @@ import (
 
 type Driver struct {
 	meiDevice  *os.File
+	devicePath string
 	bufferSize uint32
 }
 
 const (
-	Device                   = "/dev/mei0"
+	// DefaultDevice is used when no MEI devices are registered in sysfs
+	DefaultDevice            = "/dev/mei0"
+	SysfsPath                = "/sys/class/mei"
 	IOCTL_MEI_CONNECT_CLIENT = 0xC0104801
 )
 
-var MEI_IAMTHIF = [16]byte{0x28, 0x00, 0xf8, 0x12, 0xb7, 0xb4, 0x2d, 0x4b, 0xac, 0xa8, 0x46, 0xe0, 0xff, 0x65, 0x81, 0x4c}
-
 // uint8 == uchar
 type UUID_LE struct {
 	uuid [16]uint8
@@ -40,30 +44,94 @@ func NewDriver() *Driver {
 	return &Driver{}
 }
 
+// Devices lists the MEI device nodes registered in sysfs, falling back to DefaultDevice when none are found
+func Devices() []string {
+	return findDevices(SysfsPath)
+}
+
+func findDevices(sysfsPath string) []string {
+	entries, err := filepath.Glob(filepath.Join(sysfsPath, "mei*"))
+	if err != nil || len(entries) == 0 {
+		return []string{DefaultDevice}
+	}
+	// order numerically so that mei2 comes before mei10
+	sort.Slice(entries, func(i, j int) bool {
+		if len(entries[i]) != len(entries[j]) {
+			return len(entries[i]) < len(entries[j])
+		}
+		return entries[i] < entries[j]
+	})
+	devices := []string{}
+	for _, entry := range entries {
+		devices = append(devices, filepath.Join("/dev", filepath.Base(entry)))
+	}
+	return devices
+}
+
+// Candidates returns the device selected with Device, or every discovered device in the order Init tries them
+func Candidates() []string {
+	if Device != "" {
+		return []string{Device}
+	}
+	return Devices()
+}
+
+// Init connects to the AMTHI client
 func (heci *Driver) Init() error {
+	_, err := heci.Connect(AMTHI)
+	return err
+}
 
-	var err error
-	heci.meiDevice, err = os.OpenFile(Device, syscall.O_RDWR, 0)
+// Connect opens a connection to client on the first candidate device that exposes it
+func (heci *Driver) Connect(client Client) (ClientProperties, error) {
+	var firstErr error
+	candidates := Candidates()
+	for _, device := range candidates {
+		properties, err := heci.connect(device, client)
+		if err == nil {
+			return properties, nil
+		}
+		// report the first failure, later devices commonly belong to other ME interfaces
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if len(candidates) > 0 {
+		log.Println("Cannot connect to " + client.Name + " on " + strings.Join(candidates, ", "))
+	}
+	return ClientProperties{}, firstErr
+}
+
+func (heci *Driver) connect(device string, client Client) (ClientProperties, error) {
+	meiDevice, err := os.OpenFile(device, syscall.O_RDWR, 0)
 	if err != nil {
-		log.Println("Cannot open MEI Device")
-		return err
+		return ClientProperties{}, err
 	}
 
 	data := CMEIConnectClientData{}
-	data.data = MEI_IAMTHIF
-	err = Ioctl(heci.meiDevice.Fd(), IOCTL_MEI_CONNECT_CLIENT, uintptr(unsafe.Pointer(&data)))
+	data.data = client.GUID
+	err = Ioctl(meiDevice.Fd(), IOCTL_MEI_CONNECT_CLIENT, uintptr(unsafe.Pointer(&data)))
 	if err != nil {
-		return err
+		meiDevice.Close()
+		return ClientProperties{}, err
 	}
 	t := MEIConnectClientData{}
 	err = binary.Read(bytes.NewBuffer(data.data[:]), binary.LittleEndian, &t)
 	if err != nil {
-		return err
+		meiDevice.Close()
+		return ClientProperties{}, err
 	}
 
+	heci.meiDevice = meiDevice
+	heci.devicePath = device
 	heci.bufferSize = t.MaxMessageLength
 
-	return nil
+	return ClientProperties{MaxMessageLength: t.MaxMessageLength, ProtocolVersion: t.ProtocolVersion}, nil
+}
+
+// DevicePath returns the device node Init connected to
+func (heci *Driver) DevicePath() string {
+	return heci.devicePath
 }
 func (heci *Driver) GetBufferSize() uint32 {
 	return heci.bufferSize
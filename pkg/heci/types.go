@@ -1,7 +1,11 @@
 package heci
 
+// Device selects the MEI device node instead of discovering it, for example /dev/mei1. It is only used on Linux.
+var Device string
+
 type Interface interface {
 	Init() error
+	Connect(client Client) (ClientProperties, error)
 	GetBufferSize() uint32
 	SendMessage(buffer []byte, done *uint32) (bytesWritten uint32, err error)
 	ReceiveMessage(buffer []byte, done *uint32) (bytesRead uint32, err error)
@@ -30,7 +30,7 @@ type Driver struct {
 	meiDevice  windows.Handle
 	bufferSize uint32
 	GUID       windows.GUID
-	PTHIGUID   windows.GUID
+	ClientGUID windows.GUID
 }
 
 type HeciVersion struct {
@@ -47,20 +47,32 @@ func NewDriver() *Driver {
 	return &Driver{}
 }
 
+// Init connects to the AMTHI client
 func (heci *Driver) Init() error {
+	_, err := heci.Connect(AMTHI)
+	return err
+}
+
+// Connect opens the HECI device and connects to client
+func (heci *Driver) Connect(client Client) (ClientProperties, error) {
 	var err error
 	heci.GUID, err = windows.GUIDFromString("{E2D1FF34-3458-49A9-88DA-8E6915CE9BE5}")
 	if err != nil {
-		return err
+		return ClientProperties{}, err
 	}
-	heci.PTHIGUID, err = windows.GUIDFromString("{12F80028-B4B7-4B2D-ACA8-46E0FF65814C}")
-	if err != nil {
-		return err
+	heci.ClientGUID = windows.GUID{
+		Data1: binary.LittleEndian.Uint32(client.GUID[0:4]),
+		Data2: binary.LittleEndian.Uint16(client.GUID[4:6]),
+		Data3: binary.LittleEndian.Uint16(client.GUID[6:8]),
 	}
+	copy(heci.ClientGUID.Data4[:], client.GUID[8:])
 
 	// Find all devices that have our interface
 	err = heci.FindDevices(&heci.GUID)
-	return err
+	if err != nil {
+		return ClientProperties{}, err
+	}
+	return heci.ConnectHeciClient()
 }
 
 func (heci *Driver) FindDevices(guid *windows.GUID) error {
@@ -117,17 +129,7 @@ func (heci *Driver) FindDevices(guid *windows.GUID) error {
 		return err
 	}
 
-	err = heci.GetHeciVersion()
-	if err != nil {
-		return err
-	}
-
-	err = heci.ConnectHeciClient()
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return heci.GetHeciVersion()
 }
 
 func (heci *Driver) GetBufferSize() uint32 {
@@ -152,20 +154,20 @@ func (heci *Driver) GetHeciVersion() error {
 	return nil
 }
 
-func (heci *Driver) ConnectHeciClient() error {
+func (heci *Driver) ConnectHeciClient() (ClientProperties, error) {
 	properties := MEIConnectClientData{}
 	propertiesPacked := CMEIConnectClientData{}
 	propertiesSize := unsafe.Sizeof(propertiesPacked)
-	guidSize := unsafe.Sizeof(heci.PTHIGUID)
-	err := heci.doIoctl(ctl_code(FILE_DEVICE_HECI, 0x801, METHOD_BUFFERED, windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE), (*byte)(unsafe.Pointer(&heci.PTHIGUID)), (uint32)(guidSize), (*byte)(unsafe.Pointer(&propertiesPacked.data)), (uint32)(propertiesSize))
+	guidSize := unsafe.Sizeof(heci.ClientGUID)
+	err := heci.doIoctl(ctl_code(FILE_DEVICE_HECI, 0x801, METHOD_BUFFERED, windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE), (*byte)(unsafe.Pointer(&heci.ClientGUID)), (uint32)(guidSize), (*byte)(unsafe.Pointer(&propertiesPacked.data)), (uint32)(propertiesSize))
 	if err != nil {
-		return err
+		return ClientProperties{}, err
 	}
 	buf2 := bytes.NewBuffer(propertiesPacked.data[:])
 	binary.Read(buf2, binary.LittleEndian, &properties)
 	heci.bufferSize = properties.MaxMessageLength
 
-	return nil
+	return ClientProperties{MaxMessageLength: properties.MaxMessageLength, ProtocolVersion: properties.ProtocolVersion}, nil
 }
 
 func (heci *Driver) doIoctl(controlCode uint32, inBuf *byte, intsize uint32, outBuf *byte, outsize uint32) (err error) {
@@ -22,7 +22,7 @@ func TestInit(t *testing.T) {
 	pthiguid, _ := windows.GUIDFromString("{12F80028-B4B7-4B2D-ACA8-46E0FF65814C}")
 
 	assert.Equal(t, h.GUID, guid)
-	assert.Equal(t, h.PTHIGUID, pthiguid)
+	assert.Equal(t, h.ClientGUID, pthiguid)
 
 }
 
@@ -0,0 +1,181 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package mefw
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"rpc/pkg/heci"
+	"strconv"
+	"strings"
+)
+
+// SysfsPath is the sysfs class directory exposing fw_status and fw_ver for each MEI device
+const SysfsPath = "/sys/class/mei"
+
+// Status is the decoded content of the host firmware status registers (HFSTS1 and HFSTS2)
+type Status struct {
+	Registers         []string `json:"registers"`
+	WorkingState      string   `json:"workingState"`
+	OperationMode     string   `json:"operationMode"`
+	ErrorCode         string   `json:"errorCode"`
+	ManufacturingMode bool     `json:"manufacturingMode"`
+	InitComplete      bool     `json:"initComplete"`
+	UpdateInProgress  bool     `json:"updateInProgress"`
+	BootPhase         string   `json:"bootPhase"`
+	Versions          []string `json:"versions,omitempty"`
+}
+
+const (
+	WorkingStateNormal  = 5
+	OperationModeNormal = 0
+	ErrorCodeNone       = 0
+)
+
+var workingStates = map[uint32]string{
+	0: "Reset",
+	1: "Initializing",
+	2: "Recovery",
+	3: "Test",
+	4: "Disabled",
+	5: "Normal",
+	6: "Disable Wait",
+	7: "Transition",
+	8: "Invalid CPU",
+}
+
+var operationModes = map[uint32]string{
+	0: "Normal",
+	2: "Debug",
+	3: "Soft Temporary Disable",
+	4: "Security Override via Jumper",
+	5: "Security Override via MEI Message",
+}
+
+var errorCodes = map[uint32]string{
+	0: "No Error",
+	1: "Uncategorized Failure",
+	2: "Disabled",
+	3: "Image Failure",
+	4: "Debug Failure",
+}
+
+var bootPhases = map[uint32]string{
+	0: "ROM",
+	1: "Bring Up",
+	2: "Kernel",
+	3: "Policy Manager",
+	4: "Module Loading",
+	5: "Unknown",
+	6: "Host Communication",
+}
+
+// Decode interprets the raw firmware status registers. Only HFSTS1 and HFSTS2 are decoded.
+func Decode(registers []uint32) (Status, error) {
+	if len(registers) == 0 {
+		return Status{}, errors.New("no firmware status registers")
+	}
+	status := Status{}
+	for _, register := range registers {
+		status.Registers = append(status.Registers, strings.ToUpper(strconv.FormatUint(uint64(register), 16)))
+	}
+	hfsts1 := registers[0]
+	status.WorkingState = lookup(workingStates, hfsts1&0xF)
+	status.ManufacturingMode = hfsts1&(1<<4) != 0
+	status.InitComplete = hfsts1&(1<<9) != 0
+	status.UpdateInProgress = hfsts1&(1<<11) != 0
+	status.ErrorCode = lookup(errorCodes, (hfsts1>>12)&0xF)
+	status.OperationMode = lookup(operationModes, (hfsts1>>16)&0xF)
+	if len(registers) > 1 {
+		status.BootPhase = lookup(bootPhases, (registers[1]>>28)&0xF)
+	}
+	return status, nil
+}
+
+func lookup(values map[uint32]string, value uint32) string {
+	if name, ok := values[value]; ok {
+		return name
+	}
+	return "Unknown (" + strconv.Itoa(int(value)) + ")"
+}
+
+// Healthy reports whether the firmware is in its normal working state without errors
+func (s Status) Healthy() bool {
+	return s.WorkingState == workingStates[WorkingStateNormal] &&
+		s.ErrorCode == errorCodes[ErrorCodeNone] &&
+		s.OperationMode == operationModes[OperationModeNormal]
+}
+
+// String summarizes the status on a single line
+func (s Status) String() string {
+	summary := s.WorkingState + ", " + s.OperationMode + " mode, " + s.ErrorCode
+	if s.ManufacturingMode {
+		summary = summary + ", manufacturing mode"
+	}
+	if s.UpdateInProgress {
+		summary = summary + ", update in progress"
+	}
+	return summary
+}
+
+// ParseRegisters parses the content of a fw_status attribute, one hexadecimal register per line
+func ParseRegisters(data string) ([]uint32, error) {
+	registers := []uint32{}
+	for _, line := range strings.Fields(data) {
+		value, err := strconv.ParseUint(line, 16, 32)
+		if err != nil {
+			return nil, errors.New("invalid firmware status register " + line)
+		}
+		registers = append(registers, uint32(value))
+	}
+	return registers, nil
+}
+
+// ParseVersions parses the content of a fw_ver attribute into versions without the platform prefix
+func ParseVersions(data string) []string {
+	versions := []string{}
+	for _, line := range strings.Fields(data) {
+		if index := strings.Index(line, ":"); index >= 0 {
+			line = line[index+1:]
+		}
+		versions = append(versions, line)
+	}
+	return versions
+}
+
+// Read decodes the firmware status of the selected MEI device, or the first one found in sysfs
+func Read() (Status, error) {
+	return read(SysfsPath, heci.Device)
+}
+
+func read(sysfsPath string, device string) (Status, error) {
+	directory := ""
+	if device != "" {
+		directory = filepath.Join(sysfsPath, filepath.Base(device))
+	} else {
+		devices, err := filepath.Glob(filepath.Join(sysfsPath, "mei*"))
+		if err != nil || len(devices) == 0 {
+			return Status{}, errors.New("no MEI devices found in " + sysfsPath)
+		}
+		directory = devices[0]
+	}
+	data, err := ioutil.ReadFile(filepath.Join(directory, "fw_status"))
+	if err != nil {
+		return Status{}, err
+	}
+	registers, err := ParseRegisters(string(data))
+	if err != nil {
+		return Status{}, err
+	}
+	status, err := Decode(registers)
+	if err != nil {
+		return Status{}, err
+	}
+	if versions, err := ioutil.ReadFile(filepath.Join(directory, "fw_ver")); err == nil {
+		status.Versions = ParseVersions(string(versions))
+	}
+	return status, nil
+}
@@ -0,0 +1,93 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package mefw
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRegisters(t *testing.T) {
+	result, err := ParseRegisters("94000245\n09F10506\n00000020\n")
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{0x94000245, 0x09F10506, 0x20}, result)
+}
+
+func TestParseRegistersInvalid(t *testing.T) {
+	_, err := ParseRegisters("94000245\nnothex\n")
+	assert.Error(t, err)
+}
+
+func TestDecodeNormal(t *testing.T) {
+	result, err := Decode([]uint32{0x94000245, 0x09F10506})
+	assert.NoError(t, err)
+	assert.Equal(t, "Normal", result.WorkingState)
+	assert.Equal(t, "Normal", result.OperationMode)
+	assert.Equal(t, "No Error", result.ErrorCode)
+	assert.False(t, result.ManufacturingMode)
+	assert.True(t, result.InitComplete)
+	assert.Equal(t, "ROM", result.BootPhase)
+	assert.Equal(t, []string{"94000245", "9F10506"}, result.Registers)
+	assert.True(t, result.Healthy())
+	assert.Equal(t, "Normal, Normal mode, No Error", result.String())
+}
+
+func TestDecodeManufacturingMode(t *testing.T) {
+	result, err := Decode([]uint32{0x94000255, 0x69F10506})
+	assert.NoError(t, err)
+	assert.True(t, result.ManufacturingMode)
+	assert.Equal(t, "Host Communication", result.BootPhase)
+	assert.Contains(t, result.String(), "manufacturing mode")
+}
+
+func TestDecodeFailure(t *testing.T) {
+	result, err := Decode([]uint32{0x00033002})
+	assert.NoError(t, err)
+	assert.Equal(t, "Recovery", result.WorkingState)
+	assert.Equal(t, "Image Failure", result.ErrorCode)
+	assert.Equal(t, "Soft Temporary Disable", result.OperationMode)
+	assert.Equal(t, "", result.BootPhase)
+	assert.False(t, result.Healthy())
+}
+
+func TestDecodeUnknownValue(t *testing.T) {
+	result, err := Decode([]uint32{0x0000000C})
+	assert.NoError(t, err)
+	assert.Equal(t, "Unknown (12)", result.WorkingState)
+}
+
+func TestDecodeEmpty(t *testing.T) {
+	_, err := Decode([]uint32{})
+	assert.Error(t, err)
+}
+
+func TestParseVersions(t *testing.T) {
+	result := ParseVersions("0:15.0.35.2039\n0:15.0.35.2039\n0:15.0.22.1576\n")
+	assert.Equal(t, []string{"15.0.35.2039", "15.0.35.2039", "15.0.22.1576"}, result)
+}
+
+func TestRead(t *testing.T) {
+	sysfs := t.TempDir()
+	device := filepath.Join(sysfs, "mei0")
+	assert.NoError(t, os.Mkdir(device, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(device, "fw_status"), []byte("94000245\n09F10506\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(device, "fw_ver"), []byte("0:15.0.35.2039\n"), 0644))
+	result, err := read(sysfs, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "Normal", result.WorkingState)
+	assert.Equal(t, []string{"15.0.35.2039"}, result.Versions)
+
+	_, err = read(sysfs, "/dev/mei1")
+	assert.Error(t, err)
+}
+
+func TestReadNoDevices(t *testing.T) {
+	_, err := read(t.TempDir(), "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,166 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package mkhi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"rpc/pkg/heci"
+)
+
+type Command struct {
+	heci heci.Interface
+}
+
+type Interface interface {
+	Open() error
+	Close()
+	Call(command []byte, header MessageHeader) (result []byte, err error)
+	GetFirmwareVersion() (FWVersion, error)
+	GetFirmwareCapabilities() (uint32, error)
+	GetFeatureState() (uint32, error)
+	GetFirmwareStatus() ([]uint32, error)
+}
+
+func NewCommand() Command {
+	return Command{
+		heci: heci.NewDriver(),
+	}
+}
+
+func (mkhi Command) Open() error {
+	_, err := mkhi.heci.Connect(heci.MKHI)
+	return err
+}
+
+func (mkhi Command) Close() {
+	mkhi.heci.Close()
+}
+
+// Call sends command and validates the MKHI header of the response against the request header
+func (mkhi Command) Call(command []byte, header MessageHeader) (result []byte, err error) {
+	size := mkhi.heci.GetBufferSize()
+	commandSize := uint32(len(command))
+
+	bytesWritten, err := mkhi.heci.SendMessage(command, &commandSize)
+	if err != nil {
+		return nil, err
+	}
+	if bytesWritten != uint32(len(command)) {
+		return nil, errors.New("mkhi internal error")
+	}
+	readBuffer := make([]byte, size)
+	bytesRead, err := mkhi.heci.ReceiveMessage(readBuffer, &size)
+	if err != nil {
+		return nil, err
+	}
+	if bytesRead < uint32(binary.Size(MessageHeader{})) {
+		return nil, errors.New("empty response from MKHI")
+	}
+	response := MessageHeader{}
+	binary.Read(bytes.NewBuffer(readBuffer), binary.LittleEndian, &response)
+	if response.GroupID != header.GroupID || response.Command != header.Command|RESPONSE_BIT {
+		return nil, fmt.Errorf("unexpected MKHI response group 0x%02x command 0x%02x", response.GroupID, response.Command)
+	}
+	if response.Result != MKHI_STATUS_SUCCESS {
+		return nil, fmt.Errorf("MKHI command failed with status 0x%02x", response.Result)
+	}
+	return readBuffer[:bytesRead], nil
+}
+
+func CreateRequestHeader(group uint8, command uint8) MessageHeader {
+	return MessageHeader{
+		GroupID: group,
+		Command: command,
+	}
+}
+
+// GetFirmwareVersion returns the code, recovery and FITC firmware versions
+func (mkhi Command) GetFirmwareVersion() (FWVersion, error) {
+	command := GetFWVersionRequest{
+		Header: CreateRequestHeader(GEN_GROUP_ID, GEN_GET_FW_VERSION_CMD),
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, command)
+	result, err := mkhi.Call(bin_buf.Bytes(), command.Header)
+	if err != nil {
+		return FWVersion{}, err
+	}
+	response := GetFWVersionResponse{}
+	err = binary.Read(bytes.NewBuffer(result), binary.LittleEndian, &response)
+	if err != nil {
+		return FWVersion{}, errors.New("short MKHI firmware version response")
+	}
+	return response.Version, nil
+}
+
+// GetFirmwareStatus returns the host firmware status registers, starting with HFSTS1
+func (mkhi Command) GetFirmwareStatus() ([]uint32, error) {
+	command := GetFWStatusRequest{
+		Header: CreateRequestHeader(GEN_GROUP_ID, GEN_GET_FW_STATUS_CMD),
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, command)
+	result, err := mkhi.Call(bin_buf.Bytes(), command.Header)
+	if err != nil {
+		return nil, err
+	}
+	response := GetFWStatusResponse{}
+	err = binary.Read(bytes.NewBuffer(result), binary.LittleEndian, &response)
+	if err != nil {
+		return nil, errors.New("short MKHI firmware status response")
+	}
+	return response.Registers[:], nil
+}
+
+// GetFirmwareCapabilities returns the feature bits supported by this SKU
+func (mkhi Command) GetFirmwareCapabilities() (uint32, error) {
+	return mkhi.getRule(RULE_FW_CAPABILITIES)
+}
+
+// GetFeatureState returns the feature bits that are currently enabled
+func (mkhi Command) GetFeatureState() (uint32, error) {
+	return mkhi.getRule(RULE_FEATURE_STATE)
+}
+
+func (mkhi Command) getRule(ruleID uint32) (uint32, error) {
+	command := GetRuleRequest{
+		Header: CreateRequestHeader(FWCAPS_GROUP_ID, FWCAPS_GET_RULE_CMD),
+		RuleID: ruleID,
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, command)
+	result, err := mkhi.Call(bin_buf.Bytes(), command.Header)
+	if err != nil {
+		return 0, err
+	}
+	response := GetRuleResponse{}
+	err = binary.Read(bytes.NewBuffer(result), binary.LittleEndian, &response)
+	if err != nil {
+		return 0, errors.New("short MKHI rule response")
+	}
+	if response.RuleID != ruleID {
+		return 0, fmt.Errorf("unexpected rule %d in response", response.RuleID)
+	}
+	return response.RuleData, nil
+}
+
+// String formats the version as major.minor.hotfix.build
+func (v VersionBlock) String() string {
+	return fmt.Sprintf("%d.%d.%d.%d", v.Major, v.Minor, v.Hotfix, v.Build)
+}
+
+// DecodeFeatures returns the names of the features set in a capability or feature state rule
+func DecodeFeatures(bits uint32) []string {
+	names := []string{}
+	for _, feature := range Features {
+		if bits&feature.Mask != 0 {
+			names = append(names, feature.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,124 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package mkhi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"rpc/pkg/heci"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockHECICommands struct{}
+
+var message []byte
+
+func (c *MockHECICommands) Init() error { return nil }
+func (c *MockHECICommands) Connect(client heci.Client) (heci.ClientProperties, error) {
+	return heci.ClientProperties{MaxMessageLength: 512}, nil
+}
+func (c *MockHECICommands) GetBufferSize() uint32 { return 512 }
+func (c *MockHECICommands) SendMessage(buffer []byte, done *uint32) (bytesWritten uint32, err error) {
+	return uint32(len(buffer)), nil
+}
+func (c *MockHECICommands) ReceiveMessage(buffer []byte, done *uint32) (bytesRead uint32, err error) {
+	return uint32(copy(buffer, message)), nil
+}
+func (c *MockHECICommands) Close() {}
+
+var mkhi = Command{heci: &MockHECICommands{}}
+
+func prepareMessage(response interface{}) {
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, response)
+	message = bin_buf.Bytes()
+}
+
+func TestGetFirmwareVersion(t *testing.T) {
+	prepareMessage(GetFWVersionResponse{
+		Header: MessageHeader{GroupID: GEN_GROUP_ID, Command: GEN_GET_FW_VERSION_CMD | RESPONSE_BIT},
+		Version: FWVersion{
+			Code:     VersionBlock{Major: 16, Minor: 1, Hotfix: 25, Build: 1865},
+			Recovery: VersionBlock{Major: 16, Minor: 1, Hotfix: 25, Build: 1865},
+		},
+	})
+	result, err := mkhi.GetFirmwareVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, "16.1.25.1865", result.Code.String())
+	assert.Equal(t, "16.1.25.1865", result.Recovery.String())
+	assert.Equal(t, "0.0.0.0", result.FITC.String())
+}
+
+func TestGetFirmwareVersionFailed(t *testing.T) {
+	prepareMessage(GetFWVersionResponse{
+		Header: MessageHeader{GroupID: GEN_GROUP_ID, Command: GEN_GET_FW_VERSION_CMD | RESPONSE_BIT, Result: 0x89},
+	})
+	_, err := mkhi.GetFirmwareVersion()
+	assert.EqualError(t, err, "MKHI command failed with status 0x89")
+}
+
+func TestGetFirmwareVersionUnexpectedResponse(t *testing.T) {
+	prepareMessage(GetFWVersionResponse{
+		Header: MessageHeader{GroupID: FWCAPS_GROUP_ID, Command: FWCAPS_GET_RULE_CMD | RESPONSE_BIT},
+	})
+	_, err := mkhi.GetFirmwareVersion()
+	assert.Error(t, err)
+}
+
+func TestGetFirmwareVersionShortResponse(t *testing.T) {
+	prepareMessage(MessageHeader{GroupID: GEN_GROUP_ID, Command: GEN_GET_FW_VERSION_CMD | RESPONSE_BIT})
+	_, err := mkhi.GetFirmwareVersion()
+	assert.Error(t, err)
+}
+
+func TestGetFirmwareStatus(t *testing.T) {
+	prepareMessage(GetFWStatusResponse{
+		Header:    MessageHeader{GroupID: GEN_GROUP_ID, Command: GEN_GET_FW_STATUS_CMD | RESPONSE_BIT},
+		Registers: [FW_STATUS_REGISTERS]uint32{0x94000245, 0x09F10506, 0x20},
+	})
+	result, err := mkhi.GetFirmwareStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{0x94000245, 0x09F10506, 0x20, 0, 0, 0}, result)
+}
+
+func TestGetFirmwareStatusFailed(t *testing.T) {
+	prepareMessage(GetFWStatusResponse{
+		Header: MessageHeader{GroupID: GEN_GROUP_ID, Command: GEN_GET_FW_STATUS_CMD | RESPONSE_BIT, Result: 0x8D},
+	})
+	_, err := mkhi.GetFirmwareStatus()
+	assert.EqualError(t, err, "MKHI command failed with status 0x8d")
+}
+
+func TestGetFirmwareStatusShortResponse(t *testing.T) {
+	prepareMessage(GetRuleResponse{
+		Header: MessageHeader{GroupID: GEN_GROUP_ID, Command: GEN_GET_FW_STATUS_CMD | RESPONSE_BIT},
+	})
+	_, err := mkhi.GetFirmwareStatus()
+	assert.EqualError(t, err, "short MKHI firmware status response")
+}
+
+func TestGetFirmwareCapabilities(t *testing.T) {
+	prepareMessage(GetRuleResponse{
+		Header:     MessageHeader{GroupID: FWCAPS_GROUP_ID, Command: FWCAPS_GET_RULE_CMD | RESPONSE_BIT},
+		RuleID:     RULE_FW_CAPABILITIES,
+		RuleLength: 4,
+		RuleData:   0x0000400C,
+	})
+	result, err := mkhi.GetFirmwareCapabilities()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0x0000400C), result)
+	assert.Equal(t, []string{"ASF", "Intel AMT", "Corporate"}, DecodeFeatures(result))
+}
+
+func TestGetFeatureStateWrongRule(t *testing.T) {
+	prepareMessage(GetRuleResponse{
+		Header: MessageHeader{GroupID: FWCAPS_GROUP_ID, Command: FWCAPS_GET_RULE_CMD | RESPONSE_BIT},
+		RuleID: RULE_FW_CAPABILITIES,
+	})
+	_, err := mkhi.GetFeatureState()
+	assert.Error(t, err)
+}
@@ -0,0 +1,89 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package mkhi
+
+const GEN_GROUP_ID = 0xFF
+const FWCAPS_GROUP_ID = 0x03
+
+const GEN_GET_FW_VERSION_CMD = 0x02
+const GEN_GET_FW_STATUS_CMD = 0x0B
+const FWCAPS_GET_RULE_CMD = 0x02
+
+// RESPONSE_BIT is set in the command field of every MKHI response
+const RESPONSE_BIT = 0x80
+
+const MKHI_STATUS_SUCCESS = 0x00
+
+// FWCAPS rule identifiers
+const RULE_FW_CAPABILITIES = 0
+const RULE_FEATURE_STATE = 32
+
+type MessageHeader struct {
+	GroupID  uint8
+	Command  uint8
+	Reserved uint8
+	Result   uint8
+}
+
+type GetFWVersionRequest struct {
+	Header MessageHeader
+}
+
+type VersionBlock struct {
+	Minor  uint16
+	Major  uint16
+	Build  uint16
+	Hotfix uint16
+}
+
+type FWVersion struct {
+	Code     VersionBlock
+	Recovery VersionBlock
+	FITC     VersionBlock
+}
+
+type GetFWVersionResponse struct {
+	Header  MessageHeader
+	Version FWVersion
+}
+
+// FW_STATUS_REGISTERS is the number of host firmware status registers (HFSTS1 to HFSTS6) in a FW status response
+const FW_STATUS_REGISTERS = 6
+
+type GetFWStatusRequest struct {
+	Header MessageHeader
+}
+
+type GetFWStatusResponse struct {
+	Header    MessageHeader
+	Registers [FW_STATUS_REGISTERS]uint32
+}
+
+type GetRuleRequest struct {
+	Header MessageHeader
+	RuleID uint32
+}
+
+type GetRuleResponse struct {
+	Header     MessageHeader
+	RuleID     uint32
+	RuleLength uint8
+	RuleData   uint32
+}
+
+// Feature is a named bit in the FW capabilities and feature state rules
+type Feature struct {
+	Mask uint32
+	Name string
+}
+
+var Features = []Feature{
+	{Mask: 0x00000004, Name: "ASF"},
+	{Mask: 0x00000008, Name: "Intel AMT"},
+	{Mask: 0x00000010, Name: "Intel Standard Manageability"},
+	{Mask: 0x00000020, Name: "Small Business Technology"},
+	{Mask: 0x00002000, Name: "Anti-Theft"},
+	{Mask: 0x00004000, Name: "Corporate"},
+}
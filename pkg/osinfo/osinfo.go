@@ -0,0 +1,52 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package osinfo
+
+import (
+	"net"
+	"runtime"
+)
+
+// Info describes the host operating system
+type Info struct {
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	Kernel       string `json:"kernel"`
+	Architecture string `json:"architecture"`
+}
+
+// NetworkInterface is an OS network interface with a hardware address
+type NetworkInterface struct {
+	Name       string `json:"name"`
+	MACAddress string `json:"macAddress"`
+}
+
+// Disk is a block device backed by hardware
+type Disk struct {
+	Name         string `json:"name"`
+	Model        string `json:"model"`
+	SerialNumber string `json:"serialNumber"`
+	SizeBytes    uint64 `json:"sizeBytes"`
+}
+
+// Read returns the operating system name, version and kernel
+func Read() Info {
+	info := read()
+	info.Architecture = runtime.GOARCH
+	return info
+}
+
+// NetworkInterfaces lists every interface that has a MAC address, skipping loopback
+func NetworkInterfaces() []NetworkInterface {
+	result := []NetworkInterface{}
+	ifaces, _ := net.Interfaces()
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 || len(iface.HardwareAddr) == 0 {
+			continue
+		}
+		result = append(result, NetworkInterface{Name: iface.Name, MACAddress: iface.HardwareAddr.String()})
+	}
+	return result
+}
@@ -0,0 +1,104 @@
+//go:build linux
+// +build linux
+
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package osinfo
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	OSReleasePath = "/etc/os-release"
+	KernelPath    = "/proc/sys/kernel/osrelease"
+	BlockPath     = "/sys/block"
+)
+
+// sectorSize is the unit of the sysfs block device size
+const sectorSize = 512
+
+func read() Info {
+	return readFiles(OSReleasePath, KernelPath)
+}
+
+func readFiles(osReleasePath string, kernelPath string) Info {
+	info := Info{Name: "Linux"}
+	if data, err := ioutil.ReadFile(osReleasePath); err == nil {
+		values := parseOSRelease(data)
+		if name, ok := values["NAME"]; ok {
+			info.Name = name
+		}
+		info.Version = values["VERSION_ID"]
+	}
+	if data, err := ioutil.ReadFile(kernelPath); err == nil {
+		info.Kernel = strings.TrimSpace(string(data))
+	}
+	return info
+}
+
+// parseOSRelease reads the KEY=value pairs of os-release, removing quotes
+func parseOSRelease(data []byte) map[string]string {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		values[parts[0]] = strings.Trim(parts[1], "\"'")
+	}
+	return values
+}
+
+// Disks lists the block devices backed by hardware, skipping loop, RAM and device mapper devices
+func Disks() ([]Disk, error) {
+	return readDisks(BlockPath)
+}
+
+func readDisks(blockPath string) ([]Disk, error) {
+	entries, err := ioutil.ReadDir(blockPath)
+	if err != nil {
+		return nil, err
+	}
+	readValue := func(name string, file string) string {
+		data, err := ioutil.ReadFile(filepath.Join(blockPath, name, file))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(data))
+	}
+	disks := []Disk{}
+	for _, entry := range entries {
+		name := entry.Name()
+		// virtual devices have no device link
+		if _, err := os.Stat(filepath.Join(blockPath, name, "device")); err != nil {
+			continue
+		}
+		sectors, _ := strconv.ParseUint(readValue(name, "size"), 10, 64)
+		serial := readValue(name, "device/serial")
+		// SCSI and SATA disks expose the unit serial number VPD page, which starts with a 4 byte header
+		if page, err := ioutil.ReadFile(filepath.Join(blockPath, name, "device", "vpd_pg80")); serial == "" && err == nil && len(page) > 4 {
+			serial = string(page[4:])
+		}
+		disks = append(disks, Disk{
+			Name:         name,
+			Model:        readValue(name, "device/model"),
+			SerialNumber: strings.TrimSpace(strings.Trim(serial, "\x00")),
+			SizeBytes:    sectors * sectorSize,
+		})
+	}
+	return disks, nil
+}
@@ -0,0 +1,57 @@
+//go:build linux
+// +build linux
+
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package osinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadFiles(t *testing.T) {
+	dir := t.TempDir()
+	osRelease := filepath.Join(dir, "os-release")
+	kernel := filepath.Join(dir, "osrelease")
+	ioutil.WriteFile(osRelease, []byte("# comment\nNAME=\"Ubuntu\"\nVERSION_ID=\"20.04\"\nPRETTY_NAME='Ubuntu 20.04.3 LTS'\n"), 0644)
+	ioutil.WriteFile(kernel, []byte("5.11.0-27-generic\n"), 0644)
+	info := readFiles(osRelease, kernel)
+	assert.Equal(t, Info{Name: "Ubuntu", Version: "20.04", Kernel: "5.11.0-27-generic"}, info)
+}
+
+func TestReadFilesMissing(t *testing.T) {
+	info := readFiles(filepath.Join(t.TempDir(), "missing"), filepath.Join(t.TempDir(), "missing"))
+	assert.Equal(t, Info{Name: "Linux"}, info)
+}
+
+func TestReadDisks(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "nvme0n1", "device"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "nvme0n1", "size"), []byte("1000215216\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "nvme0n1", "device", "model"), []byte("SAMSUNG MZVLB512HBJQ-000L7              \n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "nvme0n1", "device", "serial"), []byte("S4ENNF0M123456      \n"), 0644)
+	os.MkdirAll(filepath.Join(dir, "sda", "device"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "sda", "size"), []byte("1953525168\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "sda", "device", "model"), []byte("WDC WD10EZEX-08W\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "sda", "device", "vpd_pg80"), []byte("\x00\x80\x00\x14     WD-WCC6Y1234567"), 0644)
+	os.MkdirAll(filepath.Join(dir, "loop0"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "loop0", "size"), []byte("8\n"), 0644)
+	disks, err := readDisks(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []Disk{
+		{Name: "nvme0n1", Model: "SAMSUNG MZVLB512HBJQ-000L7", SerialNumber: "S4ENNF0M123456", SizeBytes: 512110190592},
+		{Name: "sda", Model: "WDC WD10EZEX-08W", SerialNumber: "WD-WCC6Y1234567", SizeBytes: 1000204886016},
+	}, disks)
+}
+
+func TestReadDisksMissing(t *testing.T) {
+	_, err := readDisks(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
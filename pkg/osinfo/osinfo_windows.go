@@ -0,0 +1,29 @@
+//go:build windows
+// +build windows
+
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package osinfo
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+func read() Info {
+	version := windows.RtlGetVersion()
+	return Info{
+		Name:    "Windows",
+		Version: fmt.Sprintf("%d.%d", version.MajorVersion, version.MinorVersion),
+		Kernel:  fmt.Sprintf("%d.%d.%d", version.MajorVersion, version.MinorVersion, version.BuildNumber),
+	}
+}
+
+// Disks is not implemented on Windows
+func Disks() ([]Disk, error) {
+	return nil, errors.New("disk inventory is not supported on Windows")
+}
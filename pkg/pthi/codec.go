@@ -0,0 +1,191 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package pthi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// RESPONSE_BIT is set in the command code of every PTHI response
+const RESPONSE_BIT = 0x00800000
+
+var headerSize = binary.Size(MessageHeader{})
+var responseHeaderSize = binary.Size(ResponseMessageHeader{})
+
+// responseTypes maps each request code to the response struct it returns
+var responseTypes = map[uint32]reflect.Type{}
+
+// register associates a request code with its response struct, which must start with a ResponseMessageHeader named Header
+func register(request uint32, response interface{}) {
+	responseType := reflect.TypeOf(response)
+	if responseType.Kind() != reflect.Struct || responseType.NumField() == 0 ||
+		responseType.Field(0).Name != "Header" || responseType.Field(0).Type != reflect.TypeOf(ResponseMessageHeader{}) {
+		panic("pthi: " + responseType.Name() + " must start with Header ResponseMessageHeader")
+	}
+	responseTypes[request] = responseType
+}
+
+func init() {
+	register(CODE_VERSIONS_REQUEST, GetCodeVersionsResponse{})
+	register(GET_UUID_REQUEST, GetUUIDResponse{})
+	register(GET_CONTROL_MODE_REQUEST, GetControlModeResponse{})
+	register(GET_PKI_FQDN_SUFFIX_REQUEST, GetPKIFQDNSuffixResponse{})
+	register(ENUMERATE_HASH_HANDLES_REQUEST, GetHashHandlesResponse{})
+	register(GET_CERTHASH_ENTRY_REQUEST, GetCertHashEntryResponse{})
+	register(GET_REMOTE_ACCESS_CONNECTION_STATUS_REQUEST, GetRemoteAccessConnectionStatusResponse{})
+	register(GET_LAN_INTERFACE_SETTINGS_REQUEST, GetLANInterfaceSettingsResponse{})
+	register(GET_IPV6_LAN_INTERFACE_STATUS_REQUEST, GetIPv6LANInterfaceStatusResponse{})
+	register(GET_LOCAL_SYSTEM_ACCOUNT_REQUEST, GetLocalSystemAccountResponse{})
+}
+
+// Marshal encodes request, a pointer to a struct whose first field is Header, and fills in the header command and length
+func Marshal(command uint32, request interface{}) ([]byte, error) {
+	value := reflect.ValueOf(request)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("request must be a pointer to a struct")
+	}
+	header := value.Elem().FieldByName("Header")
+	if !header.IsValid() || header.Type() != reflect.TypeOf(MessageHeader{}) {
+		return nil, errors.New("request must have a Header field of type MessageHeader")
+	}
+	size := binary.Size(request)
+	if size < headerSize {
+		return nil, errors.New("request has an unsupported field type")
+	}
+	header.Set(reflect.ValueOf(CreateRequestHeader(command, uint32(size-headerSize))))
+	var bin_buf bytes.Buffer
+	err := binary.Write(&bin_buf, binary.LittleEndian, request)
+	if err != nil {
+		return nil, err
+	}
+	return bin_buf.Bytes(), nil
+}
+
+// Unmarshal validates the response header for command and decodes data into response.
+// Every fixed size field must be present. Only the trailing array of a response may be
+// shorter on the wire, by what its preceding length or count field leaves unused, and it
+// is zero filled. Responses with lengths or enumerations are validated before returning.
+func Unmarshal(command uint32, data []byte, response interface{}) error {
+	expected, ok := responseTypes[command]
+	if !ok {
+		return fmt.Errorf("command 0x%08x is not registered", command)
+	}
+	value := reflect.ValueOf(response)
+	if value.Kind() != reflect.Ptr || value.Elem().Type() != expected {
+		return fmt.Errorf("command 0x%08x returns %s", command, expected.Name())
+	}
+	if len(data) < responseHeaderSize {
+		return fmt.Errorf("response of %d bytes is shorter than the header", len(data))
+	}
+	header := readHeaderResponse(bytes.NewBuffer(data))
+	if header.Header.Command.val != command|RESPONSE_BIT {
+		return fmt.Errorf("unexpected response 0x%08x to command 0x%08x", header.Header.Command.val, command)
+	}
+	if header.Status != AMT_STATUS_SUCCESS {
+		return fmt.Errorf("command 0x%08x failed with AMT status %d", command, header.Status)
+	}
+	if uint64(headerSize)+uint64(header.Header.Length) > uint64(len(data)) {
+		return fmt.Errorf("response declares %d bytes but only %d were received", header.Header.Length, len(data)-headerSize)
+	}
+	size := binary.Size(response)
+	if required := requiredSize(expected, data); len(data) < required {
+		return fmt.Errorf("response of %d bytes is shorter than the %d bytes %s requires", len(data), required, expected.Name())
+	}
+	if len(data) < size {
+		padded := make([]byte, size)
+		copy(padded, data)
+		data = padded
+	}
+	// the header has unexported fields, so it is decoded separately from the body
+	body := value.Elem()
+	reader := bytes.NewReader(data[responseHeaderSize:])
+	body.Field(0).Set(reflect.ValueOf(header))
+	for i := 1; i < body.NumField(); i++ {
+		err := binary.Read(reader, binary.LittleEndian, body.Field(i).Addr().Interface())
+		if err != nil {
+			return err
+		}
+	}
+	if v, ok := value.Elem().Interface().(validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// requiredSize returns how many bytes of a response of type t must be on the wire. When the
+// last field, or the last field of a trailing struct, is an array preceded by an unsigned
+// length or count, only the elements that length covers are required.
+func requiredSize(t reflect.Type, data []byte) int {
+	size := binary.Size(reflect.New(t).Elem().Interface())
+	for t.Kind() == reflect.Struct && t.NumField() > 1 {
+		last := t.Field(t.NumField() - 1).Type
+		if last.Kind() == reflect.Struct {
+			t = last
+			continue
+		}
+		count := t.Field(t.NumField() - 2).Type
+		if last.Kind() != reflect.Array || !isUnsigned(count.Kind()) {
+			break
+		}
+		elementSize := binary.Size(reflect.New(last.Elem()).Elem().Interface())
+		fixed := size - last.Len()*elementSize
+		countSize := int(count.Size())
+		if len(data) < fixed {
+			return fixed
+		}
+		length := readUnsigned(data[fixed-countSize : fixed])
+		// a length beyond the array is reported by Validate, so only the whole array is required here
+		if length > uint64(last.Len()) {
+			length = uint64(last.Len())
+		}
+		return fixed + int(length)*elementSize
+	}
+	return size
+}
+
+func isUnsigned(kind reflect.Kind) bool {
+	return kind == reflect.Uint8 || kind == reflect.Uint16 || kind == reflect.Uint32
+}
+
+func readUnsigned(data []byte) uint64 {
+	switch len(data) {
+	case 1:
+		return uint64(data[0])
+	case 2:
+		return uint64(binary.LittleEndian.Uint16(data))
+	default:
+		return uint64(binary.LittleEndian.Uint32(data))
+	}
+}
+
+func readHeaderResponse(header *bytes.Buffer) ResponseMessageHeader {
+	response := ResponseMessageHeader{}
+
+	binary.Read(header, binary.LittleEndian, &response.Header.Version.MajorNumber)
+	binary.Read(header, binary.LittleEndian, &response.Header.Version.MinorNumber)
+	binary.Read(header, binary.LittleEndian, &response.Header.Reserved)
+	binary.Read(header, binary.LittleEndian, &response.Header.Command.val)
+	binary.Read(header, binary.LittleEndian, &response.Header.Length)
+	binary.Read(header, binary.LittleEndian, &response.Status)
+
+	return response
+}
+
+// send marshals request, calls the firmware and unmarshals the reply into response
+func (pthi Command) send(command uint32, request interface{}, response interface{}) error {
+	data, err := Marshal(command, request)
+	if err != nil {
+		return err
+	}
+	result, err := pthi.Call(data, uint32(len(data)))
+	if err != nil {
+		return err
+	}
+	return Unmarshal(command, result, response)
+}
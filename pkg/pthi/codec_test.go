@@ -0,0 +1,172 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package pthi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encode(data interface{}) []byte {
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, data)
+	return bin_buf.Bytes()
+}
+
+func TestMarshalComputesLength(t *testing.T) {
+	request := GetLocalSystemAccountRequest{}
+	result, err := Marshal(GET_LOCAL_SYSTEM_ACCOUNT_REQUEST, &request)
+	assert.NoError(t, err)
+	assert.Equal(t, 52, len(result))
+	assert.Equal(t, uint32(40), request.Header.Length)
+	assert.Equal(t, uint32(GET_LOCAL_SYSTEM_ACCOUNT_REQUEST), request.Header.Command.val)
+	assert.Equal(t, uint32(40), binary.LittleEndian.Uint32(result[8:12]))
+
+	lanRequest := GetLANInterfaceSettingsRequest{InterfaceIndex: 1}
+	result, err = Marshal(GET_LAN_INTERFACE_SETTINGS_REQUEST, &lanRequest)
+	assert.NoError(t, err)
+	assert.Equal(t, 16, len(result))
+	assert.Equal(t, uint32(4), lanRequest.Header.Length)
+}
+
+func TestMarshalInvalidRequest(t *testing.T) {
+	_, err := Marshal(GET_UUID_REQUEST, GetRequest{})
+	assert.Error(t, err)
+	_, err = Marshal(GET_UUID_REQUEST, &struct{ Value uint32 }{})
+	assert.Error(t, err)
+}
+
+func TestUnmarshal(t *testing.T) {
+	data := encode(GetControlModeResponse{
+		Header: responseHeader(GET_CONTROL_MODE_REQUEST, GetControlModeResponse{}),
+		State:  2,
+	})
+	response := GetControlModeResponse{}
+	err := Unmarshal(GET_CONTROL_MODE_REQUEST, data, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), response.State)
+	assert.Equal(t, uint32(GET_CONTROL_MODE_RESPONSE), response.Header.Header.Command.val)
+}
+
+func TestUnmarshalVariableLength(t *testing.T) {
+	header := ResponseMessageHeader{}
+	header.Header.Command.val = GET_PKI_FQDN_SUFFIX_RESPONSE
+	header.Header.Length = 4 + 2 + 3
+	data := append(encode(header), encode(uint16(3))...)
+	data = append(data, []byte("abc")...)
+	response := GetPKIFQDNSuffixResponse{}
+	err := Unmarshal(GET_PKI_FQDN_SUFFIX_REQUEST, data, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", string(response.Suffix.Buffer[:response.Suffix.Length]))
+}
+
+func TestUnmarshalWrongCommand(t *testing.T) {
+	data := encode(GetControlModeResponse{
+		Header: responseHeader(GET_UUID_REQUEST, GetControlModeResponse{}),
+	})
+	err := Unmarshal(GET_CONTROL_MODE_REQUEST, data, &GetControlModeResponse{})
+	assert.EqualError(t, err, "unexpected response 0x0480005c to command 0x0400006b")
+}
+
+func TestUnmarshalTruncated(t *testing.T) {
+	data := encode(GetControlModeResponse{
+		Header: responseHeader(GET_CONTROL_MODE_REQUEST, GetControlModeResponse{}),
+	})
+	err := Unmarshal(GET_CONTROL_MODE_REQUEST, data[:14], &GetControlModeResponse{})
+	assert.Error(t, err)
+	err = Unmarshal(GET_CONTROL_MODE_REQUEST, data[:10], &GetControlModeResponse{})
+	assert.Error(t, err)
+}
+
+func TestUnmarshalHeaderOnly(t *testing.T) {
+	header := responseHeader(GET_CONTROL_MODE_REQUEST, GetControlModeResponse{})
+	header.Header.Length = 4
+	err := Unmarshal(GET_CONTROL_MODE_REQUEST, encode(header), &GetControlModeResponse{})
+	assert.EqualError(t, err, "response of 16 bytes is shorter than the 20 bytes GetControlModeResponse requires")
+
+	header = responseHeader(GET_UUID_REQUEST, GetUUIDResponse{})
+	data := encode(GetUUIDResponse{Header: header})
+	err = Unmarshal(GET_UUID_REQUEST, data[:20], &GetUUIDResponse{})
+	assert.Error(t, err)
+}
+
+func TestUnmarshalTruncatedVariableLength(t *testing.T) {
+	header := ResponseMessageHeader{}
+	header.Header.Command.val = GET_PKI_FQDN_SUFFIX_RESPONSE
+	header.Header.Length = 4 + 2 + 2
+	data := append(encode(header), encode(uint16(3))...)
+	data = append(data, []byte("ab")...)
+	err := Unmarshal(GET_PKI_FQDN_SUFFIX_REQUEST, data, &GetPKIFQDNSuffixResponse{})
+	assert.EqualError(t, err, "response of 20 bytes is shorter than the 21 bytes GetPKIFQDNSuffixResponse requires")
+
+	// the string length itself is part of the fixed size
+	header.Header.Length = 4 + 1
+	err = Unmarshal(GET_PKI_FQDN_SUFFIX_REQUEST, append(encode(header), 3), &GetPKIFQDNSuffixResponse{})
+	assert.EqualError(t, err, "response of 17 bytes is shorter than the 18 bytes GetPKIFQDNSuffixResponse requires")
+}
+
+func TestUnmarshalTrailingCount(t *testing.T) {
+	response := GetCodeVersionsResponse{
+		Header: responseHeader(CODE_VERSIONS_REQUEST, GetCodeVersionsResponse{}),
+	}
+	response.CodeVersion.VersionsCount = 1
+	response.CodeVersion.Versions[0].Version = AMTUnicodeString{Length: 2, String: [20]uint8{49, 49}}
+	entrySize := binary.Size(AMTVersionType{})
+	response.Header.Header.Length -= uint32((VERSIONS_NUMBER - 1) * entrySize)
+	data := encode(response)[:binary.Size(response)-(VERSIONS_NUMBER-1)*entrySize]
+	result := GetCodeVersionsResponse{}
+	assert.NoError(t, Unmarshal(CODE_VERSIONS_REQUEST, data, &result))
+	assert.Equal(t, uint16(2), result.CodeVersion.Versions[0].Version.Length)
+
+	response.Header.Header.Length--
+	err := Unmarshal(CODE_VERSIONS_REQUEST, encode(response)[:len(data)-1], &GetCodeVersionsResponse{})
+	assert.EqualError(t, err, "response of 128 bytes is shorter than the 129 bytes GetCodeVersionsResponse requires")
+}
+
+func TestUnmarshalStatus(t *testing.T) {
+	response := GetControlModeResponse{
+		Header: responseHeader(GET_CONTROL_MODE_REQUEST, GetControlModeResponse{}),
+	}
+	response.Header.Status = 1
+	err := Unmarshal(GET_CONTROL_MODE_REQUEST, encode(response), &GetControlModeResponse{})
+	assert.EqualError(t, err, "command 0x0400006b failed with AMT status 1")
+
+	// a failed command commonly replies with the header alone
+	err = Unmarshal(GET_CONTROL_MODE_REQUEST, encode(response.Header), &GetControlModeResponse{})
+	assert.EqualError(t, err, "command 0x0400006b failed with AMT status 1")
+}
+
+func TestUnmarshalUnregistered(t *testing.T) {
+	err := Unmarshal(GET_FEATURES_STATE_REQUEST, []byte{}, &GetControlModeResponse{})
+	assert.Error(t, err)
+	err = Unmarshal(GET_CONTROL_MODE_REQUEST, []byte{}, &GetUUIDResponse{})
+	assert.EqualError(t, err, "command 0x0400006b returns GetControlModeResponse")
+}
+
+func TestUnmarshalStringLengthOutOfRange(t *testing.T) {
+	response := GetPKIFQDNSuffixResponse{
+		Header: responseHeader(GET_PKI_FQDN_SUFFIX_REQUEST, GetPKIFQDNSuffixResponse{}),
+	}
+	response.Suffix.Length = 1001
+	err := Unmarshal(GET_PKI_FQDN_SUFFIX_REQUEST, encode(response), &GetPKIFQDNSuffixResponse{})
+	assert.EqualError(t, err, "string length 1001 exceeds 1000 bytes")
+}
+
+func TestUnmarshalVersionCountOutOfRange(t *testing.T) {
+	response := GetCodeVersionsResponse{
+		Header: responseHeader(CODE_VERSIONS_REQUEST, GetCodeVersionsResponse{}),
+	}
+	response.CodeVersion.VersionsCount = 51
+	err := Unmarshal(CODE_VERSIONS_REQUEST, encode(response), &GetCodeVersionsResponse{})
+	assert.EqualError(t, err, "version count 51 exceeds 50 entries")
+
+	response.CodeVersion.VersionsCount = 1
+	response.CodeVersion.Versions[0].Version.Length = 21
+	err = Unmarshal(CODE_VERSIONS_REQUEST, encode(response), &GetCodeVersionsResponse{})
+	assert.EqualError(t, err, "string length 21 exceeds 20 bytes")
+}
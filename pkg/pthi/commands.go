@@ -5,8 +5,6 @@
 package pthi
 
 import (
-	"bytes"
-	"encoding/binary"
 	"errors"
 	"rpc/pkg/heci"
 )
@@ -26,6 +24,7 @@ type Interface interface {
 	GetCertificateHashes(hashHandles AMTHashHandles) (hashEntryList []CertHashEntry, err error)
 	GetRemoteAccessConnectionStatus() (RAStatus GetRemoteAccessConnectionStatusResponse, err error)
 	GetLANInterfaceSettings(useWireless bool) (LANInterface GetLANInterfaceSettingsResponse, err error)
+	GetIPv6LANInterfaceStatus(useWireless bool) (IPv6Status GetIPv6LANInterfaceStatusResponse, err error)
 	GetLocalSystemAccount() (localAccount GetLocalSystemAccountResponse, err error)
 }
 
@@ -66,7 +65,10 @@ func (pthi Command) Call(command []byte, commandSize uint32) (result []byte, err
 	if bytesRead == 0 {
 		return nil, errors.New("empty response from AMT")
 	}
-	return readBuffer, nil
+	if bytesRead > size {
+		return nil, errors.New("response from AMT exceeds the buffer size")
+	}
+	return readBuffer[:bytesRead], nil
 }
 
 func CreateRequestHeader(command uint32, length uint32) MessageHeader {
@@ -84,121 +86,50 @@ func CreateRequestHeader(command uint32, length uint32) MessageHeader {
 }
 
 func (pthi Command) GetCodeVersions() (GetCodeVersionsResponse, error) {
-	command := GetRequest{
-		Header: CreateRequestHeader(CODE_VERSIONS_REQUEST, 0),
-	}
-	var bin_buf bytes.Buffer
-	binary.Write(&bin_buf, binary.LittleEndian, command)
-	result, err := pthi.Call(bin_buf.Bytes(), GET_REQUEST_SIZE)
+	response := GetCodeVersionsResponse{}
+	err := pthi.send(CODE_VERSIONS_REQUEST, &GetRequest{}, &response)
 	if err != nil {
 		return GetCodeVersionsResponse{}, err
 	}
-	buf2 := bytes.NewBuffer(result)
-	response := GetCodeVersionsResponse{
-		Header: readHeaderResponse(buf2),
-	}
-	binary.Read(buf2, binary.LittleEndian, &response.CodeVersion)
-
 	return response, nil
 }
 
 func (pthi Command) GetUUID() (uuid string, err error) {
-	command := GetRequest{
-		Header: CreateRequestHeader(GET_UUID_REQUEST, 0),
-	}
-	var bin_buf bytes.Buffer
-	binary.Write(&bin_buf, binary.LittleEndian, command)
-	result, err := pthi.Call(bin_buf.Bytes(), GET_REQUEST_SIZE)
+	response := GetUUIDResponse{}
+	err = pthi.send(GET_UUID_REQUEST, &GetRequest{}, &response)
 	if err != nil {
 		return "", err
 	}
-	buf2 := bytes.NewBuffer(result)
-	response := GetUUIDResponse{
-		Header: readHeaderResponse(buf2),
-	}
-
-	binary.Read(buf2, binary.LittleEndian, &response.UUID)
-
 	return string(([]byte)(response.UUID[:])), nil
 }
 
 func (pthi Command) GetControlMode() (state int, err error) {
-	command := GetRequest{
-		Header: CreateRequestHeader(GET_CONTROL_MODE_REQUEST, 0),
-	}
-	var bin_buf bytes.Buffer
-	binary.Write(&bin_buf, binary.LittleEndian, command)
-	result, err := pthi.Call(bin_buf.Bytes(), GET_REQUEST_SIZE)
+	response := GetControlModeResponse{}
+	err = pthi.send(GET_CONTROL_MODE_REQUEST, &GetRequest{}, &response)
 	if err != nil {
 		return -1, err
 	}
-	buf2 := bytes.NewBuffer(result)
-	response := GetControlModeResponse{
-		Header: readHeaderResponse(buf2),
-	}
-
-	binary.Read(buf2, binary.LittleEndian, &response.State)
 	return int(response.State), nil
 }
 
-func readHeaderResponse(header *bytes.Buffer) ResponseMessageHeader {
-	response := ResponseMessageHeader{}
-
-	binary.Read(header, binary.LittleEndian, &response.Header.Version.MajorNumber)
-	binary.Read(header, binary.LittleEndian, &response.Header.Version.MinorNumber)
-	binary.Read(header, binary.LittleEndian, &response.Header.Reserved)
-	binary.Read(header, binary.LittleEndian, &response.Header.Command.val)
-	binary.Read(header, binary.LittleEndian, &response.Header.Length)
-	binary.Read(header, binary.LittleEndian, &response.Status)
-
-	return response
-}
-
 func (pthi Command) GetDNSSuffix() (suffix string, err error) {
-	command := GetRequest{
-		Header: CreateRequestHeader(GET_PKI_FQDN_SUFFIX_REQUEST, 0),
-	}
-	var bin_buf bytes.Buffer
-	binary.Write(&bin_buf, binary.LittleEndian, command)
-	result, err := pthi.Call(bin_buf.Bytes(), GET_REQUEST_SIZE)
+	response := GetPKIFQDNSuffixResponse{}
+	err = pthi.send(GET_PKI_FQDN_SUFFIX_REQUEST, &GetRequest{}, &response)
 	if err != nil {
 		return "", err
 	}
-	buf2 := bytes.NewBuffer(result)
-	response := GetPKIFQDNSuffixResponse{
-		Header: readHeaderResponse(buf2),
-	}
-
-	binary.Read(buf2, binary.LittleEndian, &response.Suffix.Length)
-	binary.Read(buf2, binary.LittleEndian, &response.Suffix.Buffer)
-
-	if int(response.Suffix.Length) > 0 {
-		return string(response.Suffix.Buffer[:response.Suffix.Length]), nil
-	}
-
-	return "", nil
+	return response.Suffix.Decode()
 }
 
 func (pthi Command) enumerateHashHandles() (AMTHashHandles, error) {
-	// Enumerate a list of hash handles to request from
-	enumerateCommand := GetRequest{
-		Header: CreateRequestHeader(ENUMERATE_HASH_HANDLES_REQUEST, 0),
-	}
-	var EnumerateBin_buf bytes.Buffer
-	binary.Write(&EnumerateBin_buf, binary.LittleEndian, enumerateCommand)
-	enumerateResult, err := pthi.Call(EnumerateBin_buf.Bytes(), GET_REQUEST_SIZE)
+	response := GetHashHandlesResponse{}
+	err := pthi.send(ENUMERATE_HASH_HANDLES_REQUEST, &GetRequest{}, &response)
 	if err != nil {
 		return AMTHashHandles{}, err
 	}
-	enumerateBuf2 := bytes.NewBuffer(enumerateResult)
-	enumerateResponse := GetHashHandlesResponse{
-		Header: readHeaderResponse(enumerateBuf2),
-	}
-
-	binary.Read(enumerateBuf2, binary.LittleEndian, &enumerateResponse.HashHandles.Length)
-	binary.Read(enumerateBuf2, binary.LittleEndian, &enumerateResponse.HashHandles.Handles)
-	return enumerateResponse.HashHandles, nil
+	return response.HashHandles, nil
 }
+
 func (pthi Command) GetCertificateHashes(hashHandles AMTHashHandles) (hashEntryList []CertHashEntry, err error) {
 	if hashHandles.Length == 0 {
 		hashHandles, err = pthi.enumerateHashHandles()
@@ -206,32 +137,16 @@ func (pthi Command) GetCertificateHashes(hashHandles AMTHashHandles) (hashEntryL
 			return []CertHashEntry{}, err
 		}
 	}
+	if err := hashHandles.Validate(); err != nil {
+		return []CertHashEntry{}, err
+	}
 	// Request from the enumerated list and return cert hashes
 	for i := 0; i < int(hashHandles.Length); i++ {
-		commandSize := (uint32)(16)
-		command := GetCertHashEntryRequest{
-			Header:     CreateRequestHeader(GET_CERTHASH_ENTRY_REQUEST, 4),
-			HashHandle: hashHandles.Handles[i],
-		}
-		var bin_buf bytes.Buffer
-		binary.Write(&bin_buf, binary.LittleEndian, command)
-		result, err := pthi.Call(bin_buf.Bytes(), commandSize)
+		response := GetCertHashEntryResponse{}
+		err := pthi.send(GET_CERTHASH_ENTRY_REQUEST, &GetCertHashEntryRequest{HashHandle: hashHandles.Handles[i]}, &response)
 		if err != nil {
-			emptyHashList := []CertHashEntry{}
-			return emptyHashList, err
-		}
-		buf2 := bytes.NewBuffer(result)
-		response := GetCertHashEntryResponse{
-			Header: readHeaderResponse(buf2),
+			return []CertHashEntry{}, err
 		}
-
-		binary.Read(buf2, binary.LittleEndian, &response.Hash.IsDefault)
-		binary.Read(buf2, binary.LittleEndian, &response.Hash.IsActive)
-		binary.Read(buf2, binary.LittleEndian, &response.Hash.CertificateHash)
-		binary.Read(buf2, binary.LittleEndian, &response.Hash.HashAlgorithm)
-		binary.Read(buf2, binary.LittleEndian, &response.Hash.Name.Length)
-		binary.Read(buf2, binary.LittleEndian, &response.Hash.Name.Buffer)
-
 		hashEntryList = append(hashEntryList, response.Hash)
 	}
 
@@ -239,80 +154,49 @@ func (pthi Command) GetCertificateHashes(hashHandles AMTHashHandles) (hashEntryL
 }
 
 func (pthi Command) GetRemoteAccessConnectionStatus() (RAStatus GetRemoteAccessConnectionStatusResponse, err error) {
-	command := GetRequest{
-		Header: CreateRequestHeader(GET_REMOTE_ACCESS_CONNECTION_STATUS_REQUEST, 0),
-	}
-	var bin_buf bytes.Buffer
-	binary.Write(&bin_buf, binary.LittleEndian, command)
-	result, err := pthi.Call(bin_buf.Bytes(), GET_REQUEST_SIZE)
+	response := GetRemoteAccessConnectionStatusResponse{}
+	err = pthi.send(GET_REMOTE_ACCESS_CONNECTION_STATUS_REQUEST, &GetRequest{}, &response)
 	if err != nil {
-		emptyResponse := GetRemoteAccessConnectionStatusResponse{}
-		return emptyResponse, err
-	}
-	buf2 := bytes.NewBuffer(result)
-	response := GetRemoteAccessConnectionStatusResponse{
-		Header: readHeaderResponse(buf2),
+		return GetRemoteAccessConnectionStatusResponse{}, err
 	}
-
-	binary.Read(buf2, binary.LittleEndian, &response.NetworkStatus)
-	binary.Read(buf2, binary.LittleEndian, &response.RemoteStatus)
-	binary.Read(buf2, binary.LittleEndian, &response.RemoteTrigger)
-	binary.Read(buf2, binary.LittleEndian, &response.MPSHostname.Length)
-	binary.Read(buf2, binary.LittleEndian, &response.MPSHostname.Buffer)
-
 	return response, nil
 }
 
 func (pthi Command) GetLANInterfaceSettings(useWireless bool) (LANInterface GetLANInterfaceSettingsResponse, err error) {
-	commandSize := (uint32)(16)
-	command := GetLANInterfaceSettingsRequest{
-		Header:         CreateRequestHeader(GET_LAN_INTERFACE_SETTINGS_REQUEST, 4),
+	request := GetLANInterfaceSettingsRequest{
 		InterfaceIndex: 0,
 	}
 	if useWireless {
-		command.InterfaceIndex = 1
+		request.InterfaceIndex = 1
 	}
-	var bin_buf bytes.Buffer
-	binary.Write(&bin_buf, binary.LittleEndian, command)
-	result, err := pthi.Call(bin_buf.Bytes(), commandSize)
+	response := GetLANInterfaceSettingsResponse{}
+	err = pthi.send(GET_LAN_INTERFACE_SETTINGS_REQUEST, &request, &response)
 	if err != nil {
-		emptySettings := GetLANInterfaceSettingsResponse{}
-		return emptySettings, err
-	}
-	buf2 := bytes.NewBuffer(result)
-	response := GetLANInterfaceSettingsResponse{
-		Header: readHeaderResponse(buf2),
+		return GetLANInterfaceSettingsResponse{}, err
 	}
-
-	binary.Read(buf2, binary.LittleEndian, &response.Enabled)
-	binary.Read(buf2, binary.LittleEndian, &response.Ipv4Address)
-	binary.Read(buf2, binary.LittleEndian, &response.DhcpEnabled)
-	binary.Read(buf2, binary.LittleEndian, &response.DhcpIpMode)
-	binary.Read(buf2, binary.LittleEndian, &response.LinkStatus)
-	binary.Read(buf2, binary.LittleEndian, &response.MacAddress)
-
 	return response, nil
 }
 
-func (pthi Command) GetLocalSystemAccount() (localAccount GetLocalSystemAccountResponse, err error) {
-	commandSize := (uint32)(52)
-	command := GetLocalSystemAccountRequest{
-		Header: CreateRequestHeader(GET_LOCAL_SYSTEM_ACCOUNT_REQUEST, 40),
+func (pthi Command) GetIPv6LANInterfaceStatus(useWireless bool) (IPv6Status GetIPv6LANInterfaceStatusResponse, err error) {
+	request := GetIPv6LANInterfaceStatusRequest{
+		InterfaceIndex: 0,
 	}
-	var bin_buf bytes.Buffer
-	binary.Write(&bin_buf, binary.LittleEndian, command)
-	result, err := pthi.Call(bin_buf.Bytes(), commandSize)
-	if err != nil {
-		emptyAccount := GetLocalSystemAccountResponse{}
-		return emptyAccount, err
+	if useWireless {
+		request.InterfaceIndex = 1
 	}
-	buf2 := bytes.NewBuffer(result)
-	response := GetLocalSystemAccountResponse{
-		Header: readHeaderResponse(buf2),
+	response := GetIPv6LANInterfaceStatusResponse{}
+	err = pthi.send(GET_IPV6_LAN_INTERFACE_STATUS_REQUEST, &request, &response)
+	if err != nil {
+		return GetIPv6LANInterfaceStatusResponse{}, err
 	}
+	return response, nil
+}
 
-	binary.Read(buf2, binary.LittleEndian, &response.Account.Username)
-	binary.Read(buf2, binary.LittleEndian, &response.Account.Password)
-
+func (pthi Command) GetLocalSystemAccount() (localAccount GetLocalSystemAccountResponse, err error) {
+	response := GetLocalSystemAccountResponse{}
+	err = pthi.send(GET_LOCAL_SYSTEM_ACCOUNT_REQUEST, &GetLocalSystemAccountRequest{}, &response)
+	if err != nil {
+		return GetLocalSystemAccountResponse{}, err
+	}
 	return response, nil
 }
@@ -7,6 +7,7 @@ package pthi
 import (
 	"bytes"
 	"encoding/binary"
+	"rpc/pkg/heci"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -17,7 +18,10 @@ type MockHECICommands struct{}
 var message []byte
 var numBytes uint32 = GET_REQUEST_SIZE
 
-func (c *MockHECICommands) Init() error           { return nil }
+func (c *MockHECICommands) Init() error { return nil }
+func (c *MockHECICommands) Connect(client heci.Client) (heci.ClientProperties, error) {
+	return heci.ClientProperties{MaxMessageLength: 5120}, nil
+}
 func (c *MockHECICommands) GetBufferSize() uint32 { return 5120 } // MaxMessageLength
 
 func (c *MockHECICommands) SendMessage(buffer []byte, done *uint32) (bytesWritten uint32, err error) {
@@ -27,12 +31,19 @@ func (c *MockHECICommands) ReceiveMessage(buffer []byte, done *uint32) (bytesRea
 	for i := 0; i < len(message) && i < len(buffer); i++ {
 		buffer[i] = message[i]
 	}
-	return 12, nil
+	return uint32(len(message)), nil
 }
 func (c *MockHECICommands) Close() {}
 
 var pthi Command
 
+func responseHeader(command uint32, response interface{}) ResponseMessageHeader {
+	header := ResponseMessageHeader{Status: AMT_STATUS_SUCCESS}
+	header.Header.Command.val = command | RESPONSE_BIT
+	header.Header.Length = uint32(binary.Size(response) - headerSize)
+	return header
+}
+
 func init() {
 	pthi = Command{}
 	pthi.heci = &MockHECICommands{}
@@ -45,7 +56,7 @@ func TestGetGUID(t *testing.T) {
 
 	// Load byte array of response into message
 	prepareMessage := GetUUIDResponse{
-		Header: ResponseMessageHeader{},
+		Header: responseHeader(GET_UUID_REQUEST, GetUUIDResponse{}),
 		UUID:   [16]uint8{1, 2, 3, 4},
 	}
 	var bin_buf bytes.Buffer
@@ -61,7 +72,7 @@ func TestGetGUID(t *testing.T) {
 func TestGetControlMode(t *testing.T) {
 	numBytes = GET_REQUEST_SIZE
 	prepareMessage := GetControlModeResponse{
-		Header: ResponseMessageHeader{},
+		Header: responseHeader(GET_CONTROL_MODE_REQUEST, GetControlModeResponse{}),
 		State:  3,
 	}
 	var bin_buf bytes.Buffer
@@ -76,7 +87,7 @@ func TestGetCodeVersions(t *testing.T) {
 
 	numBytes = GET_REQUEST_SIZE
 	prepareMessage := GetCodeVersionsResponse{
-		Header: ResponseMessageHeader{},
+		Header: responseHeader(CODE_VERSIONS_REQUEST, GetCodeVersionsResponse{}),
 		CodeVersion: CodeVersions{
 			BiosVersion:   [BIOS_VERSION_LEN]uint8{1, 2, 3},
 			VersionsCount: 1,
@@ -96,7 +107,7 @@ func TestGetCodeVersions(t *testing.T) {
 func TestGetDNSSuffix(t *testing.T) {
 	numBytes = GET_REQUEST_SIZE
 	prepareMessage := GetPKIFQDNSuffixResponse{
-		Header: ResponseMessageHeader{},
+		Header: responseHeader(GET_PKI_FQDN_SUFFIX_REQUEST, GetPKIFQDNSuffixResponse{}),
 		Suffix: AMTANSIString{
 			Length: 4,
 			Buffer: [1000]uint8{1, 2, 3, 4},
@@ -115,7 +126,7 @@ func TestGetDNSSuffix(t *testing.T) {
 func TestEnumerateHashHandles(t *testing.T) {
 	numBytes = GET_REQUEST_SIZE
 	prepareMessage := GetHashHandlesResponse{
-		Header: ResponseMessageHeader{},
+		Header: responseHeader(ENUMERATE_HASH_HANDLES_REQUEST, GetHashHandlesResponse{}),
 		HashHandles: AMTHashHandles{
 			Length:  1,
 			Handles: [CERT_HASH_MAX_NUMBER]uint32{0},
@@ -132,11 +143,11 @@ func TestEnumerateHashHandles(t *testing.T) {
 func TestGetCertificateHashes(t *testing.T) { // Needs more work
 	numBytes = 16
 	prepareMessage2 := GetCertHashEntryResponse{
-		Header: ResponseMessageHeader{Status: 1},
+		Header: responseHeader(GET_CERTHASH_ENTRY_REQUEST, GetCertHashEntryResponse{}),
 		Hash: CertHashEntry{
 			IsDefault:       1,
 			IsActive:        1,
-			HashAlgorithm:   4,
+			HashAlgorithm:   2,
 			CertificateHash: [CERT_HASH_MAX_LENGTH]uint8{9, 9, 9},
 			Name: AMTANSIString{
 				Length: 4,
@@ -154,14 +165,35 @@ func TestGetCertificateHashes(t *testing.T) { // Needs more work
 	assert.Equal(t, int(result[0].IsDefault), 1)
 	assert.Equal(t, int(result[0].IsActive), 1)
 	assert.Equal(t, int(result[0].CertificateHash[0]), 9)
-	assert.Equal(t, int(result[0].HashAlgorithm), 4)
+	assert.Equal(t, int(result[0].HashAlgorithm), 2)
 	assert.Equal(t, int(result[0].Name.Length), 4)
 }
 
+func TestGetCertificateHashesUnknownAlgorithm(t *testing.T) {
+	numBytes = 16
+	prepareMessage := GetCertHashEntryResponse{
+		Header: responseHeader(GET_CERTHASH_ENTRY_REQUEST, GetCertHashEntryResponse{}),
+		Hash: CertHashEntry{
+			HashAlgorithm: 9,
+		},
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	_, err := pthi.GetCertificateHashes(AMTHashHandles{Length: 1})
+	assert.EqualError(t, err, "unknown hash algorithm 9")
+}
+
+func TestGetCertificateHashesTooManyHandles(t *testing.T) {
+	_, err := pthi.GetCertificateHashes(AMTHashHandles{Length: CERT_HASH_MAX_NUMBER + 1})
+	assert.EqualError(t, err, "hash handle count 24 exceeds 23 handles")
+}
+
 func TestGetRemoteAccessConnectionStatus(t *testing.T) {
 	numBytes = GET_REQUEST_SIZE
 	prepareMessage := GetRemoteAccessConnectionStatusResponse{
-		Header:        ResponseMessageHeader{},
+		Header:        responseHeader(GET_REMOTE_ACCESS_CONNECTION_STATUS_REQUEST, GetRemoteAccessConnectionStatusResponse{}),
 		NetworkStatus: 1,
 		RemoteStatus:  2,
 		RemoteTrigger: 3,
@@ -186,7 +218,7 @@ func TestGetRemoteAccessConnectionStatus(t *testing.T) {
 func TestGetLANInterfaceSettings(t *testing.T) {
 	numBytes = 16
 	prepareMessage := GetLANInterfaceSettingsResponse{
-		Header:      ResponseMessageHeader{},
+		Header:      responseHeader(GET_LAN_INTERFACE_SETTINGS_REQUEST, GetLANInterfaceSettingsResponse{}),
 		Enabled:     1,
 		Ipv4Address: 1020,
 		DhcpEnabled: 0,
@@ -212,7 +244,7 @@ func TestGetLANInterfaceSettings(t *testing.T) {
 func TestGetLocalSystemAccount(t *testing.T) {
 	numBytes = 52
 	prepareMessage := GetLocalSystemAccountResponse{
-		Header: ResponseMessageHeader{},
+		Header: responseHeader(GET_LOCAL_SYSTEM_ACCOUNT_REQUEST, GetLocalSystemAccountResponse{}),
 		Account: LocalSystemAccount{
 			Username: [CFG_MAX_ACL_USER_LENGTH]uint8{1, 2, 3, 4},
 			Password: [CFG_MAX_ACL_USER_LENGTH]uint8{8, 7, 6, 5},
@@ -229,3 +261,41 @@ func TestGetLocalSystemAccount(t *testing.T) {
 	assert.Equal(t, result.Account.Password, [CFG_MAX_ACL_USER_LENGTH]uint8{8, 7, 6, 5})
 
 }
+
+func TestGetIPv6LANInterfaceStatus(t *testing.T) {
+	numBytes = 16
+	prepareMessage := GetIPv6LANInterfaceStatusResponse{
+		Header:       responseHeader(GET_IPV6_LAN_INTERFACE_STATUS_REQUEST, GetIPv6LANInterfaceStatusResponse{}),
+		Enabled:      1,
+		AddressCount: 1,
+	}
+	prepareMessage.Addresses[0] = IPv6AddressInfo{
+		Address: [16]uint8{0xfe, 0x80, 15: 1},
+		Type:    IPV6_ADDR_TYPE_LINK_LOCAL,
+		State:   IPV6_ADDR_STATE_PREFERRED,
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.GetIPv6LANInterfaceStatus(false)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), result.Enabled)
+	assert.Equal(t, uint32(1), result.AddressCount)
+	assert.Equal(t, uint8(0xfe), result.Addresses[0].Address[0])
+	assert.Equal(t, uint32(IPV6_ADDR_STATE_PREFERRED), result.Addresses[0].State)
+}
+
+func TestGetIPv6LANInterfaceStatusTooManyAddresses(t *testing.T) {
+	numBytes = 16
+	prepareMessage := GetIPv6LANInterfaceStatusResponse{
+		Header:       responseHeader(GET_IPV6_LAN_INTERFACE_STATUS_REQUEST, GetIPv6LANInterfaceStatusResponse{}),
+		AddressCount: IPV6_MAX_ADDRESSES + 1,
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	_, err := pthi.GetIPv6LANInterfaceStatus(false)
+	assert.EqualError(t, err, "ipv6 address count 7 exceeds 6 addresses")
+}
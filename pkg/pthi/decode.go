@@ -0,0 +1,102 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package pthi
+
+import (
+	"fmt"
+	"rpc/pkg/utils"
+)
+
+// validator is implemented by responses whose lengths and enumerations must be checked after decoding
+type validator interface {
+	Validate() error
+}
+
+// Decode returns the string, failing if the declared length exceeds the buffer
+func (s AMTANSIString) Decode() (string, error) {
+	if int(s.Length) > len(s.Buffer) {
+		return "", fmt.Errorf("string length %d exceeds %d bytes", s.Length, len(s.Buffer))
+	}
+	return string(s.Buffer[:s.Length]), nil
+}
+
+// Decode returns the string, failing if the declared length exceeds the buffer
+func (s AMTUnicodeString) Decode() (string, error) {
+	if int(s.Length) > len(s.String) {
+		return "", fmt.Errorf("string length %d exceeds %d bytes", s.Length, len(s.String))
+	}
+	return string(s.String[:s.Length]), nil
+}
+
+// Validate checks the version count and every entry in the table
+func (v CodeVersions) Validate() error {
+	if int(v.VersionsCount) > len(v.Versions) {
+		return fmt.Errorf("version count %d exceeds %d entries", v.VersionsCount, len(v.Versions))
+	}
+	for i := 0; i < int(v.VersionsCount); i++ {
+		if _, err := v.Versions[i].Description.Decode(); err != nil {
+			return err
+		}
+		if _, err := v.Versions[i].Version.Decode(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks the handle count
+func (h AMTHashHandles) Validate() error {
+	if int(h.Length) > len(h.Handles) {
+		return fmt.Errorf("hash handle count %d exceeds %d handles", h.Length, len(h.Handles))
+	}
+	return nil
+}
+
+// Digest returns the hash bytes for the entry's algorithm
+func (e CertHashEntry) Digest() ([]byte, error) {
+	hashSize, _ := utils.InterpretHashAlgorithm(int(e.HashAlgorithm))
+	if hashSize == 0 {
+		return nil, fmt.Errorf("unknown hash algorithm %d", e.HashAlgorithm)
+	}
+	return e.CertificateHash[:hashSize], nil
+}
+
+// Validate checks the hash algorithm and name
+func (e CertHashEntry) Validate() error {
+	if _, err := e.Digest(); err != nil {
+		return err
+	}
+	_, err := e.Name.Decode()
+	return err
+}
+
+func (r GetCodeVersionsResponse) Validate() error {
+	return r.CodeVersion.Validate()
+}
+
+func (r GetPKIFQDNSuffixResponse) Validate() error {
+	_, err := r.Suffix.Decode()
+	return err
+}
+
+func (r GetHashHandlesResponse) Validate() error {
+	return r.HashHandles.Validate()
+}
+
+func (r GetCertHashEntryResponse) Validate() error {
+	return r.Hash.Validate()
+}
+
+func (r GetRemoteAccessConnectionStatusResponse) Validate() error {
+	_, err := r.MPSHostname.Decode()
+	return err
+}
+
+func (r GetIPv6LANInterfaceStatusResponse) Validate() error {
+	if int(r.AddressCount) > len(r.Addresses) {
+		return fmt.Errorf("ipv6 address count %d exceeds %d addresses", r.AddressCount, len(r.Addresses))
+	}
+	return nil
+}
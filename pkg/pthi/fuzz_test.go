@@ -0,0 +1,54 @@
+//go:build go1.18
+// +build go1.18
+
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package pthi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func FuzzUnmarshal(f *testing.F) {
+	f.Add(encode(GetControlModeResponse{Header: responseHeader(GET_CONTROL_MODE_REQUEST, GetControlModeResponse{}), State: 2}))
+	f.Add(encode(GetPKIFQDNSuffixResponse{Header: responseHeader(GET_PKI_FQDN_SUFFIX_REQUEST, GetPKIFQDNSuffixResponse{})}))
+	f.Add(encode(GetCodeVersionsResponse{Header: responseHeader(CODE_VERSIONS_REQUEST, GetCodeVersionsResponse{})}))
+	f.Add(encode(GetCertHashEntryResponse{Header: responseHeader(GET_CERTHASH_ENTRY_REQUEST, GetCertHashEntryResponse{})}))
+	f.Add([]byte{})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for command, responseType := range responseTypes {
+			response := reflect.New(responseType).Interface()
+			if Unmarshal(command, data, response) != nil {
+				continue
+			}
+			// a response that passed validation must decode without panicking
+			switch r := response.(type) {
+			case *GetCertHashEntryResponse:
+				r.Hash.Digest()
+				r.Hash.Name.Decode()
+			case *GetCodeVersionsResponse:
+				for i := 0; i < int(r.CodeVersion.VersionsCount); i++ {
+					r.CodeVersion.Versions[i].Description.Decode()
+				}
+			case *GetHashHandlesResponse:
+				for i := 0; i < int(r.HashHandles.Length); i++ {
+					_ = r.HashHandles.Handles[i]
+				}
+			}
+		}
+	})
+}
+
+func FuzzGetCertificateHashes(f *testing.F) {
+	f.Add(encode(GetCertHashEntryResponse{Header: responseHeader(GET_CERTHASH_ENTRY_REQUEST, GetCertHashEntryResponse{})}))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		message = data
+		defer func() { message = nil }()
+		pthi.GetCertificateHashes(AMTHashHandles{Length: 1})
+		pthi.GetDNSSuffix()
+		pthi.GetRemoteAccessConnectionStatus()
+	})
+}
@@ -19,6 +19,27 @@ const BIOS_VERSION_LEN = 65
 const VERSIONS_NUMBER = 50
 const UNICODE_STRING_LEN = 20
 
+const IPV6_MAX_ADDRESSES = 6
+
+// AMT_STATUS_SUCCESS is the Status of a response to a command that succeeded
+const AMT_STATUS_SUCCESS = 0
+
+// IPv6 address types, which record how each address was configured
+const (
+	IPV6_ADDR_TYPE_LINK_LOCAL = 0
+	IPV6_ADDR_TYPE_DHCP       = 1
+	IPV6_ADDR_TYPE_AUTO       = 2
+	IPV6_ADDR_TYPE_MANUAL     = 3
+)
+
+// IPv6 address states
+const (
+	IPV6_ADDR_STATE_TENTATIVE  = 0
+	IPV6_ADDR_STATE_PREFERRED  = 1
+	IPV6_ADDR_STATE_DEPRECATED = 2
+	IPV6_ADDR_STATE_INVALID    = 3
+)
+
 const CFG_MAX_ACL_USER_LENGTH = 33
 const CFG_MAX_ACL_PWD_LENGTH = 33
 
@@ -76,6 +97,9 @@ const GET_CURRENT_POWER_POLICY_RESPONSE = 0x04800047
 const GET_LAN_INTERFACE_SETTINGS_REQUEST = 0x04000048
 const GET_LAN_INTERFACE_SETTINGS_RESPONSE = 0x04800048
 
+const GET_IPV6_LAN_INTERFACE_STATUS_REQUEST = 0x0400007A
+const GET_IPV6_LAN_INTERFACE_STATUS_RESPONSE = 0x0480007A
+
 const GET_FEATURES_STATE_REQUEST = 0x04000049
 const GET_FEATURES_STATE_RESPONSE = 0x04800049
 
@@ -213,6 +237,27 @@ type GetLANInterfaceSettingsResponse struct {
 	MacAddress  [6]uint8
 }
 
+type IPv6AddressInfo struct {
+	Address [16]uint8
+	Type    uint32
+	State   uint32
+}
+
+// GetIPv6LANInterfaceStatusRequest uses the same interface index as GetLANInterfaceSettingsRequest
+type GetIPv6LANInterfaceStatusRequest struct {
+	Header         MessageHeader
+	InterfaceIndex uint32
+}
+type GetIPv6LANInterfaceStatusResponse struct {
+	Header        ResponseMessageHeader
+	Enabled       uint32
+	DefaultRouter [16]uint8
+	PrimaryDNS    [16]uint8
+	SecondaryDNS  [16]uint8
+	AddressCount  uint32
+	Addresses     [IPV6_MAX_ADDRESSES]IPv6AddressInfo
+}
+
 type AMTHashHandles struct {
 	Length  uint32
 	Handles [CERT_HASH_MAX_NUMBER]uint32
@@ -0,0 +1,110 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package smbios
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// DMIPath is the sysfs directory exposing the SMBIOS system information
+const DMIPath = "/sys/class/dmi/id"
+
+// Info is the SMBIOS system information sent to RPS
+type Info struct {
+	UUID         string `json:"uuid"`
+	Serial       string `json:"serial"`
+	Manufacturer string `json:"manufacturer"`
+	Model        string `json:"model"`
+}
+
+// UUIDCheck is the result of comparing the AMT UUID with the SMBIOS UUID
+type UUIDCheck string
+
+const (
+	UUIDMatch       UUIDCheck = "match"
+	UUIDByteSwapped UUIDCheck = "byte-swapped"
+	UUIDMismatch    UUIDCheck = "mismatch"
+	UUIDUnavailable UUIDCheck = "unavailable"
+)
+
+// Read returns the SMBIOS system information. The UUID and serial are only readable by root.
+func Read() (Info, error) {
+	return read(DMIPath)
+}
+
+func read(dmiPath string) (Info, error) {
+	readValue := func(name string) string {
+		data, err := ioutil.ReadFile(filepath.Join(dmiPath, name))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(data))
+	}
+	info := Info{
+		UUID:         strings.ToLower(readValue("product_uuid")),
+		Serial:       readValue("product_serial"),
+		Manufacturer: readValue("sys_vendor"),
+		Model:        readValue("product_name"),
+	}
+	if info == (Info{}) {
+		return info, errors.New("no SMBIOS information found in " + dmiPath)
+	}
+	return info, nil
+}
+
+// FormatUUID formats 16 raw bytes as SMBIOS does, with the first three fields little endian
+func FormatUUID(raw []byte) (string, error) {
+	if len(raw) != 16 {
+		return "", fmt.Errorf("uuid must be 16 bytes, got %d", len(raw))
+	}
+	return FormatRawUUID(swapFields(raw))
+}
+
+// FormatRawUUID formats 16 raw bytes in the order they are given, with every field big endian
+func FormatRawUUID(b []byte) (string, error) {
+	if len(b) != 16 {
+		return "", fmt.Errorf("uuid must be 16 bytes, got %d", len(b))
+	}
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// SwapUUID reverses the byte order of the first three fields of a formatted UUID
+func SwapUUID(uuid string) (string, error) {
+	raw, err := hex.DecodeString(strings.Replace(uuid, "-", "", -1))
+	if err != nil || len(raw) != 16 {
+		return "", errors.New("invalid uuid " + uuid)
+	}
+	return FormatUUID(raw)
+}
+
+// CompareUUID compares the AMT and SMBIOS UUIDs, detecting the same bytes read with the wrong byte order
+func CompareUUID(amtUUID string, smbiosUUID string) UUIDCheck {
+	amtUUID = strings.ToLower(amtUUID)
+	smbiosUUID = strings.ToLower(smbiosUUID)
+	if amtUUID == "" || smbiosUUID == "" {
+		return UUIDUnavailable
+	}
+	if amtUUID == smbiosUUID {
+		return UUIDMatch
+	}
+	if swapped, err := SwapUUID(amtUUID); err == nil && swapped == smbiosUUID {
+		return UUIDByteSwapped
+	}
+	return UUIDMismatch
+}
+
+func swapFields(raw []byte) []byte {
+	b := make([]byte, 16)
+	copy(b, raw)
+	b[0], b[1], b[2], b[3] = raw[3], raw[2], raw[1], raw[0]
+	b[4], b[5] = raw[5], raw[4]
+	b[6], b[7] = raw[7], raw[6]
+	return b
+}
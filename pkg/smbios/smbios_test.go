@@ -0,0 +1,78 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package smbios
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"product_uuid":   "1C113FD2-3325-4594-A272-54B2038BEB07\n",
+		"product_serial": "PF2ABCDE\n",
+		"sys_vendor":     "LENOVO\n",
+		"product_name":   "20XW0055US\n",
+	}
+	for name, value := range files {
+		ioutil.WriteFile(filepath.Join(dir, name), []byte(value), 0644)
+	}
+	info, err := read(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, Info{UUID: "1c113fd2-3325-4594-a272-54b2038beb07", Serial: "PF2ABCDE", Manufacturer: "LENOVO", Model: "20XW0055US"}, info)
+}
+
+func TestReadNotRoot(t *testing.T) {
+	// product_uuid and product_serial are only readable by root
+	dir := t.TempDir()
+	ioutil.WriteFile(filepath.Join(dir, "sys_vendor"), []byte("Intel Corporation\n"), 0644)
+	info, err := read(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "", info.UUID)
+	assert.Equal(t, "Intel Corporation", info.Manufacturer)
+}
+
+func TestReadMissing(t *testing.T) {
+	_, err := read(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestFormatUUID(t *testing.T) {
+	result, err := FormatUUID([]byte{0xd2, 0x3f, 0x11, 0x1c, 0x25, 0x33, 0x94, 0x45, 0xa2, 0x72, 0x54, 0xb2, 0x03, 0x8b, 0xeb, 0x07})
+	assert.NoError(t, err)
+	assert.Equal(t, "1c113fd2-3325-4594-a272-54b2038beb07", result)
+
+	_, err = FormatUUID([]byte{1, 2, 3})
+	assert.Error(t, err)
+}
+
+func TestFormatRawUUID(t *testing.T) {
+	result, err := FormatRawUUID([]byte{0xd2, 0x3f, 0x11, 0x1c, 0x25, 0x33, 0x94, 0x45, 0xa2, 0x72, 0x54, 0xb2, 0x03, 0x8b, 0xeb, 0x07})
+	assert.NoError(t, err)
+	assert.Equal(t, "d23f111c-2533-9445-a272-54b2038beb07", result)
+
+	_, err = FormatRawUUID([]byte{1, 2, 3})
+	assert.Error(t, err)
+}
+
+func TestSwapUUID(t *testing.T) {
+	result, err := SwapUUID("1c113fd2-3325-4594-a272-54b2038beb07")
+	assert.NoError(t, err)
+	assert.Equal(t, "d23f111c-2533-9445-a272-54b2038beb07", result)
+
+	_, err = SwapUUID("not-a-uuid")
+	assert.Error(t, err)
+}
+
+func TestCompareUUID(t *testing.T) {
+	assert.Equal(t, UUIDMatch, CompareUUID("1c113fd2-3325-4594-a272-54b2038beb07", "1C113FD2-3325-4594-A272-54B2038BEB07"))
+	assert.Equal(t, UUIDByteSwapped, CompareUUID("1c113fd2-3325-4594-a272-54b2038beb07", "d23f111c-2533-9445-a272-54b2038beb07"))
+	assert.Equal(t, UUIDMismatch, CompareUUID("1c113fd2-3325-4594-a272-54b2038beb07", "00000000-3325-4594-a272-54b2038beb07"))
+	assert.Equal(t, UUIDUnavailable, CompareUUID("1c113fd2-3325-4594-a272-54b2038beb07", ""))
+}
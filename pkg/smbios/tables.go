@@ -0,0 +1,172 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package smbios
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// TablePath is the sysfs file exposing the raw SMBIOS structure table, only readable by root
+const TablePath = "/sys/firmware/dmi/tables/DMI"
+
+// SMBIOS structure types
+const (
+	typeMemoryDevice = 17
+	typeEndOfTable   = 127
+)
+
+// BIOS is the SMBIOS BIOS information
+type BIOS struct {
+	Vendor      string `json:"vendor"`
+	Version     string `json:"version"`
+	ReleaseDate string `json:"releaseDate"`
+}
+
+// MemoryDevice is an installed memory module
+type MemoryDevice struct {
+	Locator      string `json:"locator"`
+	BankLocator  string `json:"bankLocator"`
+	Manufacturer string `json:"manufacturer"`
+	SerialNumber string `json:"serialNumber"`
+	PartNumber   string `json:"partNumber"`
+	SizeMB       uint64 `json:"sizeMB"`
+	Speed        int    `json:"speed"`
+}
+
+// structure is one SMBIOS structure with its formatted area and strings
+type structure struct {
+	Type      uint8
+	Formatted []byte
+	Strings   []string
+}
+
+// str returns the string referenced by the byte at offset, which is a 1 based index into the strings
+func (s structure) str(offset int) string {
+	if offset >= len(s.Formatted) {
+		return ""
+	}
+	index := int(s.Formatted[offset])
+	if index == 0 || index > len(s.Strings) {
+		return ""
+	}
+	return strings.TrimSpace(s.Strings[index-1])
+}
+
+func (s structure) word(offset int) uint16 {
+	if offset+2 > len(s.Formatted) {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(s.Formatted[offset:])
+}
+
+func (s structure) dword(offset int) uint32 {
+	if offset+4 > len(s.Formatted) {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(s.Formatted[offset:])
+}
+
+// parseStructures splits the structure table, stopping at the end of table structure
+func parseStructures(data []byte) ([]structure, error) {
+	structures := []structure{}
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return structures, errors.New("truncated SMBIOS structure header")
+		}
+		length := int(data[1])
+		if length < 4 || length > len(data) {
+			return structures, fmt.Errorf("invalid SMBIOS structure length %d", length)
+		}
+		s := structure{Type: data[0], Formatted: data[:length]}
+		// the string set follows the formatted area and ends with two null bytes
+		end := length
+		for end+1 < len(data) && !(data[end] == 0 && data[end+1] == 0) {
+			end++
+		}
+		if end+1 >= len(data) {
+			return structures, errors.New("unterminated SMBIOS string set")
+		}
+		if end > length {
+			s.Strings = strings.Split(string(data[length:end]), "\x00")
+		}
+		structures = append(structures, s)
+		if s.Type == typeEndOfTable {
+			break
+		}
+		data = data[end+2:]
+	}
+	return structures, nil
+}
+
+// ReadMemoryDevices lists the installed memory modules from the SMBIOS table
+func ReadMemoryDevices() ([]MemoryDevice, error) {
+	data, err := ioutil.ReadFile(TablePath)
+	if err != nil {
+		return nil, err
+	}
+	return parseMemoryDevices(data)
+}
+
+func parseMemoryDevices(data []byte) ([]MemoryDevice, error) {
+	structures, err := parseStructures(data)
+	if err != nil {
+		return nil, err
+	}
+	devices := []MemoryDevice{}
+	for _, s := range structures {
+		if s.Type != typeMemoryDevice {
+			continue
+		}
+		size := uint64(s.word(0x0C))
+		switch {
+		case size == 0 || size == 0xFFFF:
+			// empty slot or unknown size
+			continue
+		case size == 0x7FFF:
+			size = uint64(s.dword(0x1C))
+		case size&0x8000 != 0:
+			size = (size & 0x7FFF) / 1024
+		}
+		devices = append(devices, MemoryDevice{
+			Locator:      s.str(0x10),
+			BankLocator:  s.str(0x11),
+			Speed:        int(s.word(0x15)),
+			Manufacturer: s.str(0x17),
+			SerialNumber: s.str(0x18),
+			PartNumber:   s.str(0x1A),
+			SizeMB:       size,
+		})
+	}
+	return devices, nil
+}
+
+// ReadBIOS returns the BIOS vendor, version and release date
+func ReadBIOS() (BIOS, error) {
+	return readBIOS(DMIPath)
+}
+
+func readBIOS(dmiPath string) (BIOS, error) {
+	readValue := func(name string) string {
+		data, err := ioutil.ReadFile(filepath.Join(dmiPath, name))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(data))
+	}
+	bios := BIOS{
+		Vendor:      readValue("bios_vendor"),
+		Version:     readValue("bios_version"),
+		ReleaseDate: readValue("bios_date"),
+	}
+	if bios == (BIOS{}) {
+		return bios, errors.New("no BIOS information found in " + dmiPath)
+	}
+	return bios, nil
+}
@@ -0,0 +1,79 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package smbios
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryDevice builds a type 17 structure with the given size word and strings
+func memoryDevice(size uint16, extended uint32, strings ...string) []byte {
+	formatted := make([]byte, 0x28)
+	formatted[0] = typeMemoryDevice
+	formatted[1] = 0x28
+	formatted[0x0C], formatted[0x0D] = byte(size), byte(size>>8)
+	formatted[0x10], formatted[0x11] = 1, 2
+	formatted[0x15], formatted[0x16] = 0x80, 0x0C // 3200
+	formatted[0x17], formatted[0x18], formatted[0x1A] = 3, 4, 5
+	formatted[0x1C], formatted[0x1D], formatted[0x1E], formatted[0x1F] = byte(extended), byte(extended>>8), byte(extended>>16), byte(extended>>24)
+	data := formatted
+	for _, s := range strings {
+		data = append(data, s...)
+		data = append(data, 0)
+	}
+	if len(strings) == 0 {
+		data = append(data, 0)
+	}
+	return append(data, 0)
+}
+
+func TestParseMemoryDevices(t *testing.T) {
+	table := []byte{0, 4, 0, 0, 'B', 'I', 'O', 'S', 0, 0}
+	table = append(table, memoryDevice(16384, 0, "DIMM A", "BANK 0", "Samsung", "12345678 ", "M471A2K43DB1-CWE")...)
+	table = append(table, memoryDevice(0, 0)...)
+	table = append(table, memoryDevice(0x7FFF, 65536, "DIMM B", "BANK 1", "Micron", "87654321", "MTA16ATF2G64HZ")...)
+	table = append(table, memoryDevice(0x8000|2048, 0, "DIMM C", "BANK 2", "Hynix", "1111", "HMA")...)
+	table = append(table, typeEndOfTable, 4, 0, 0, 0, 0)
+	devices, err := parseMemoryDevices(table)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemoryDevice{
+		{Locator: "DIMM A", BankLocator: "BANK 0", Manufacturer: "Samsung", SerialNumber: "12345678", PartNumber: "M471A2K43DB1-CWE", SizeMB: 16384, Speed: 3200},
+		{Locator: "DIMM B", BankLocator: "BANK 1", Manufacturer: "Micron", SerialNumber: "87654321", PartNumber: "MTA16ATF2G64HZ", SizeMB: 65536, Speed: 3200},
+		{Locator: "DIMM C", BankLocator: "BANK 2", Manufacturer: "Hynix", SerialNumber: "1111", PartNumber: "HMA", SizeMB: 2, Speed: 3200},
+	}, devices)
+}
+
+func TestParseStructuresInvalid(t *testing.T) {
+	_, err := parseStructures([]byte{17, 2, 0, 0})
+	assert.EqualError(t, err, "invalid SMBIOS structure length 2")
+	_, err = parseStructures([]byte{17, 4, 0, 0, 'a', 0})
+	assert.EqualError(t, err, "unterminated SMBIOS string set")
+	_, err = parseStructures([]byte{17, 4})
+	assert.EqualError(t, err, "truncated SMBIOS structure header")
+}
+
+func TestStructureStringOutOfRange(t *testing.T) {
+	s := structure{Formatted: []byte{17, 5, 0, 0, 3}, Strings: []string{"a"}}
+	assert.Equal(t, "", s.str(4))
+	assert.Equal(t, "", s.str(10))
+	assert.Equal(t, uint16(0), s.word(4))
+	assert.Equal(t, uint32(0), s.dword(4))
+}
+
+func TestReadBIOS(t *testing.T) {
+	dir := t.TempDir()
+	ioutil.WriteFile(filepath.Join(dir, "bios_vendor"), []byte("LENOVO\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "bios_version"), []byte("N32ET75W (1.51 )\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "bios_date"), []byte("03/05/2021\n"), 0644)
+	bios, err := readBIOS(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, BIOS{Vendor: "LENOVO", Version: "N32ET75W (1.51 )", ReleaseDate: "03/05/2021"}, bios)
+	_, err = readBIOS(t.TempDir())
+	assert.Error(t, err)
+}
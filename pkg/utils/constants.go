@@ -20,4 +20,34 @@ const (
 
 	// MPSServerMaxLength is the max length of the servername
 	MPSServerMaxLength = 256
+
+	// TranscriptFileName is the name of the file in the user cache directory that holds the last RPS session transcript
+	TranscriptFileName = "last-session.log"
+
+	// AccessErrorMessage is displayed when rpc is unable to communicate with the AMT host interface
+	AccessErrorMessage = "Unable to launch application. Please ensure that Intel ME is present, the MEI driver is installed and that this application is run with administrator or root privileges."
+)
+
+// Exit codes reported by rpc
+const (
+	// Success indicates the command completed
+	Success = 0
+	// GenericFailure indicates an error without a more specific exit code
+	GenericFailure = 1
+	// DeviceNotFound indicates the MEI device node does not exist
+	DeviceNotFound = 10
+	// DriverNotLoaded indicates the MEI driver is not loaded
+	DriverNotLoaded = 11
+	// NotRoot indicates rpc needs to run as root or Administrator
+	NotRoot = 12
+	// PermissionDenied indicates access to the MEI device was denied despite running as root
+	PermissionDenied = 13
+	// ClientNotFound indicates the AMT host interface client is not exposed by the firmware
+	ClientNotFound = 14
+	// FirmwareBusy indicates the firmware rejected the connection because it is busy
+	FirmwareBusy = 15
+	// ContainerDeviceMissing indicates rpc runs in a container without the MEI device passed through
+	ContainerDeviceMissing = 16
+	// UnsupportedFirmware indicates the AMT version does not support the requested command
+	UnsupportedFirmware = 17
 )
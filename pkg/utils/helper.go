@@ -76,3 +76,31 @@ func InterpretRemoteAccessTrigger(status int) string {
 		return "unknown"
 	}
 }
+func InterpretIPv6AddressType(addressType int) string {
+	switch addressType {
+	case 0:
+		return "link-local"
+	case 1:
+		return "dhcpv6"
+	case 2:
+		return "slaac"
+	case 3:
+		return "manual"
+	default:
+		return "unknown"
+	}
+}
+func InterpretIPv6AddressState(state int) string {
+	switch state {
+	case 0:
+		return "tentative"
+	case 1:
+		return "preferred"
+	case 2:
+		return "deprecated"
+	case 3:
+		return "invalid"
+	default:
+		return "unknown"
+	}
+}
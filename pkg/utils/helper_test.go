@@ -109,3 +109,17 @@ func TestInterpretRemoteAccessConnectionStatus3(t *testing.T) {
 	result := InterpretRemoteAccessConnectionStatus(3)
 	assert.Equal(t, "unknown", result)
 }
+func TestInterpretIPv6AddressType(t *testing.T) {
+	assert.Equal(t, "link-local", InterpretIPv6AddressType(0))
+	assert.Equal(t, "dhcpv6", InterpretIPv6AddressType(1))
+	assert.Equal(t, "slaac", InterpretIPv6AddressType(2))
+	assert.Equal(t, "manual", InterpretIPv6AddressType(3))
+	assert.Equal(t, "unknown", InterpretIPv6AddressType(4))
+}
+func TestInterpretIPv6AddressState(t *testing.T) {
+	assert.Equal(t, "tentative", InterpretIPv6AddressState(0))
+	assert.Equal(t, "preferred", InterpretIPv6AddressState(1))
+	assert.Equal(t, "deprecated", InterpretIPv6AddressState(2))
+	assert.Equal(t, "invalid", InterpretIPv6AddressState(3))
+	assert.Equal(t, "unknown", InterpretIPv6AddressState(4))
+}
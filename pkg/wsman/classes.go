@@ -0,0 +1,532 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package wsman
+
+import "encoding/xml"
+
+// Resource URI prefixes of the AMT, CIM and IPS schemas
+const (
+	AMTSchema = "http://intel.com/wbem/wscim/1/amt-schema/1/"
+	CIMSchema = "http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/"
+	IPSSchema = "http://intel.com/wbem/wscim/1/ips-schema/1/"
+)
+
+// Resource URIs of the classes below
+const (
+	AMTGeneralSettingsURI              = AMTSchema + "AMT_GeneralSettings"
+	AMTEthernetPortSettingsURI         = AMTSchema + "AMT_EthernetPortSettings"
+	AMTSetupAndConfigurationServiceURI = AMTSchema + "AMT_SetupAndConfigurationService"
+	CIMSoftwareIdentityURI             = CIMSchema + "CIM_SoftwareIdentity"
+	IPSHostBasedSetupServiceURI        = IPSSchema + "IPS_HostBasedSetupService"
+	CIMComputerSystemURI               = CIMSchema + "CIM_ComputerSystem"
+	CIMPowerManagementServiceURI       = CIMSchema + "CIM_PowerManagementService"
+	CIMAssociatedPowerURI              = CIMSchema + "CIM_AssociatedPowerManagementService"
+	AMTRedirectionServiceURI           = AMTSchema + "AMT_RedirectionService"
+	CIMKVMRedirectionSAPURI            = CIMSchema + "CIM_KVMRedirectionSAP"
+	IPSKVMRedirectionSettingDataURI    = IPSSchema + "IPS_KVMRedirectionSettingData"
+	IPSOptInServiceURI                 = IPSSchema + "IPS_OptInService"
+	AMTMessageLogURI                   = AMTSchema + "AMT_MessageLog"
+	AMTAuditLogURI                     = AMTSchema + "AMT_AuditLog"
+	CIMProcessorURI                    = CIMSchema + "CIM_Processor"
+	CIMPhysicalMemoryURI               = CIMSchema + "CIM_PhysicalMemory"
+	CIMChassisURI                      = CIMSchema + "CIM_Chassis"
+	CIMBIOSElementURI                  = CIMSchema + "CIM_BIOSElement"
+	CIMMediaAccessDeviceURI            = CIMSchema + "CIM_MediaAccessDevice"
+	AMTBootSettingDataURI              = AMTSchema + "AMT_BootSettingData"
+	CIMBootConfigSettingURI            = CIMSchema + "CIM_BootConfigSetting"
+	CIMBootSourceSettingURI            = CIMSchema + "CIM_BootSourceSetting"
+	CIMBootServiceURI                  = CIMSchema + "CIM_BootService"
+	AMTAuthorizationServiceURI         = AMTSchema + "AMT_AuthorizationService"
+	AMTWiFiPortConfigurationServiceURI = AMTSchema + "AMT_WiFiPortConfigurationService"
+	CIMWiFiPortURI                     = CIMSchema + "CIM_WiFiPort"
+	CIMWiFiEndpointURI                 = CIMSchema + "CIM_WiFiEndpoint"
+	CIMWiFiEndpointSettingsURI         = CIMSchema + "CIM_WiFiEndpointSettings"
+	AMTPublicKeyManagementServiceURI   = AMTSchema + "AMT_PublicKeyManagementService"
+	AMTPublicKeyCertificateURI         = AMTSchema + "AMT_PublicKeyCertificate"
+)
+
+// RequestStateChangeInput is the input of the RequestStateChange method every enabled logical element has
+type RequestStateChangeInput struct {
+	XMLName        xml.Name
+	RequestedState int `xml:"RequestedState"`
+}
+
+// NewRequestStateChange creates the RequestStateChange input for the class at resourceURI
+func NewRequestStateChange(resourceURI string, requestedState int) RequestStateChangeInput {
+	return RequestStateChangeInput{
+		XMLName:        xml.Name{Space: resourceURI, Local: "RequestStateChange_INPUT"},
+		RequestedState: requestedState,
+	}
+}
+
+// MethodOutput decodes the return value of any method's _OUTPUT element
+type MethodOutput struct {
+	ReturnValue int `xml:"ReturnValue"`
+}
+
+// AMTGeneralSettings holds the AMT network identity and general configuration
+type AMTGeneralSettings struct {
+	XMLName                       xml.Name `xml:"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_GeneralSettings AMT_GeneralSettings"`
+	ElementName                   string   `xml:"ElementName"`
+	InstanceID                    string   `xml:"InstanceID"`
+	NetworkInterfaceEnabled       bool     `xml:"NetworkInterfaceEnabled"`
+	DigestRealm                   string   `xml:"DigestRealm"`
+	IdleWakeTimeout               int      `xml:"IdleWakeTimeout"`
+	HostName                      string   `xml:"HostName"`
+	DomainName                    string   `xml:"DomainName"`
+	PingResponseEnabled           bool     `xml:"PingResponseEnabled"`
+	WsmanOnlyMode                 bool     `xml:"WsmanOnlyMode"`
+	PreferredAddressFamily        int      `xml:"PreferredAddressFamily"`
+	DHCPv6ConfigurationTimeout    int      `xml:"DHCPv6ConfigurationTimeout"`
+	DDNSUpdateEnabled             bool     `xml:"DDNSUpdateEnabled"`
+	DDNSUpdateByDHCPServerEnabled bool     `xml:"DDNSUpdateByDHCPServerEnabled"`
+	SharedFQDN                    bool     `xml:"SharedFQDN"`
+	HostOSFQDN                    string   `xml:"HostOSFQDN,omitempty"`
+	DDNSTTL                       int      `xml:"DDNSTTL"`
+	AMTNetworkEnabled             int      `xml:"AMTNetworkEnabled"`
+	RmcpPingResponseEnabled       bool     `xml:"RmcpPingResponseEnabled"`
+	DDNSPeriodicUpdateInterval    int      `xml:"DDNSPeriodicUpdateInterval"`
+	PresenceNotificationInterval  int      `xml:"PresenceNotificationInterval"`
+	PrivacyLevel                  int      `xml:"PrivacyLevel"`
+	PowerSource                   int      `xml:"PowerSource"`
+	ThunderboltDockEnabled        int      `xml:"ThunderboltDockEnabled"`
+}
+
+// AMTEthernetPortSettings holds the configuration of a wired or wireless AMT interface
+type AMTEthernetPortSettings struct {
+	XMLName                xml.Name `xml:"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_EthernetPortSettings AMT_EthernetPortSettings"`
+	ElementName            string   `xml:"ElementName"`
+	InstanceID             string   `xml:"InstanceID"`
+	VLANTag                int      `xml:"VLANTag,omitempty"`
+	SharedMAC              bool     `xml:"SharedMAC"`
+	MACAddress             string   `xml:"MACAddress"`
+	LinkIsUp               bool     `xml:"LinkIsUp"`
+	LinkPolicy             []int    `xml:"LinkPolicy,omitempty"`
+	LinkPreference         int      `xml:"LinkPreference,omitempty"`
+	LinkControl            int      `xml:"LinkControl,omitempty"`
+	SharedStaticIp         bool     `xml:"SharedStaticIp"`
+	SharedDynamicIP        bool     `xml:"SharedDynamicIP"`
+	IpSyncEnabled          bool     `xml:"IpSyncEnabled"`
+	DHCPEnabled            bool     `xml:"DHCPEnabled"`
+	IPAddress              string   `xml:"IPAddress,omitempty"`
+	SubnetMask             string   `xml:"SubnetMask,omitempty"`
+	DefaultGateway         string   `xml:"DefaultGateway,omitempty"`
+	PrimaryDNS             string   `xml:"PrimaryDNS,omitempty"`
+	SecondaryDNS           string   `xml:"SecondaryDNS,omitempty"`
+	PhysicalConnectionType int      `xml:"PhysicalConnectionType"`
+}
+
+// AMTSetupAndConfigurationService reports the provisioning state
+type AMTSetupAndConfigurationService struct {
+	XMLName                       xml.Name `xml:"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_SetupAndConfigurationService AMT_SetupAndConfigurationService"`
+	ElementName                   string   `xml:"ElementName"`
+	Name                          string   `xml:"Name"`
+	EnabledState                  int      `xml:"EnabledState"`
+	ProvisioningMode              int      `xml:"ProvisioningMode"`
+	ProvisioningState             int      `xml:"ProvisioningState"`
+	ZeroTouchConfigurationEnabled bool     `xml:"ZeroTouchConfigurationEnabled"`
+	ProvisioningServerOTP         string   `xml:"ProvisioningServerOTP,omitempty"`
+	ConfigurationServerFQDN       string   `xml:"ConfigurationServerFQDN,omitempty"`
+	PasswordModel                 int      `xml:"PasswordModel"`
+	DhcpDNSSuffix                 string   `xml:"DhcpDNSSuffix,omitempty"`
+	TrustedDNSSuffix              string   `xml:"TrustedDNSSuffix,omitempty"`
+}
+
+// CIMSoftwareIdentity is one firmware component version
+type CIMSoftwareIdentity struct {
+	XMLName       xml.Name `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_SoftwareIdentity CIM_SoftwareIdentity"`
+	InstanceID    string   `xml:"InstanceID"`
+	VersionString string   `xml:"VersionString"`
+	IsEntity      bool     `xml:"IsEntity"`
+}
+
+// IPSHostBasedSetupService reports which host based provisioning modes are allowed
+type IPSHostBasedSetupService struct {
+	XMLName             xml.Name `xml:"http://intel.com/wbem/wscim/1/ips-schema/1/IPS_HostBasedSetupService IPS_HostBasedSetupService"`
+	ElementName         string   `xml:"ElementName"`
+	Name                string   `xml:"Name"`
+	CurrentControlMode  int      `xml:"CurrentControlMode"`
+	AllowedControlModes []int    `xml:"AllowedControlModes"`
+	ConfigurationNonce  string   `xml:"ConfigurationNonce,omitempty"`
+	CertChainStatus     int      `xml:"CertChainStatus"`
+}
+
+// ManagedSystem refers to the host computer system managed by AMT
+var ManagedSystem = NewReference(CIMComputerSystemURI,
+	Selector{Name: "CreationClassName", Value: "CIM_ComputerSystem"},
+	Selector{Name: "Name", Value: "ManagedSystem"})
+
+// CIMAssociatedPowerManagementService reports the host power state and the states it can be changed to
+type CIMAssociatedPowerManagementService struct {
+	XMLName                       xml.Name `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_AssociatedPowerManagementService CIM_AssociatedPowerManagementService"`
+	PowerState                    int      `xml:"PowerState"`
+	AvailableRequestedPowerStates []int    `xml:"AvailableRequestedPowerStates"`
+	RequestedPowerState           int      `xml:"RequestedPowerState"`
+}
+
+// RequestPowerStateChangeInput is the input of CIM_PowerManagementService.RequestPowerStateChange
+type RequestPowerStateChangeInput struct {
+	XMLName        xml.Name          `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_PowerManagementService RequestPowerStateChange_INPUT"`
+	PowerState     int               `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_PowerManagementService PowerState"`
+	ManagedElement EndpointReference `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_PowerManagementService ManagedElement"`
+}
+
+// AMTRedirectionService controls the SOL and IDE-R redirection and the redirection listener
+type AMTRedirectionService struct {
+	XMLName                 xml.Name `xml:"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_RedirectionService AMT_RedirectionService"`
+	CreationClassName       string   `xml:"CreationClassName"`
+	ElementName             string   `xml:"ElementName"`
+	EnabledState            int      `xml:"EnabledState"`
+	ListenerEnabled         bool     `xml:"ListenerEnabled"`
+	Name                    string   `xml:"Name"`
+	SystemCreationClassName string   `xml:"SystemCreationClassName"`
+	SystemName              string   `xml:"SystemName"`
+}
+
+// CIMKVMRedirectionSAP is the KVM redirection service access point
+type CIMKVMRedirectionSAP struct {
+	XMLName                 xml.Name `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_KVMRedirectionSAP CIM_KVMRedirectionSAP"`
+	CreationClassName       string   `xml:"CreationClassName"`
+	ElementName             string   `xml:"ElementName"`
+	EnabledState            int      `xml:"EnabledState"`
+	KVMProtocol             int      `xml:"KVMProtocol"`
+	Name                    string   `xml:"Name"`
+	RequestedState          int      `xml:"RequestedState"`
+	SystemCreationClassName string   `xml:"SystemCreationClassName"`
+	SystemName              string   `xml:"SystemName"`
+}
+
+// IPSKVMRedirectionSettingData holds the KVM session settings
+type IPSKVMRedirectionSettingData struct {
+	XMLName           xml.Name `xml:"http://intel.com/wbem/wscim/1/ips-schema/1/IPS_KVMRedirectionSettingData IPS_KVMRedirectionSettingData"`
+	DefaultScreen     int      `xml:"DefaultScreen"`
+	ElementName       string   `xml:"ElementName"`
+	EnabledByMEBx     bool     `xml:"EnabledByMEBx"`
+	InstanceID        string   `xml:"InstanceID"`
+	Is5900PortEnabled bool     `xml:"Is5900PortEnabled"`
+	OptInPolicy       bool     `xml:"OptInPolicy"`
+	SessionTimeout    int      `xml:"SessionTimeout"`
+}
+
+// IPSOptInService holds the user consent policy for redirection sessions
+type IPSOptInService struct {
+	XMLName                 xml.Name `xml:"http://intel.com/wbem/wscim/1/ips-schema/1/IPS_OptInService IPS_OptInService"`
+	CanModifyOptInPolicy    int      `xml:"CanModifyOptInPolicy"`
+	CreationClassName       string   `xml:"CreationClassName"`
+	ElementName             string   `xml:"ElementName"`
+	Name                    string   `xml:"Name"`
+	OptInCodeTimeout        int      `xml:"OptInCodeTimeout"`
+	OptInDisplayTimeout     int      `xml:"OptInDisplayTimeout"`
+	OptInRequired           uint32   `xml:"OptInRequired"`
+	OptInState              int      `xml:"OptInState"`
+	SystemCreationClassName string   `xml:"SystemCreationClassName"`
+	SystemName              string   `xml:"SystemName"`
+}
+
+// PositionToFirstRecordInput is the input of AMT_MessageLog.PositionToFirstRecord
+type PositionToFirstRecordInput struct {
+	XMLName xml.Name `xml:"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_MessageLog PositionToFirstRecord_INPUT"`
+}
+
+// PositionToFirstRecordOutput is the output of AMT_MessageLog.PositionToFirstRecord
+type PositionToFirstRecordOutput struct {
+	IterationIdentifier string `xml:"IterationIdentifier"`
+	ReturnValue         int    `xml:"ReturnValue"`
+}
+
+// GetRecordsInput is the input of AMT_MessageLog.GetRecords
+type GetRecordsInput struct {
+	XMLName             xml.Name `xml:"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_MessageLog GetRecords_INPUT"`
+	IterationIdentifier string   `xml:"IterationIdentifier"`
+	MaxReadRecords      int      `xml:"MaxReadRecords"`
+}
+
+// GetRecordsOutput is the output of AMT_MessageLog.GetRecords, each record is base64 encoded
+type GetRecordsOutput struct {
+	IterationIdentifier string   `xml:"IterationIdentifier"`
+	NoMoreRecords       bool     `xml:"NoMoreRecords"`
+	RecordArray         []string `xml:"RecordArray"`
+	ReturnValue         int      `xml:"ReturnValue"`
+}
+
+// ReadRecordsInput is the input of AMT_AuditLog.ReadRecords
+type ReadRecordsInput struct {
+	XMLName    xml.Name `xml:"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_AuditLog ReadRecords_INPUT"`
+	StartIndex int      `xml:"StartIndex"`
+}
+
+// ReadRecordsOutput is the output of AMT_AuditLog.ReadRecords, each record is base64 encoded
+type ReadRecordsOutput struct {
+	TotalRecordCount int      `xml:"TotalRecordCount"`
+	RecordsReturned  int      `xml:"RecordsReturned"`
+	EventRecords     []string `xml:"EventRecords"`
+	ReturnValue      int      `xml:"ReturnValue"`
+}
+
+// Datetime is a CIM date and time property
+type Datetime struct {
+	Datetime string `xml:"Datetime"`
+}
+
+// CIMProcessor is a processor from the AMT hardware asset table
+type CIMProcessor struct {
+	XMLName           xml.Name `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_Processor CIM_Processor"`
+	CPUStatus         int      `xml:"CPUStatus"`
+	CurrentClockSpeed int      `xml:"CurrentClockSpeed"`
+	DeviceID          string   `xml:"DeviceID"`
+	ElementName       string   `xml:"ElementName"`
+	Family            int      `xml:"Family"`
+	MaxClockSpeed     int      `xml:"MaxClockSpeed"`
+	Stepping          string   `xml:"Stepping"`
+}
+
+// CIMPhysicalMemory is a memory module from the AMT hardware asset table
+type CIMPhysicalMemory struct {
+	XMLName                    xml.Name `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_PhysicalMemory CIM_PhysicalMemory"`
+	BankLabel                  string   `xml:"BankLabel"`
+	Capacity                   uint64   `xml:"Capacity"`
+	ConfiguredMemoryClockSpeed int      `xml:"ConfiguredMemoryClockSpeed"`
+	ElementName                string   `xml:"ElementName"`
+	FormFactor                 int      `xml:"FormFactor"`
+	Manufacturer               string   `xml:"Manufacturer"`
+	MaxMemorySpeed             int      `xml:"MaxMemorySpeed"`
+	MemoryType                 int      `xml:"MemoryType"`
+	PartNumber                 string   `xml:"PartNumber"`
+	SerialNumber               string   `xml:"SerialNumber"`
+	Speed                      int      `xml:"Speed"`
+	Tag                        string   `xml:"Tag"`
+}
+
+// CIMChassis is the system enclosure from the AMT hardware asset table
+type CIMChassis struct {
+	XMLName            xml.Name `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_Chassis CIM_Chassis"`
+	ChassisPackageType int      `xml:"ChassisPackageType"`
+	ElementName        string   `xml:"ElementName"`
+	Manufacturer       string   `xml:"Manufacturer"`
+	Model              string   `xml:"Model"`
+	SerialNumber       string   `xml:"SerialNumber"`
+	Tag                string   `xml:"Tag"`
+	Version            string   `xml:"Version"`
+}
+
+// CIMBIOSElement is the BIOS from the AMT hardware asset table
+type CIMBIOSElement struct {
+	XMLName      xml.Name `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_BIOSElement CIM_BIOSElement"`
+	ElementName  string   `xml:"ElementName"`
+	Manufacturer string   `xml:"Manufacturer"`
+	PrimaryBIOS  bool     `xml:"PrimaryBIOS"`
+	ReleaseDate  Datetime `xml:"ReleaseDate"`
+	Version      string   `xml:"Version"`
+}
+
+// CIMMediaAccessDevice is a disk or optical drive from the AMT hardware asset table, MaxMediaSize is in KB
+type CIMMediaAccessDevice struct {
+	XMLName      xml.Name `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_MediaAccessDevice CIM_MediaAccessDevice"`
+	Capabilities []int    `xml:"Capabilities"`
+	DeviceID     string   `xml:"DeviceID"`
+	ElementName  string   `xml:"ElementName"`
+	MaxMediaSize uint64   `xml:"MaxMediaSize"`
+}
+
+// AMTBootSettingData holds the options AMT passes to the BIOS for the next boot
+type AMTBootSettingData struct {
+	XMLName                xml.Name `xml:"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_BootSettingData AMT_BootSettingData"`
+	BIOSPause              bool     `xml:"BIOSPause"`
+	BIOSSetup              bool     `xml:"BIOSSetup"`
+	BootMediaIndex         int      `xml:"BootMediaIndex"`
+	ConfigurationDataReset bool     `xml:"ConfigurationDataReset,omitempty"`
+	ElementName            string   `xml:"ElementName"`
+	EnforceSecureBoot      bool     `xml:"EnforceSecureBoot,omitempty"`
+	FirmwareVerbosity      int      `xml:"FirmwareVerbosity"`
+	ForcedProgressEvents   bool     `xml:"ForcedProgressEvents"`
+	IDERBootDevice         int      `xml:"IDERBootDevice"`
+	InstanceID             string   `xml:"InstanceID"`
+	LockKeyboard           bool     `xml:"LockKeyboard"`
+	LockPowerButton        bool     `xml:"LockPowerButton"`
+	LockResetButton        bool     `xml:"LockResetButton"`
+	LockSleepButton        bool     `xml:"LockSleepButton"`
+	OwningEntity           string   `xml:"OwningEntity"`
+	ReflashBIOS            bool     `xml:"ReflashBIOS"`
+	SecureErase            bool     `xml:"SecureErase,omitempty"`
+	UseIDER                bool     `xml:"UseIDER"`
+	UseSOL                 bool     `xml:"UseSOL"`
+	UseSafeMode            bool     `xml:"UseSafeMode"`
+	UserPasswordBypass     bool     `xml:"UserPasswordBypass"`
+}
+
+// CIMBootSourceSetting is a device AMT can force the next boot from
+type CIMBootSourceSetting struct {
+	XMLName              xml.Name `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_BootSourceSetting CIM_BootSourceSetting"`
+	ElementName          string   `xml:"ElementName"`
+	FailThroughSupported int      `xml:"FailThroughSupported"`
+	InstanceID           string   `xml:"InstanceID"`
+	StructuredBootString string   `xml:"StructuredBootString"`
+}
+
+// ChangeBootOrderInput is the input of CIM_BootConfigSetting.ChangeBootOrder, a nil source clears the boot order
+type ChangeBootOrderInput struct {
+	XMLName xml.Name           `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_BootConfigSetting ChangeBootOrder_INPUT"`
+	Source  *EndpointReference `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_BootConfigSetting Source,omitempty"`
+}
+
+// SetBootConfigRoleInput is the input of CIM_BootService.SetBootConfigRole
+type SetBootConfigRoleInput struct {
+	XMLName           xml.Name          `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_BootService SetBootConfigRole_INPUT"`
+	BootConfigSetting EndpointReference `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_BootService BootConfigSetting"`
+	Role              int               `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_BootService Role"`
+}
+
+// EnumerateUserAclEntriesInput is the input of AMT_AuthorizationService.EnumerateUserAclEntries, StartIndex starts at 1
+type EnumerateUserAclEntriesInput struct {
+	XMLName    xml.Name `xml:"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_AuthorizationService EnumerateUserAclEntries_INPUT"`
+	StartIndex int      `xml:"StartIndex"`
+}
+
+// EnumerateUserAclEntriesOutput is the output of AMT_AuthorizationService.EnumerateUserAclEntries
+type EnumerateUserAclEntriesOutput struct {
+	TotalCount   int   `xml:"TotalCount"`
+	HandlesCount int   `xml:"HandlesCount"`
+	Handles      []int `xml:"Handles"`
+	ReturnValue  int   `xml:"ReturnValue"`
+}
+
+// GetUserAclEntryExInput is the input of AMT_AuthorizationService.GetUserAclEntryEx
+type GetUserAclEntryExInput struct {
+	XMLName xml.Name `xml:"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_AuthorizationService GetUserAclEntryEx_INPUT"`
+	Handle  int      `xml:"Handle"`
+}
+
+// GetUserAclEntryExOutput is the output of AMT_AuthorizationService.GetUserAclEntryEx, the password is never returned
+type GetUserAclEntryExOutput struct {
+	DigestUsername   string `xml:"DigestUsername"`
+	KerberosUserSid  string `xml:"KerberosUserSid"`
+	AccessPermission int    `xml:"AccessPermission"`
+	Realms           []int  `xml:"Realms"`
+	ReturnValue      int    `xml:"ReturnValue"`
+}
+
+// AddUserAclEntryExInput is the input of AMT_AuthorizationService.AddUserAclEntryEx.
+// DigestPassword is the base64 encoded MD5 hash of username:digest realm:password.
+type AddUserAclEntryExInput struct {
+	XMLName          xml.Name `xml:"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_AuthorizationService AddUserAclEntryEx_INPUT"`
+	DigestUsername   string   `xml:"DigestUsername"`
+	DigestPassword   string   `xml:"DigestPassword"`
+	AccessPermission int      `xml:"AccessPermission"`
+	Realms           []int    `xml:"Realms"`
+}
+
+// AddUserAclEntryExOutput is the output of AMT_AuthorizationService.AddUserAclEntryEx
+type AddUserAclEntryExOutput struct {
+	Handle      int `xml:"Handle"`
+	ReturnValue int `xml:"ReturnValue"`
+}
+
+// UpdateUserAclEntryExInput is the input of AMT_AuthorizationService.UpdateUserAclEntryEx, an empty password is left unchanged
+type UpdateUserAclEntryExInput struct {
+	XMLName          xml.Name `xml:"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_AuthorizationService UpdateUserAclEntryEx_INPUT"`
+	Handle           int      `xml:"Handle"`
+	DigestUsername   string   `xml:"DigestUsername"`
+	DigestPassword   string   `xml:"DigestPassword,omitempty"`
+	AccessPermission int      `xml:"AccessPermission"`
+	Realms           []int    `xml:"Realms"`
+}
+
+// RemoveUserAclEntryInput is the input of AMT_AuthorizationService.RemoveUserAclEntry
+type RemoveUserAclEntryInput struct {
+	XMLName xml.Name `xml:"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_AuthorizationService RemoveUserAclEntry_INPUT"`
+	Handle  int      `xml:"Handle"`
+}
+
+// AMTWiFiPortConfigurationService adds wireless profiles and controls whether AMT uses the profiles of the OS
+type AMTWiFiPortConfigurationService struct {
+	XMLName                            xml.Name `xml:"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_WiFiPortConfigurationService AMT_WiFiPortConfigurationService"`
+	CreationClassName                  string   `xml:"CreationClassName"`
+	ElementName                        string   `xml:"ElementName"`
+	EnabledState                       int      `xml:"EnabledState"`
+	HealthState                        int      `xml:"HealthState"`
+	Name                               string   `xml:"Name"`
+	RequestedState                     int      `xml:"RequestedState"`
+	SystemCreationClassName            string   `xml:"SystemCreationClassName"`
+	SystemName                         string   `xml:"SystemName"`
+	LocalProfileSynchronizationEnabled int      `xml:"localProfileSynchronizationEnabled"`
+	LastConnectedSsidUnderMeControl    string   `xml:"LastConnectedSsidUnderMeControl,omitempty"`
+}
+
+// CIMWiFiPort is the wireless network adapter, AMT only uses it while it is enabled
+type CIMWiFiPort struct {
+	XMLName          xml.Name `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_WiFiPort CIM_WiFiPort"`
+	DeviceID         string   `xml:"DeviceID"`
+	ElementName      string   `xml:"ElementName"`
+	EnabledState     int      `xml:"EnabledState"`
+	PermanentAddress string   `xml:"PermanentAddress"`
+	RequestedState   int      `xml:"RequestedState"`
+}
+
+// CIMWiFiEndpointSettings is a wireless profile, AMT never returns the passphrase
+type CIMWiFiEndpointSettings struct {
+	XMLName              xml.Name `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_WiFiEndpointSettings CIM_WiFiEndpointSettings"`
+	AuthenticationMethod int      `xml:"AuthenticationMethod"`
+	BSSType              int      `xml:"BSSType"`
+	ElementName          string   `xml:"ElementName"`
+	EncryptionMethod     int      `xml:"EncryptionMethod"`
+	InstanceID           string   `xml:"InstanceID"`
+	Priority             int      `xml:"Priority"`
+	SSID                 string   `xml:"SSID"`
+}
+
+// WiFiEndpointSettingsInput is the wireless profile passed to AddWiFiSettings
+type WiFiEndpointSettingsInput struct {
+	XMLName              xml.Name `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_WiFiEndpointSettings WiFiEndpointSettingsInput"`
+	ElementName          string   `xml:"ElementName"`
+	InstanceID           string   `xml:"InstanceID"`
+	AuthenticationMethod int      `xml:"AuthenticationMethod"`
+	EncryptionMethod     int      `xml:"EncryptionMethod"`
+	SSID                 string   `xml:"SSID"`
+	Priority             int      `xml:"Priority"`
+	PSKPassPhrase        string   `xml:"PSKPassPhrase,omitempty"`
+}
+
+// IEEE8021xSettingsInput holds the 802.1x credentials of a WPA2-Enterprise profile passed to AddWiFiSettings
+type IEEE8021xSettingsInput struct {
+	XMLName                xml.Name `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_IEEE8021xSettings IEEE8021xSettingsInput"`
+	ElementName            string   `xml:"ElementName"`
+	InstanceID             string   `xml:"InstanceID"`
+	AuthenticationProtocol int      `xml:"AuthenticationProtocol"`
+	Username               string   `xml:"Username"`
+	Password               string   `xml:"Password"`
+}
+
+// AddWiFiSettingsInput is the input of AMT_WiFiPortConfigurationService.AddWiFiSettings
+type AddWiFiSettingsInput struct {
+	XMLName                   xml.Name                  `xml:"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_WiFiPortConfigurationService AddWiFiSettings_INPUT"`
+	WiFiEndpoint              EndpointReference         `xml:"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_WiFiPortConfigurationService WiFiEndpoint"`
+	WiFiEndpointSettingsInput WiFiEndpointSettingsInput `xml:"WiFiEndpointSettingsInput"`
+	IEEE8021xSettingsInput    *IEEE8021xSettingsInput   `xml:"IEEE8021xSettingsInput,omitempty"`
+	CACredential              *EndpointReference        `xml:"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_WiFiPortConfigurationService CACredential,omitempty"`
+}
+
+// AddTrustedRootCertificateInput is the input of AMT_PublicKeyManagementService.AddTrustedRootCertificate, the blob is the base64 DER certificate
+type AddTrustedRootCertificateInput struct {
+	XMLName         xml.Name `xml:"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_PublicKeyManagementService AddTrustedRootCertificate_INPUT"`
+	CertificateBlob string   `xml:"CertificateBlob"`
+}
+
+// AddTrustedRootCertificateOutput is the output of AMT_PublicKeyManagementService.AddTrustedRootCertificate
+type AddTrustedRootCertificateOutput struct {
+	CreatedCertificate EndpointReference `xml:"CreatedCertificate"`
+	ReturnValue        int               `xml:"ReturnValue"`
+}
+
+// AMTPublicKeyCertificate is a certificate stored in AMT, the certificate is the base64 DER encoding
+type AMTPublicKeyCertificate struct {
+	XMLName xml.Name `xml:"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_PublicKeyCertificate AMT_PublicKeyCertificate"`
+	// TrustedRootCertficate is misspelt in the AMT schema
+	TrustedRootCertficate bool   `xml:"TrustedRootCertficate"`
+	ElementName           string `xml:"ElementName"`
+	InstanceID            string `xml:"InstanceID"`
+	Issuer                string `xml:"Issuer"`
+	Subject               string `xml:"Subject"`
+	X509Certificate       string `xml:"X509Certificate"`
+}
@@ -0,0 +1,192 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package wsman
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"rpc/pkg/utils"
+	"time"
+)
+
+// Path is the WS-Management endpoint on the AMT HTTP port
+const Path = "/wsman"
+
+// maxElements is the number of items requested per pull
+const maxElements = 100
+
+// DefaultEndpoint is AMT reached through LMS on the local host
+var DefaultEndpoint = "http://" + net.JoinHostPort(utils.LMSAddress, utils.LMSPort) + Path
+
+// Client sends WS-Management requests to AMT with digest authentication
+type Client struct {
+	Endpoint   string
+	HTTPClient *http.Client
+	auth       *digestAuth
+}
+
+// NewClient creates a client for endpoint, for example DefaultEndpoint
+func NewClient(endpoint string, username string, password string) *Client {
+	return &Client{
+		Endpoint:   endpoint,
+		HTTPClient: &http.Client{Timeout: 60 * time.Second},
+		auth:       &digestAuth{Username: username, Password: password},
+	}
+}
+
+// Get reads the instance of resourceURI identified by selectors into out
+func (c *Client) Get(resourceURI string, selectors []Selector, out interface{}) error {
+	return c.call(request{Action: ActionGet, ResourceURI: resourceURI, Selectors: selectors}, out)
+}
+
+// Put replaces the instance of resourceURI with in and decodes the updated instance into out, which may be nil
+func (c *Client) Put(resourceURI string, selectors []Selector, in interface{}, out interface{}) error {
+	body, err := xml.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return c.call(request{Action: ActionPut, ResourceURI: resourceURI, Selectors: selectors, Body: body}, out)
+}
+
+// Delete removes the instance of resourceURI identified by selectors
+func (c *Client) Delete(resourceURI string, selectors []Selector) error {
+	return c.call(request{Action: ActionDelete, ResourceURI: resourceURI, Selectors: selectors}, nil)
+}
+
+// Invoke calls method on resourceURI with the method's _INPUT struct and decodes the _OUTPUT struct into out
+func (c *Client) Invoke(resourceURI string, method string, selectors []Selector, in interface{}, out interface{}) error {
+	body, err := xml.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return c.call(request{Action: resourceURI + "/" + method, ResourceURI: resourceURI, Selectors: selectors, Body: body}, out)
+}
+
+// Enumerate starts an enumeration of resourceURI and returns its context
+func (c *Client) Enumerate(resourceURI string) (string, error) {
+	response := pullResponse{}
+	body := []byte(`<n:Enumerate/>`)
+	err := c.call(request{Action: ActionEnumerate, ResourceURI: resourceURI, Body: body}, &response)
+	if err != nil {
+		return "", err
+	}
+	if response.EnumerationContext == "" {
+		return "", errors.New("wsman: enumerate response has no context")
+	}
+	return response.EnumerationContext, nil
+}
+
+// Pull returns the next items of an enumeration, the context for the following pull and whether the enumeration ended
+func (c *Client) Pull(resourceURI string, context string) ([]Item, string, bool, error) {
+	response := pullResponse{}
+	body := []byte(fmt.Sprintf(`<n:Pull><n:EnumerationContext>%s</n:EnumerationContext><n:MaxElements>%d</n:MaxElements></n:Pull>`, escape(context), maxElements))
+	err := c.call(request{Action: ActionPull, ResourceURI: resourceURI, Body: body}, &response)
+	if err != nil {
+		return nil, "", false, err
+	}
+	items := []Item{}
+	for _, n := range response.Items.Nodes {
+		item, err := n.item()
+		if err != nil {
+			return nil, "", false, err
+		}
+		items = append(items, item)
+	}
+	return items, response.EnumerationContext, response.EndOfSequence != nil, nil
+}
+
+// EnumerateAll enumerates resourceURI and pulls until the end of the sequence
+func (c *Client) EnumerateAll(resourceURI string) ([]Item, error) {
+	context, err := c.Enumerate(resourceURI)
+	if err != nil {
+		return nil, err
+	}
+	all := []Item{}
+	for {
+		items, next, end, err := c.Pull(resourceURI, context)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if end || next == "" {
+			return all, nil
+		}
+		context = next
+	}
+}
+
+// call sends r and decodes the first element of the response body into out
+func (c *Client) call(r request, out interface{}) error {
+	data, err := c.post(r.marshal(c.Endpoint, newMessageID()))
+	if err != nil {
+		return err
+	}
+	decoder, start, err := bodyDecoder(data)
+	if err != nil {
+		return err
+	}
+	if out == nil || start == nil {
+		return nil
+	}
+	return decoder.DecodeElement(out, start)
+}
+
+// post sends the envelope, answering a digest challenge once per request
+func (c *Client) post(envelope []byte) ([]byte, error) {
+	endpoint, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(http.MethodPost, c.Endpoint, bytes.NewReader(envelope))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/soap+xml; charset=utf-8")
+		if authorization := c.auth.authorization(http.MethodPost, endpoint.RequestURI()); authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusUnauthorized {
+			challenge, err := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+			if err != nil {
+				return nil, err
+			}
+			// a second challenge is only expected when the nonce went stale
+			if attempt > 0 && !challenge.Stale {
+				return nil, errors.New("wsman: authentication failed, check the AMT username and password")
+			}
+			if attempt > 1 {
+				return nil, errors.New("wsman: authentication failed")
+			}
+			c.auth.update(challenge)
+			continue
+		}
+		// AMT reports SOAP faults with an error status, which bodyDecoder turns into a *Fault
+		if resp.StatusCode != http.StatusOK && !bytes.Contains(data, []byte("Fault")) {
+			return nil, fmt.Errorf("wsman: unexpected HTTP status %s", resp.Status)
+		}
+		return data, nil
+	}
+}
+
+func newMessageID() string {
+	id := randomHex(16)
+	return "uuid:" + id[0:8] + "-" + id[8:12] + "-" + id[12:16] + "-" + id[16:20] + "-" + id[20:32]
+}
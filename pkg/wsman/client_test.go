@@ -0,0 +1,248 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package wsman
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRealm = "Digest:A3829B3827DE4D33D4449B366831B8E5"
+const testNonce = "3D0TBBkAAAAAAAAAAAAAAA=="
+
+// testRequest is the part of a request envelope the stand-in server inspects
+type testRequest struct {
+	Action      string
+	ResourceURI string
+	Selectors   map[string]string
+	Body        string
+}
+
+type testEnvelope struct {
+	Header struct {
+		Action      string `xml:"Action"`
+		ResourceURI string `xml:"ResourceURI"`
+		Selectors   []struct {
+			Name  string `xml:"Name,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"SelectorSet>Selector"`
+	} `xml:"Header"`
+	Body struct {
+		Inner string `xml:",innerxml"`
+	} `xml:"Body"`
+}
+
+// newTestServer stands in for AMT, requiring digest authentication as admin/P@ssw0rd
+func newTestServer(t *testing.T, handler func(r testRequest) (int, string)) (*httptest.Server, *[]testRequest) {
+	requests := []testRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !validDigest(r, "admin", "P@ssw0rd") {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Digest realm="%s", nonce="%s", stale="false", qop="auth"`, testRealm, testNonce))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		data, _ := ioutil.ReadAll(r.Body)
+		envelope := testEnvelope{}
+		if err := xml.Unmarshal(data, &envelope); err != nil {
+			t.Errorf("invalid envelope: %v", err)
+		}
+		request := testRequest{
+			Action:      envelope.Header.Action,
+			ResourceURI: envelope.Header.ResourceURI,
+			Selectors:   map[string]string{},
+			Body:        envelope.Body.Inner,
+		}
+		for _, selector := range envelope.Header.Selectors {
+			request.Selectors[selector.Name] = selector.Value
+		}
+		requests = append(requests, request)
+		status, response := handler(request)
+		w.Header().Set("Content-Type", "application/soap+xml; charset=UTF-8")
+		w.WriteHeader(status)
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+var digestParam = regexp.MustCompile(`(\w+)="?([^",]*)"?`)
+
+func validDigest(r *http.Request, username string, password string) bool {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Digest ") {
+		return false
+	}
+	params := map[string]string{}
+	for _, match := range digestParam.FindAllStringSubmatch(header[len("Digest "):], -1) {
+		params[match[1]] = match[2]
+	}
+	ha1 := md5Hex(username + ":" + testRealm + ":" + password)
+	ha2 := md5Hex(r.Method + ":" + params["uri"])
+	expected := md5Hex(ha1 + ":" + testNonce + ":" + params["nc"] + ":" + params["cnonce"] + ":auth:" + ha2)
+	return params["username"] == username && params["response"] == expected
+}
+
+// envelope wraps body in a response envelope using prefixes the way AMT does
+func envelope(body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?><a:Envelope xmlns:a="http://www.w3.org/2003/05/soap-envelope" xmlns:b="http://schemas.xmlsoap.org/ws/2004/08/addressing" xmlns:c="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd" xmlns:d="http://schemas.xmlsoap.org/ws/2005/02/trust" xmlns:e="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd" xmlns:f="http://schemas.dmtf.org/wbem/wsman/1/cimbinding.xsd" xmlns:g="http://schemas.xmlsoap.org/ws/2004/09/enumeration" xmlns:h="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_GeneralSettings" xmlns:i="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_EthernetPortSettings" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"><a:Header><b:To>http://schemas.xmlsoap.org/ws/2004/08/addressing/role/anonymous</b:To><b:RelatesTo>0</b:RelatesTo><b:Action a:mustUnderstand="true">response</b:Action><b:MessageID>uuid:00000000-8086-8086-8086-000000000001</b:MessageID></a:Header><a:Body>` + body + `</a:Body></a:Envelope>`
+}
+
+const generalSettingsBody = `<h:AMT_GeneralSettings><h:AMTNetworkEnabled>1</h:AMTNetworkEnabled><h:DDNSPeriodicUpdateInterval>1440</h:DDNSPeriodicUpdateInterval><h:DDNSTTL>900</h:DDNSTTL><h:DDNSUpdateByDHCPServerEnabled>true</h:DDNSUpdateByDHCPServerEnabled><h:DDNSUpdateEnabled>false</h:DDNSUpdateEnabled><h:DHCPv6ConfigurationTimeout>0</h:DHCPv6ConfigurationTimeout><h:DigestRealm>Digest:A3829B3827DE4D33D4449B366831B8E5</h:DigestRealm><h:DomainName>vprodemo.com</h:DomainName><h:ElementName>Intel(r) AMT: General Settings</h:ElementName><h:HostName>DESKTOP-1</h:HostName><h:HostOSFQDN>DESKTOP-1.vprodemo.com</h:HostOSFQDN><h:IdleWakeTimeout>65535</h:IdleWakeTimeout><h:InstanceID>Intel(r) AMT: General Settings</h:InstanceID><h:NetworkInterfaceEnabled>true</h:NetworkInterfaceEnabled><h:PingResponseEnabled>true</h:PingResponseEnabled><h:PowerSource>0</h:PowerSource><h:PreferredAddressFamily>0</h:PreferredAddressFamily><h:PresenceNotificationInterval>0</h:PresenceNotificationInterval><h:PrivacyLevel>0</h:PrivacyLevel><h:RmcpPingResponseEnabled>true</h:RmcpPingResponseEnabled><h:SharedFQDN>true</h:SharedFQDN><h:ThunderboltDockEnabled>0</h:ThunderboltDockEnabled><h:WsmanOnlyMode>false</h:WsmanOnlyMode></h:AMT_GeneralSettings>`
+
+func TestGet(t *testing.T) {
+	server, requests := newTestServer(t, func(r testRequest) (int, string) {
+		return http.StatusOK, envelope(generalSettingsBody)
+	})
+	client := NewClient(server.URL+Path, "admin", "P@ssw0rd")
+	settings := AMTGeneralSettings{}
+	err := client.Get(AMTGeneralSettingsURI, nil, &settings)
+	assert.NoError(t, err)
+	assert.Equal(t, "DESKTOP-1", settings.HostName)
+	assert.Equal(t, "vprodemo.com", settings.DomainName)
+	assert.Equal(t, true, settings.SharedFQDN)
+	assert.Equal(t, 65535, settings.IdleWakeTimeout)
+	assert.Equal(t, ActionGet, (*requests)[0].Action)
+	assert.Equal(t, AMTGeneralSettingsURI, (*requests)[0].ResourceURI)
+}
+
+func TestGetWithSelectors(t *testing.T) {
+	server, requests := newTestServer(t, func(r testRequest) (int, string) {
+		return http.StatusOK, envelope(`<i:AMT_EthernetPortSettings><i:DHCPEnabled>true</i:DHCPEnabled><i:ElementName>Intel(r) AMT Ethernet Port Settings</i:ElementName><i:InstanceID>Intel(r) AMT Ethernet Port Settings 0</i:InstanceID><i:IpSyncEnabled>true</i:IpSyncEnabled><i:LinkIsUp>true</i:LinkIsUp><i:LinkPolicy>1</i:LinkPolicy><i:LinkPolicy>14</i:LinkPolicy><i:MACAddress>a4-bb-6d-89-52-e4</i:MACAddress><i:PhysicalConnectionType>0</i:PhysicalConnectionType><i:SharedDynamicIP>true</i:SharedDynamicIP><i:SharedMAC>true</i:SharedMAC><i:SharedStaticIp>false</i:SharedStaticIp></i:AMT_EthernetPortSettings>`)
+	})
+	client := NewClient(server.URL+Path, "admin", "P@ssw0rd")
+	settings := AMTEthernetPortSettings{}
+	err := client.Get(AMTEthernetPortSettingsURI, []Selector{{Name: "InstanceID", Value: "Intel(r) AMT Ethernet Port Settings 0"}}, &settings)
+	assert.NoError(t, err)
+	assert.Equal(t, "a4-bb-6d-89-52-e4", settings.MACAddress)
+	assert.Equal(t, []int{1, 14}, settings.LinkPolicy)
+	assert.Equal(t, "Intel(r) AMT Ethernet Port Settings 0", (*requests)[0].Selectors["InstanceID"])
+}
+
+func TestPut(t *testing.T) {
+	server, requests := newTestServer(t, func(r testRequest) (int, string) {
+		return http.StatusOK, envelope(generalSettingsBody)
+	})
+	client := NewClient(server.URL+Path, "admin", "P@ssw0rd")
+	settings := AMTGeneralSettings{HostName: "DESKTOP-2", DomainName: "vprodemo.com"}
+	result := AMTGeneralSettings{}
+	err := client.Put(AMTGeneralSettingsURI, nil, settings, &result)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionPut, (*requests)[0].Action)
+	assert.Contains(t, (*requests)[0].Body, `<AMT_GeneralSettings xmlns="`+AMTGeneralSettingsURI+`">`)
+	assert.Contains(t, (*requests)[0].Body, `<HostName>DESKTOP-2</HostName>`)
+	assert.Equal(t, "DESKTOP-1", result.HostName)
+}
+
+func TestDelete(t *testing.T) {
+	server, requests := newTestServer(t, func(r testRequest) (int, string) {
+		return http.StatusOK, envelope(``)
+	})
+	client := NewClient(server.URL+Path, "admin", "P@ssw0rd")
+	uri := CIMSchema + "CIM_WiFiEndpointSettings"
+	err := client.Delete(uri, []Selector{{Name: "InstanceID", Value: "Intel(r) AMT:WiFi Endpoint Settings home"}})
+	assert.NoError(t, err)
+	assert.Equal(t, ActionDelete, (*requests)[0].Action)
+	assert.Equal(t, uri, (*requests)[0].ResourceURI)
+	assert.Equal(t, "Intel(r) AMT:WiFi Endpoint Settings home", (*requests)[0].Selectors["InstanceID"])
+	assert.Equal(t, "", (*requests)[0].Body)
+}
+
+type testPowerInput struct {
+	XMLName    xml.Name `xml:"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_PowerManagementService RequestPowerStateChange_INPUT"`
+	PowerState int      `xml:"PowerState"`
+}
+
+func TestInvoke(t *testing.T) {
+	server, requests := newTestServer(t, func(r testRequest) (int, string) {
+		return http.StatusOK, envelope(`<g:RequestPowerStateChange_OUTPUT xmlns:g="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_PowerManagementService"><g:ReturnValue>0</g:ReturnValue></g:RequestPowerStateChange_OUTPUT>`)
+	})
+	client := NewClient(server.URL+Path, "admin", "P@ssw0rd")
+	output := MethodOutput{}
+	uri := CIMSchema + "CIM_PowerManagementService"
+	err := client.Invoke(uri, "RequestPowerStateChange", nil, testPowerInput{PowerState: 10}, &output)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, output.ReturnValue)
+	assert.Equal(t, uri+"/RequestPowerStateChange", (*requests)[0].Action)
+	assert.Contains(t, (*requests)[0].Body, "<PowerState>10</PowerState>")
+}
+
+func TestEnumerateAll(t *testing.T) {
+	pulls := 0
+	server, requests := newTestServer(t, func(r testRequest) (int, string) {
+		switch r.Action {
+		case ActionEnumerate:
+			return http.StatusOK, envelope(`<g:EnumerateResponse><g:EnumerationContext>01000000-0000-0000-0000-000000000000</g:EnumerationContext></g:EnumerateResponse>`)
+		case ActionPull:
+			pulls++
+			if pulls == 1 {
+				return http.StatusOK, envelope(`<g:PullResponse><g:EnumerationContext>02000000-0000-0000-0000-000000000000</g:EnumerationContext><g:Items><j:CIM_SoftwareIdentity xmlns:j="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_SoftwareIdentity"><j:InstanceID>Flash</j:InstanceID><j:IsEntity>true</j:IsEntity><j:VersionString>15.0.30</j:VersionString></j:CIM_SoftwareIdentity></g:Items></g:PullResponse>`)
+			}
+			return http.StatusOK, envelope(`<g:PullResponse><g:Items><j:CIM_SoftwareIdentity xmlns:j="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_SoftwareIdentity"><j:InstanceID>Netstack</j:InstanceID><j:IsEntity>true</j:IsEntity><j:VersionString>15.0.30</j:VersionString></j:CIM_SoftwareIdentity></g:Items><g:EndOfSequence></g:EndOfSequence></g:PullResponse>`)
+		}
+		return http.StatusBadRequest, ""
+	})
+	client := NewClient(server.URL+Path, "admin", "P@ssw0rd")
+	items, err := client.EnumerateAll(CIMSoftwareIdentityURI)
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+	identity := CIMSoftwareIdentity{}
+	assert.NoError(t, items[1].Decode(&identity))
+	assert.Equal(t, "Netstack", identity.InstanceID)
+	assert.Equal(t, "15.0.30", identity.VersionString)
+	assert.Equal(t, true, identity.IsEntity)
+	assert.Contains(t, (*requests)[1].Body, "01000000-0000-0000-0000-000000000000")
+	assert.Contains(t, (*requests)[2].Body, "02000000-0000-0000-0000-000000000000")
+}
+
+func TestFault(t *testing.T) {
+	server, _ := newTestServer(t, func(r testRequest) (int, string) {
+		return http.StatusBadRequest, envelope(`<a:Fault><a:Code><a:Value>a:Sender</a:Value><a:Subcode><a:Value>b:DestinationUnreachable</a:Value></a:Subcode></a:Code><a:Reason><a:Text xml:lang="en-US">No route can be determined to reach the destination role defined by the WS-Addressing To.</a:Text></a:Reason><a:Detail></a:Detail></a:Fault>`)
+	})
+	client := NewClient(server.URL+Path, "admin", "P@ssw0rd")
+	err := client.Get(AMTGeneralSettingsURI, nil, &AMTGeneralSettings{})
+	fault, ok := err.(*Fault)
+	assert.True(t, ok)
+	assert.Equal(t, "b:DestinationUnreachable", fault.Subcode)
+	assert.Equal(t, "wsman fault DestinationUnreachable: No route can be determined to reach the destination role defined by the WS-Addressing To.", err.Error())
+}
+
+func TestWrongPassword(t *testing.T) {
+	server, _ := newTestServer(t, func(r testRequest) (int, string) {
+		return http.StatusOK, envelope(generalSettingsBody)
+	})
+	client := NewClient(server.URL+Path, "admin", "wrong")
+	err := client.Get(AMTGeneralSettingsURI, nil, &AMTGeneralSettings{})
+	assert.EqualError(t, err, "wsman: authentication failed, check the AMT username and password")
+}
+
+func TestUnexpectedStatus(t *testing.T) {
+	server, _ := newTestServer(t, func(r testRequest) (int, string) {
+		return http.StatusInternalServerError, "internal error"
+	})
+	client := NewClient(server.URL+Path, "admin", "P@ssw0rd")
+	err := client.Get(AMTGeneralSettingsURI, nil, &AMTGeneralSettings{})
+	assert.EqualError(t, err, "wsman: unexpected HTTP status 500 Internal Server Error")
+}
+
+func TestRequestEnvelope(t *testing.T) {
+	r := request{Action: ActionGet, ResourceURI: AMTGeneralSettingsURI, Selectors: []Selector{{Name: "InstanceID", Value: "a<b"}}}
+	data := string(r.marshal("/wsman", "uuid:1"))
+	assert.Contains(t, data, `<a:Action s:mustUnderstand="true">`+ActionGet+`</a:Action>`)
+	assert.Contains(t, data, `<w:Selector Name="InstanceID">a&lt;b</w:Selector>`)
+	assert.Contains(t, data, `<s:Body/>`)
+	assert.NoError(t, xml.Unmarshal([]byte(data), &testEnvelope{}))
+}
+
+func TestNewMessageID(t *testing.T) {
+	assert.Regexp(t, `^uuid:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`, newMessageID())
+}
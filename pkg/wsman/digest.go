@@ -0,0 +1,140 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package wsman
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// challenge holds the parameters of a WWW-Authenticate: Digest header
+type challenge struct {
+	Realm     string
+	Nonce     string
+	Opaque    string
+	QOP       string
+	Algorithm string
+	Stale     bool
+}
+
+// digestAuth computes Authorization headers for RFC 2617 digest authentication as AMT implements it
+type digestAuth struct {
+	Username   string
+	Password   string
+	mutex      sync.Mutex
+	challenge  *challenge
+	nonceCount uint32
+}
+
+// parseChallenge reads a WWW-Authenticate header, accepting only MD5 digest
+func parseChallenge(header string) (*challenge, error) {
+	if !strings.HasPrefix(strings.ToLower(header), "digest ") {
+		return nil, errors.New("wsman: server does not offer digest authentication")
+	}
+	c := &challenge{}
+	for _, param := range splitParams(header[len("digest "):]) {
+		parts := strings.SplitN(param, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value := strings.Trim(strings.TrimSpace(parts[1]), "\"")
+		switch strings.ToLower(strings.TrimSpace(parts[0])) {
+		case "realm":
+			c.Realm = value
+		case "nonce":
+			c.Nonce = value
+		case "opaque":
+			c.Opaque = value
+		case "qop":
+			// prefer auth when the server offers a list such as "auth,auth-int"
+			for _, qop := range strings.Split(value, ",") {
+				if strings.TrimSpace(qop) == "auth" {
+					c.QOP = "auth"
+				}
+			}
+		case "algorithm":
+			c.Algorithm = value
+		case "stale":
+			c.Stale = strings.EqualFold(value, "true")
+		}
+	}
+	if c.Nonce == "" {
+		return nil, errors.New("wsman: digest challenge has no nonce")
+	}
+	if c.Algorithm != "" && !strings.EqualFold(c.Algorithm, "MD5") {
+		return nil, errors.New("wsman: unsupported digest algorithm " + c.Algorithm)
+	}
+	return c, nil
+}
+
+// splitParams splits comma separated parameters, ignoring commas inside quotes
+func splitParams(value string) []string {
+	params := []string{}
+	quoted := false
+	start := 0
+	for i, r := range value {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			params = append(params, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(params, value[start:])
+}
+
+// update stores a new challenge and resets the nonce count
+func (d *digestAuth) update(c *challenge) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.challenge = c
+	d.nonceCount = 0
+}
+
+// authorization returns the Authorization header for method and uri, or "" before the first challenge
+func (d *digestAuth) authorization(method string, uri string) string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.challenge == nil {
+		return ""
+	}
+	c := d.challenge
+	ha1 := md5Hex(d.Username + ":" + c.Realm + ":" + d.Password)
+	ha2 := md5Hex(method + ":" + uri)
+	header := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s"`, d.Username, c.Realm, c.Nonce, uri)
+	if c.QOP == "auth" {
+		d.nonceCount++
+		nc := fmt.Sprintf("%08x", d.nonceCount)
+		cnonce := randomHex(8)
+		response := md5Hex(ha1 + ":" + c.Nonce + ":" + nc + ":" + cnonce + ":" + c.QOP + ":" + ha2)
+		header = header + fmt.Sprintf(`, qop=%s, nc=%s, cnonce="%s", response="%s"`, c.QOP, nc, cnonce, response)
+	} else {
+		header = header + fmt.Sprintf(`, response="%s"`, md5Hex(ha1+":"+c.Nonce+":"+ha2))
+	}
+	if c.Opaque != "" {
+		header = header + fmt.Sprintf(`, opaque="%s"`, c.Opaque)
+	}
+	if c.Algorithm != "" {
+		header = header + ", algorithm=" + c.Algorithm
+	}
+	return header
+}
+
+func md5Hex(value string) string {
+	sum := md5.Sum([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(size int) string {
+	b := make([]byte, size)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,43 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package wsman
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseChallenge(t *testing.T) {
+	c, err := parseChallenge(`Digest realm="Digest:A3829B3827DE4D33D4449B366831B8E5", nonce="3D0TBBkAAAAAAAAAAAAAAA==", stale="false", qop="auth,auth-int", opaque="x,y"`)
+	assert.NoError(t, err)
+	assert.Equal(t, "Digest:A3829B3827DE4D33D4449B366831B8E5", c.Realm)
+	assert.Equal(t, "3D0TBBkAAAAAAAAAAAAAAA==", c.Nonce)
+	assert.Equal(t, "auth", c.QOP)
+	assert.Equal(t, "x,y", c.Opaque)
+	assert.False(t, c.Stale)
+}
+
+func TestParseChallengeErrors(t *testing.T) {
+	_, err := parseChallenge(`Basic realm="AMT"`)
+	assert.Error(t, err)
+	_, err = parseChallenge(`Digest realm="AMT"`)
+	assert.Error(t, err)
+	_, err = parseChallenge(`Digest realm="AMT", nonce="abc", algorithm=SHA-256`)
+	assert.Error(t, err)
+}
+
+func TestAuthorization(t *testing.T) {
+	auth := digestAuth{Username: "admin", Password: "P@ssw0rd"}
+	assert.Equal(t, "", auth.authorization("POST", "/wsman"))
+	auth.update(&challenge{Realm: "AMT", Nonce: "abc"})
+	// without qop the response is md5(HA1:nonce:HA2)
+	expected := md5Hex(md5Hex("admin:AMT:P@ssw0rd") + ":abc:" + md5Hex("POST:/wsman"))
+	assert.Equal(t, `Digest username="admin", realm="AMT", nonce="abc", uri="/wsman", response="`+expected+`"`, auth.authorization("POST", "/wsman"))
+
+	auth.update(&challenge{Realm: "AMT", Nonce: "abc", QOP: "auth"})
+	assert.Contains(t, auth.authorization("POST", "/wsman"), "nc=00000001")
+	assert.Contains(t, auth.authorization("POST", "/wsman"), "nc=00000002")
+}
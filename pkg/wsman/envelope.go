@@ -0,0 +1,222 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2021
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package wsman
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Namespaces used in WS-Management messages
+const (
+	NSSoap        = "http://www.w3.org/2003/05/soap-envelope"
+	NSAddressing  = "http://schemas.xmlsoap.org/ws/2004/08/addressing"
+	NSWSMan       = "http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd"
+	NSEnumeration = "http://schemas.xmlsoap.org/ws/2004/09/enumeration"
+	NSTransfer    = "http://schemas.xmlsoap.org/ws/2004/09/transfer"
+	AnonymousRole = "http://schemas.xmlsoap.org/ws/2004/08/addressing/role/anonymous"
+)
+
+// Actions for the generic WS-Transfer and WS-Enumeration operations
+const (
+	ActionGet       = NSTransfer + "/Get"
+	ActionPut       = NSTransfer + "/Put"
+	ActionDelete    = NSTransfer + "/Delete"
+	ActionEnumerate = NSEnumeration + "/Enumerate"
+	ActionPull      = NSEnumeration + "/Pull"
+)
+
+// Selector identifies one instance of a class
+type Selector struct {
+	Name  string `xml:"Name,attr"`
+	Value string `xml:",chardata"`
+}
+
+// EndpointReference refers to an instance by its resource URI and selectors, for example as a method parameter
+type EndpointReference struct {
+	Address             string              `xml:"http://schemas.xmlsoap.org/ws/2004/08/addressing Address"`
+	ReferenceParameters ReferenceParameters `xml:"http://schemas.xmlsoap.org/ws/2004/08/addressing ReferenceParameters"`
+}
+
+// ReferenceParameters identifies the instance an EndpointReference refers to
+type ReferenceParameters struct {
+	ResourceURI string      `xml:"http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd ResourceURI"`
+	SelectorSet SelectorSet `xml:"http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd SelectorSet"`
+}
+
+// SelectorSet holds the selectors of a reference
+type SelectorSet struct {
+	Selectors []Selector `xml:"http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd Selector"`
+}
+
+// NewReference creates an EndpointReference to the instance of resourceURI identified by selectors
+func NewReference(resourceURI string, selectors ...Selector) EndpointReference {
+	return EndpointReference{
+		Address:             AnonymousRole,
+		ReferenceParameters: ReferenceParameters{ResourceURI: resourceURI, SelectorSet: SelectorSet{Selectors: selectors}},
+	}
+}
+
+// request is a single WS-Management message
+type request struct {
+	Action      string
+	ResourceURI string
+	Selectors   []Selector
+	Body        []byte
+}
+
+// Item is a single instance returned by Pull, with every element qualified by its namespace
+type Item []byte
+
+// Decode unmarshals the item into a class struct
+func (i Item) Decode(v interface{}) error {
+	return xml.Unmarshal(i, v)
+}
+
+// Fault is a SOAP fault returned by AMT
+type Fault struct {
+	Code    string `xml:"Code>Value"`
+	Subcode string `xml:"Code>Subcode>Value"`
+	Reason  string `xml:"Reason>Text"`
+	Detail  string `xml:"Detail>FaultDetail"`
+}
+
+func (f *Fault) Error() string {
+	message := "wsman fault " + localName(f.Subcode)
+	if f.Subcode == "" {
+		message = "wsman fault " + localName(f.Code)
+	}
+	if f.Reason != "" {
+		message = message + ": " + strings.TrimSpace(f.Reason)
+	}
+	if f.Detail != "" {
+		message = message + " (" + localName(f.Detail) + ")"
+	}
+	return message
+}
+
+// localName drops the namespace prefix or URI from a fault code
+func localName(value string) string {
+	value = strings.TrimSpace(value)
+	if index := strings.LastIndexAny(value, ":/"); index >= 0 {
+		return value[index+1:]
+	}
+	return value
+}
+
+// marshal writes the SOAP envelope for r
+func (r request) marshal(to string, messageID string) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?>`)
+	b.WriteString(`<s:Envelope xmlns:s="` + NSSoap + `" xmlns:a="` + NSAddressing + `" xmlns:w="` + NSWSMan + `" xmlns:n="` + NSEnumeration + `">`)
+	b.WriteString(`<s:Header>`)
+	b.WriteString(`<a:Action s:mustUnderstand="true">` + escape(r.Action) + `</a:Action>`)
+	b.WriteString(`<a:To s:mustUnderstand="true">` + escape(to) + `</a:To>`)
+	b.WriteString(`<w:ResourceURI s:mustUnderstand="true">` + escape(r.ResourceURI) + `</w:ResourceURI>`)
+	b.WriteString(`<a:MessageID s:mustUnderstand="true">` + escape(messageID) + `</a:MessageID>`)
+	b.WriteString(`<a:ReplyTo><a:Address>` + AnonymousRole + `</a:Address></a:ReplyTo>`)
+	b.WriteString(`<w:OperationTimeout>PT60S</w:OperationTimeout>`)
+	if len(r.Selectors) > 0 {
+		b.WriteString(`<w:SelectorSet>`)
+		for _, selector := range r.Selectors {
+			b.WriteString(`<w:Selector Name="` + escape(selector.Name) + `">` + escape(selector.Value) + `</w:Selector>`)
+		}
+		b.WriteString(`</w:SelectorSet>`)
+	}
+	b.WriteString(`</s:Header>`)
+	if len(r.Body) > 0 {
+		b.WriteString(`<s:Body>`)
+		b.Write(r.Body)
+		b.WriteString(`</s:Body>`)
+	} else {
+		b.WriteString(`<s:Body/>`)
+	}
+	b.WriteString(`</s:Envelope>`)
+	return b.Bytes()
+}
+
+func escape(value string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+// bodyDecoder positions a decoder on the first element inside the SOAP body.
+// It returns the fault as an error when the body holds one, and a nil start element for an empty body.
+func bodyDecoder(data []byte) (*xml.Decoder, *xml.StartElement, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	inBody := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, nil, errors.New("wsman: response has no SOAP body")
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("wsman: invalid response: %v", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if !inBody {
+				inBody = t.Name.Space == NSSoap && t.Name.Local == "Body"
+				continue
+			}
+			if t.Name.Space == NSSoap && t.Name.Local == "Fault" {
+				fault := &Fault{}
+				if err := decoder.DecodeElement(fault, &t); err != nil {
+					return nil, nil, fmt.Errorf("wsman: invalid fault: %v", err)
+				}
+				return nil, nil, fault
+			}
+			return decoder, &t, nil
+		case xml.EndElement:
+			if inBody {
+				return decoder, nil, nil
+			}
+		}
+	}
+}
+
+// node captures an element with its namespaces resolved so it can be written back out on its own
+type node struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Text    string     `xml:",chardata"`
+	Nodes   []node     `xml:",any"`
+}
+
+// item re-encodes the captured element, dropping the prefix declarations that xml.Marshal regenerates
+func (n node) item() (Item, error) {
+	n.strip()
+	return xml.Marshal(n)
+}
+
+func (n *node) strip() {
+	attrs := []xml.Attr{}
+	for _, attr := range n.Attrs {
+		if attr.Name.Space != "xmlns" && !(attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+			attrs = append(attrs, attr)
+		}
+	}
+	n.Attrs = attrs
+	if len(n.Nodes) > 0 {
+		n.Text = ""
+	}
+	for i := range n.Nodes {
+		n.Nodes[i].strip()
+	}
+}
+
+// pullResponse is the body of an enumerate or pull response
+type pullResponse struct {
+	EnumerationContext string `xml:"EnumerationContext"`
+	Items              struct {
+		Nodes []node `xml:",any"`
+	} `xml:"Items"`
+	EndOfSequence *struct{} `xml:"EndOfSequence"`
+}